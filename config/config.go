@@ -0,0 +1,294 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings of the service
+type Config struct {
+	Server    ServerConfig    `json:"server" yaml:"server"`
+	Database  DatabaseConfig  `json:"database" yaml:"database"`
+	Storage   StorageConfig   `json:"storage" yaml:"storage"`
+	Landmarks LandmarksConfig `json:"landmarks" yaml:"landmarks"`
+	Tiles     TilesConfig     `json:"tiles" yaml:"tiles"`
+	OSM       OSMConfig       `json:"osm" yaml:"osm"`
+}
+
+// ServerConfig holds the settings of the HTTP server
+type ServerConfig struct {
+	Addr string `json:"addr" yaml:"addr"`
+}
+
+// DatabaseConfig holds the settings of the database connection
+type DatabaseConfig struct {
+	Driver  string `json:"driver" yaml:"driver"`
+	DSN     string `json:"dsn" yaml:"dsn"`
+	LogMode bool   `json:"log_mode" yaml:"log_mode"`
+}
+
+// StorageConfig holds the settings of the photo storage backend
+type StorageConfig struct {
+	Backend  string `json:"backend" yaml:"backend"`
+	Bucket   string `json:"bucket" yaml:"bucket"`
+	LocalDir string `json:"local_dir" yaml:"local_dir"`
+	BaseURL  string `json:"base_url" yaml:"base_url"`
+}
+
+// LandmarksConfig holds the settings of landmark placement
+type LandmarksConfig struct {
+	// CityValidation tells what to do when the city_id of a landmark contradicts its
+	// coordinates: off, warn (accept with a Warning header) or reject
+	CityValidation string `json:"city_validation" yaml:"city_validation"`
+	// MaxCityDistanceKm is the largest accepted distance between a landmark and the center
+	// of its city, 0 disables the distance check
+	MaxCityDistanceKm float64 `json:"max_city_distance_km" yaml:"max_city_distance_km"`
+}
+
+// TilesConfig holds the settings of the vector tile cache
+type TilesConfig struct {
+	// Cache selects where rendered tiles are kept: memory, disk or off
+	Cache string `json:"cache" yaml:"cache"`
+	// CacheDir is the directory of the disk cache
+	CacheDir string `json:"cache_dir" yaml:"cache_dir"`
+	// CacheSize is the number of tiles kept by the memory cache
+	CacheSize int `json:"cache_size" yaml:"cache_size"`
+}
+
+// OSMConfig holds the settings of the OpenStreetMap importer
+type OSMConfig struct {
+	// TagRules select the imported features, each one written key=value or key=* for any value
+	TagRules []string `json:"tag_rules" yaml:"tag_rules"`
+	// MaxCityDistanceKm leaves out the features farther from every city, 0 disables the limit
+	MaxCityDistanceKm float64 `json:"max_city_distance_km" yaml:"max_city_distance_km"`
+	// TypeMap maps the types of the features, the values of their tags, to the slugs of the landmark types
+	TypeMap map[string]string `json:"type_map" yaml:"type_map"`
+}
+
+// Default returns the configuration used when nothing else is provided
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr: ":8080",
+		},
+		Database: DatabaseConfig{
+			Driver: "mysql",
+			DSN:    "root@tcp(localhost:3306)/landmarks?charset=utf8mb4&parseTime=True&loc=Local",
+		},
+		Storage: StorageConfig{
+			Backend:  "s3",
+			Bucket:   "golang-backend-photos",
+			LocalDir: "uploads",
+		},
+		Landmarks: LandmarksConfig{
+			CityValidation:    "warn",
+			MaxCityDistanceKm: 50,
+		},
+		Tiles: TilesConfig{
+			Cache:     "memory",
+			CacheDir:  "tile-cache",
+			CacheSize: 10000,
+		},
+		OSM: OSMConfig{
+			TagRules:          []string{"tourism=museum", "tourism=attraction", "tourism=viewpoint", "historic=*"},
+			MaxCityDistanceKm: 50,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the optional file named by
+// CONFIG_FILE and the environment variables, in that order of precedence
+func Load() (Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Storage.Backend == "local" && cfg.Storage.BaseURL == "" {
+		cfg.Storage.BaseURL = "http://localhost" + cfg.Server.Addr + "/files"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadFile reads a YAML or JSON configuration file, chosen by its extension
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// loadEnv overrides the configuration with the environment variables which are set
+func loadEnv(cfg *Config) error {
+	vars := map[string]*string{
+		"SERVER_ADDR":       &cfg.Server.Addr,
+		"DB_DRIVER":         &cfg.Database.Driver,
+		"DB_DSN":            &cfg.Database.DSN,
+		"STORAGE_BACKEND":   &cfg.Storage.Backend,
+		"S3_BUCKET":         &cfg.Storage.Bucket,
+		"STORAGE_LOCAL_DIR": &cfg.Storage.LocalDir,
+		"STORAGE_BASE_URL":  &cfg.Storage.BaseURL,
+		"TILE_CACHE":        &cfg.Tiles.Cache,
+		"TILE_CACHE_DIR":    &cfg.Tiles.CacheDir,
+	}
+	for name, field := range vars {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+
+	// PORT is the common convention of hosting platforms
+	if port, ok := os.LookupEnv("PORT"); ok && os.Getenv("SERVER_ADDR") == "" {
+		cfg.Server.Addr = ":" + port
+	}
+
+	if value, ok := os.LookupEnv("DB_LOG_MODE"); ok {
+		logMode, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid DB_LOG_MODE %q: must be true or false", value)
+		}
+		cfg.Database.LogMode = logMode
+	}
+
+	if value, ok := os.LookupEnv("LANDMARK_CITY_VALIDATION"); ok {
+		cfg.Landmarks.CityValidation = value
+	}
+	if value, ok := os.LookupEnv("LANDMARK_MAX_CITY_DISTANCE_KM"); ok {
+		distance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid LANDMARK_MAX_CITY_DISTANCE_KM %q: must be a number", value)
+		}
+		cfg.Landmarks.MaxCityDistanceKm = distance
+	}
+
+	if value, ok := os.LookupEnv("TILE_CACHE_SIZE"); ok {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid TILE_CACHE_SIZE %q: must be an integer", value)
+		}
+		cfg.Tiles.CacheSize = size
+	}
+
+	if value, ok := os.LookupEnv("OSM_TAG_RULES"); ok {
+		cfg.OSM.TagRules = strings.Split(value, ",")
+	}
+	if value, ok := os.LookupEnv("OSM_MAX_CITY_DISTANCE_KM"); ok {
+		distance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid OSM_MAX_CITY_DISTANCE_KM %q: must be a number", value)
+		}
+		cfg.OSM.MaxCityDistanceKm = distance
+	}
+	return nil
+}
+
+// Validate checks the configuration and reports every invalid setting
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr must not be empty"))
+	} else if !strings.Contains(c.Server.Addr, ":") {
+		errs = append(errs, fmt.Errorf("server.addr %q must have the form host:port", c.Server.Addr))
+	}
+
+	switch c.Database.Driver {
+	case "mysql", "sqlite3":
+	case "":
+		errs = append(errs, errors.New("database.driver must not be empty"))
+	default:
+		errs = append(errs, fmt.Errorf("database.driver %q is not supported, use mysql or sqlite3", c.Database.Driver))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn must not be empty"))
+	}
+
+	switch c.Storage.Backend {
+	case "s3":
+		if c.Storage.Bucket == "" {
+			errs = append(errs, errors.New("storage.bucket is required for the s3 backend"))
+		}
+	case "local":
+		if c.Storage.LocalDir == "" {
+			errs = append(errs, errors.New("storage.local_dir is required for the local backend"))
+		}
+		if c.Storage.BaseURL == "" {
+			errs = append(errs, errors.New("storage.base_url is required for the local backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.backend %q is not supported, use s3 or local", c.Storage.Backend))
+	}
+
+	switch c.Landmarks.CityValidation {
+	case "off", "warn", "reject":
+	default:
+		errs = append(errs, fmt.Errorf("landmarks.city_validation %q is not supported, use off, warn or reject", c.Landmarks.CityValidation))
+	}
+	if c.Landmarks.MaxCityDistanceKm < 0 {
+		errs = append(errs, errors.New("landmarks.max_city_distance_km must not be negative"))
+	}
+
+	switch c.Tiles.Cache {
+	case "memory":
+		if c.Tiles.CacheSize <= 0 {
+			errs = append(errs, errors.New("tiles.cache_size must be positive for the memory cache"))
+		}
+	case "disk":
+		if c.Tiles.CacheDir == "" {
+			errs = append(errs, errors.New("tiles.cache_dir is required for the disk cache"))
+		}
+	case "off":
+	default:
+		errs = append(errs, fmt.Errorf("tiles.cache %q is not supported, use memory, disk or off", c.Tiles.Cache))
+	}
+
+	for _, rule := range c.OSM.TagRules {
+		if key, value, ok := strings.Cut(rule, "="); !ok || strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("osm.tag_rules entry %q must have the form key=value or key=*", rule))
+		}
+	}
+	for featureType, slug := range c.OSM.TypeMap {
+		if strings.TrimSpace(slug) == "" {
+			errs = append(errs, fmt.Errorf("osm.type_map entry %q must map to a landmark type slug", featureType))
+		}
+	}
+	if c.OSM.MaxCityDistanceKm < 0 {
+		errs = append(errs, errors.New("osm.max_city_distance_km must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"landmarksmodule/geo"
+)
+
+// coordinateTables are the tables whose latitude and longitude columns used to hold text
+var coordinateTables = []string{"countries", "cities", "landmarks"}
+
+// numericSuffix names the numeric columns filled while converting textual coordinates
+const numericSuffix = "_numeric"
+
+// migrateCoordinates converts textual latitude and longitude columns to numeric columns. The
+// conversion of a table is refused, listing the offending rows, when some of its values cannot
+// be parsed or lie out of range, since 0, 0 would turn them into a real place.
+func migrateCoordinates() error {
+	for _, table := range coordinateTables {
+		if !DB.HasTable(table) {
+			continue
+		}
+
+		textual, err := hasTextColumn(table, "latitude")
+		if err != nil {
+			return err
+		}
+		if textual {
+			if err := convertCoordinates(table); err != nil {
+				return fmt.Errorf("failed to convert the coordinates of %s: %v", table, err)
+			}
+		}
+
+		// Swap the numeric columns in, also when a previous conversion stopped before doing so
+		for _, column := range []string{"latitude", "longitude"} {
+			if !DB.Dialect().HasColumn(table, column+numericSuffix) {
+				continue
+			}
+			if DB.Dialect().HasColumn(table, column) {
+				if err := DB.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column)).Error; err != nil {
+					return err
+				}
+			}
+			if err := DB.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, column+numericSuffix, column)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasTextColumn reports whether the column exists and holds text
+func hasTextColumn(table, column string) (bool, error) {
+	if !DB.Dialect().HasColumn(table, column) {
+		return false, nil
+	}
+
+	rows, err := DB.DB().Query(fmt.Sprintf("SELECT %s FROM %s LIMIT 1", column, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return false, err
+	}
+	name := strings.ToUpper(types[0].DatabaseTypeName())
+	return strings.Contains(name, "CHAR") || strings.Contains(name, "TEXT"), nil
+}
+
+// textCoordinates is a row of a table being converted
+type textCoordinates struct {
+	ID        uint
+	Latitude  string
+	Longitude string
+}
+
+// convertCoordinates copies the parsed coordinates of every row of table into numeric columns,
+// leaving the table untouched when some of them are invalid
+func convertCoordinates(table string) error {
+	// Soft deleted rows are converted as well
+	var rows []textCoordinates
+	if err := DB.Raw(fmt.Sprintf("SELECT id, COALESCE(latitude, '') AS latitude, COALESCE(longitude, '') AS longitude FROM %s ORDER BY id", table)).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	type coordinates struct{ latitude, longitude float64 }
+	parsed := make([]coordinates, len(rows))
+	var invalid []string
+	for i, row := range rows {
+		latitude, err1 := strconv.ParseFloat(strings.TrimSpace(row.Latitude), 64)
+		longitude, err2 := strconv.ParseFloat(strings.TrimSpace(row.Longitude), 64)
+		if err1 != nil || err2 != nil || geo.ValidateCoordinates(latitude, longitude) != nil {
+			invalid = append(invalid, fmt.Sprintf("id %d (latitude %q, longitude %q)", row.ID, row.Latitude, row.Longitude))
+			continue
+		}
+		parsed[i] = coordinates{latitude, longitude}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%d rows have invalid coordinates, correct them and restart: %s", len(invalid), strings.Join(invalid, ", "))
+	}
+
+	for _, column := range []string{"latitude", "longitude"} {
+		if DB.Dialect().HasColumn(table, column+numericSuffix) {
+			continue
+		}
+		statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s NOT NULL DEFAULT 0", table, column+numericSuffix, SQL.FloatType())
+		if err := DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	tx := DB.Begin()
+	update := fmt.Sprintf("UPDATE %s SET latitude%s = ?, longitude%s = ? WHERE id = ?", table, numericSuffix, numericSuffix)
+	for i, row := range rows {
+		if err := tx.Exec(update, parsed[i].latitude, parsed[i].longitude, row.ID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	log.Printf("Converted the coordinates of %d %s", len(rows), table)
+	return nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+// useTextualLandmarks opens an in-memory database holding a landmarks table of textual coordinates
+func useTextualLandmarks(t *testing.T, rows [][2]string) {
+	t.Helper()
+	database, err := openSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	DB, SQL = database, sqliteDialect{}
+	t.Cleanup(func() { database.Close() })
+
+	if err := DB.Exec("CREATE TABLE landmarks (id INTEGER PRIMARY KEY, name TEXT, latitude VARCHAR(255), longitude VARCHAR(255))").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := DB.Exec("INSERT INTO landmarks (name, latitude, longitude) VALUES ('landmark', ?, ?)", row[0], row[1]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrateCoordinatesConvertsText(t *testing.T) {
+	useTextualLandmarks(t, [][2]string{{"42.2139", "20.7397"}, {" -33.8688 ", "151.2093"}})
+	if err := migrateCoordinates(); err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		ID        uint
+		Latitude  float64
+		Longitude float64
+	}
+	var rows []row
+	if err := DB.Raw("SELECT id, latitude, longitude FROM landmarks ORDER BY id").Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []row{{1, 42.2139, 20.7397}, {2, -33.8688, 151.2093}}
+	if len(rows) != len(want) || rows[0] != want[0] || rows[1] != want[1] {
+		t.Errorf("converted rows %+v, want %+v", rows, want)
+	}
+	if textual, err := hasTextColumn("landmarks", "latitude"); err != nil || textual {
+		t.Errorf("latitude column still textual: %v, %v", textual, err)
+	}
+}
+
+func TestMigrateCoordinatesRefusesInvalidRows(t *testing.T) {
+	useTextualLandmarks(t, [][2]string{{"42.2139", "20.7397"}, {"north", "20.7"}, {"500", "20.7"}, {"", ""}})
+	err := migrateCoordinates()
+	if err == nil {
+		t.Fatal("migration of invalid coordinates succeeded")
+	}
+	for _, part := range []string{"3 rows", `id 2 (latitude "north"`, `id 3 (latitude "500"`, `id 4 (latitude ""`} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("error %q does not mention %s", err, part)
+		}
+	}
+	if strings.Contains(err.Error(), "id 1 ") {
+		t.Errorf("error %q mentions the valid row", err)
+	}
+
+	// The table is left as it was, so that the rows can be corrected before migrating again
+	if DB.Dialect().HasColumn("landmarks", "latitude"+numericSuffix) {
+		t.Error("numeric column added despite the invalid rows")
+	}
+	if textual, err := hasTextColumn("landmarks", "latitude"); err != nil || !textual {
+		t.Errorf("latitude column no longer textual: %v, %v", textual, err)
+	}
+
+	if err := DB.Exec("UPDATE landmarks SET latitude = '42.2', longitude = '20.7' WHERE id > 1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateCoordinates(); err != nil {
+		t.Errorf("migration after correcting the rows failed: %v", err)
+	}
+}
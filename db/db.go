@@ -1,47 +1,87 @@
 package db
 
 import (
-	"errors"
+	"database/sql"
 	"fmt"
 
+	"landmarksmodule/config"
 	"landmarksmodule/models"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
 )
 
 var DB *gorm.DB
 
-func Init() {
+// Init opens the database described by cfg and migrates the schema
+func Init(cfg config.DatabaseConfig) error {
 	var err error
-	DB, err = gorm.Open("mysql", "root@tcp(localhost:3306)/landmarks?charset=utf8mb4&parseTime=True&loc=Local")
+	switch cfg.Driver {
+	case "mysql":
+		DB, err = gorm.Open("mysql", cfg.DSN)
+		SQL = mysqlDialect{}
+	case "sqlite3":
+		DB, err = openSQLite(cfg.DSN)
+		SQL = sqliteDialect{}
+	default:
+		return fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to open %s database: %v", cfg.Driver, err)
 	}
 
-	// Enable debug mode
-	DB.LogMode(true)
+	// Log every SQL statement when enabled
+	DB.LogMode(cfg.LogMode)
 
 	// Run auto migration
-	migrate()
+	if err := migrate(); err != nil {
+		return fmt.Errorf("failed to migrate %s database: %v", cfg.Driver, err)
+	}
+	return nil
 }
 
-func GetGeoJSONByRegionID(regionID uint) (*models.GeoJSON, error) {
-	var geoJSON models.GeoJSON
-	result := DB.Where("region_id = ?", regionID).First(&geoJSON)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, nil // Return nil if no GeoJSON found
-		}
-		return nil, result.Error
+// openSQLite opens a SQLite database file, or an in-memory database for ":memory:"
+func openSQLite(dsn string) (*gorm.DB, error) {
+	sqlDB, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite serializes writes, and every connection to ":memory:" would get its own database
+	sqlDB.SetMaxOpenConns(1)
+
+	database, err := gorm.Open("sqlite3", sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.Exec("PRAGMA foreign_keys = ON").Error; err != nil {
+		return nil, err
 	}
-	return &geoJSON, nil
+	return database, nil
 }
 
-func migrate() {
-	DB.AutoMigrate(&models.Region{}, &models.City{}, &models.Landmark{}, &models.Review{}, &models.GeoJSON{}, &models.LandmarkPhoto{}, &models.ReviewPhoto{}, &models.Country{})
-	DB.Model(&models.City{}).AddForeignKey("region_id", "regions(id)", "RESTRICT", "RESTRICT")
-	DB.Model(&models.Landmark{}).AddForeignKey("city_id", "cities(id)", "RESTRICT", "RESTRICT")
-	DB.Model(&models.Review{}).AddForeignKey("landmark_id", "landmarks(id)", "RESTRICT", "RESTRICT")
+func migrate() error {
+	// Textual coordinates are converted before AutoMigrate indexes the numeric columns
+	if err := migrateCoordinates(); err != nil {
+		return err
+	}
+
+	DB.AutoMigrate(&models.Region{}, &models.City{}, &models.Landmark{}, &models.Review{}, &models.GeoJSON{}, &models.GeoJSONSimplification{}, &models.LandmarkPhoto{}, &models.ReviewPhoto{}, &models.Country{}, &models.LandmarkType{}, &models.LandmarkTypeLabel{}, &models.Translation{})
+	// SQLite cannot add constraints to existing tables
+	if DB.Dialect().GetName() != "sqlite3" {
+		DB.Model(&models.City{}).AddForeignKey("region_id", "regions(id)", "RESTRICT", "RESTRICT")
+		DB.Model(&models.Landmark{}).AddForeignKey("city_id", "cities(id)", "RESTRICT", "RESTRICT")
+		DB.Model(&models.Review{}).AddForeignKey("landmark_id", "landmarks(id)", "RESTRICT", "RESTRICT")
+		DB.Model(&models.LandmarkType{}).AddForeignKey("parent_id", "landmark_types(id)", "RESTRICT", "RESTRICT")
+		DB.Model(&models.LandmarkTypeLabel{}).AddForeignKey("landmark_type_id", "landmark_types(id)", "CASCADE", "RESTRICT")
+	}
+
+	if err := migrateGeoJSON(); err != nil {
+		return err
+	}
+	if err := migrateLandmarkTypes(); err != nil {
+		return err
+	}
 	fmt.Println("Database migrated successfully")
+	return nil
 }
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"landmarksmodule/geo"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect builds the SQL fragments which differ between the supported databases
+type Dialect interface {
+	// FloatType is the column type storing float64 values
+	FloatType() string
+	// Floor rounds a non-negative numeric expression down to an integer
+	Floor(expr string) string
+	// CastText converts a column to text usable with LIKE
+	CastText(column string) string
+	// Distance returns an expression computing the great-circle distance in km between
+	// the point stored in the given columns and the point (lat, lng), along with its arguments
+	Distance(latColumn, lngColumn string, lat, lng float64) (string, []interface{})
+}
+
+// SQL is the dialect of the opened database
+var SQL Dialect
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) FloatType() string {
+	return "DOUBLE"
+}
+
+func (mysqlDialect) Floor(expr string) string {
+	return fmt.Sprintf("FLOOR(%s)", expr)
+}
+
+func (mysqlDialect) CastText(column string) string {
+	return fmt.Sprintf("CAST(%s AS CHAR)", column)
+}
+
+func (mysqlDialect) Distance(latColumn, lngColumn string, lat, lng float64) (string, []interface{}) {
+	expr := fmt.Sprintf("(%f * acos(cos(radians(?)) * cos(radians(%s)) * cos(radians(%s) - radians(?)) + sin(radians(?)) * sin(radians(%s))))",
+		geo.EarthRadiusKm, latColumn, lngColumn, latColumn)
+	return expr, []interface{}{lat, lng, lat}
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) FloatType() string {
+	return "REAL"
+}
+
+// Floor truncates, which rounds down the non-negative values it is used with
+func (sqliteDialect) Floor(expr string) string {
+	return fmt.Sprintf("CAST(%s AS INTEGER)", expr)
+}
+
+func (sqliteDialect) CastText(column string) string {
+	return fmt.Sprintf("CAST(%s AS TEXT)", column)
+}
+
+func (sqliteDialect) Distance(latColumn, lngColumn string, lat, lng float64) (string, []interface{}) {
+	expr := fmt.Sprintf("haversine_km(?, ?, %s, %s)", latColumn, lngColumn)
+	return expr, []interface{}{lat, lng}
+}
+
+// sqliteDriverName is the database/sql driver registering the functions SQLite lacks
+const sqliteDriverName = "sqlite3_landmarks"
+
+func init() {
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("haversine_km", geo.DistanceKm, true)
+		},
+	})
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"math"
+	"testing"
+
+	"landmarksmodule/geo"
+)
+
+func TestSQLiteDistanceMatchesGeo(t *testing.T) {
+	database, err := openSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	points := [][4]float64{
+		{42.2139, 20.7397, 42.2101, 20.7389},
+		{42.6629, 21.1655, 41.9981, 21.4254},
+		{0, 179.9, 0, -179.9},
+		{-33.8688, 151.2093, 51.5074, -0.1278},
+	}
+	for _, p := range points {
+		expr, args := sqliteDialect{}.Distance("?", "?", p[0], p[1])
+		var distance float64
+		if err := database.DB().QueryRow("SELECT "+expr, append(args, p[2], p[3])...).Scan(&distance); err != nil {
+			t.Fatal(err)
+		}
+		if want := geo.DistanceKm(p[0], p[1], p[2], p[3]); math.Abs(distance-want) > 1e-9 {
+			t.Errorf("distance between %v is %f km in SQLite, want %f", p, distance, want)
+		}
+	}
+}
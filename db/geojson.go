@@ -0,0 +1,181 @@
+package db
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+)
+
+// legacyBoundaryKeys are the map metadata which used to be stored inside the GeoJSON documents
+var legacyBoundaryKeys = []string{"middle_point", "zoom"}
+
+// migrateGeoJSON normalizes the boundaries stored before uploads were validated, removing the
+// metadata mixed into them, then fills the missing map views. Documents which are not valid GeoJSON
+// are reported and left as they are. Finally the boundaries are simplified for the lower zoom levels.
+func migrateGeoJSON() error {
+	var records []models.GeoJSON
+	if err := DB.Unscoped().Where("geo_json_data LIKE ?", `%"middle_point"%`).Find(&records).Error; err != nil {
+		return err
+	}
+
+	migrated := 0
+	for _, record := range records {
+		var document map[string]interface{}
+		if err := json.Unmarshal([]byte(record.GeoJSONData), &document); err != nil {
+			log.Printf("GeoJSON %d of region %d is not valid JSON, leaving it unchanged: %v", record.ID, record.RegionID, err)
+			continue
+		}
+		for _, key := range legacyBoundaryKeys {
+			delete(document, key)
+		}
+
+		normalized, err := geo.NormalizeBoundary(document)
+		if err != nil {
+			log.Printf("GeoJSON %d of region %d cannot be normalized, leaving it unchanged: %v", record.ID, record.RegionID, err)
+			continue
+		}
+		data, err := json.Marshal(normalized)
+		if err != nil {
+			return err
+		}
+		if err := DB.Model(&record).UpdateColumn("geo_json_data", string(data)).Error; err != nil {
+			return err
+		}
+		migrated++
+	}
+
+	if len(records) > 0 {
+		log.Printf("Normalized %d of %d stored GeoJSON boundaries", migrated, len(records))
+	}
+	return migrateMapViews()
+}
+
+// legacyMiddlePoint is the textual middle_point column replaced by the map view columns
+type legacyMiddlePoint struct {
+	ID          uint
+	MiddlePoint string
+}
+
+// migrateMapViews fills the map view of the boundaries stored without one. A middle point saved in the
+// former textual column as "[longitude, latitude]" is kept, the rest is computed from the boundary.
+func migrateMapViews() error {
+	legacy := map[uint]string{}
+	hasLegacyColumn := DB.Dialect().HasColumn("geo_jsons", "middle_point")
+	if hasLegacyColumn {
+		var rows []legacyMiddlePoint
+		if err := DB.Raw("SELECT id, COALESCE(middle_point, '') AS middle_point FROM geo_jsons").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			legacy[row.ID] = row.MiddlePoint
+		}
+	}
+
+	// A boundary always has a bounding box with some extent
+	var records []models.GeoJSON
+	if err := DB.Unscoped().Where("bbox_min_latitude = 0 AND bbox_max_latitude = 0 AND bbox_min_longitude = 0 AND bbox_max_longitude = 0").Find(&records).Error; err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		shape, err := geo.ParseShape([]byte(record.GeoJSONData))
+		if err != nil {
+			log.Printf("GeoJSON %d of region %d cannot be parsed, leaving its map view empty: %v", record.ID, record.RegionID, err)
+			continue
+		}
+
+		view := models.MapView{
+			BBox: models.BoundingBox{
+				MinLatitude:  shape.BBox.MinLat,
+				MinLongitude: shape.BBox.MinLng,
+				MaxLatitude:  shape.BBox.MaxLat,
+				MaxLongitude: shape.BBox.MaxLng,
+			},
+			Zoom: record.Zoom,
+		}
+		if point, ok := parseLegacyMiddlePoint(legacy[record.ID]); ok {
+			view.MiddlePoint = point
+		} else {
+			centroid := shape.Centroid()
+			view.MiddlePoint = models.Coordinates{Latitude: centroid.Lat, Longitude: centroid.Lng}
+		}
+		if view.Zoom == 0 {
+			view.Zoom = float64(geo.DefaultZoom(shape.BBox))
+		}
+
+		if err := DB.Model(&record).UpdateColumns(map[string]interface{}{
+			"middle_latitude":    view.MiddlePoint.Latitude,
+			"middle_longitude":   view.MiddlePoint.Longitude,
+			"bbox_min_latitude":  view.BBox.MinLatitude,
+			"bbox_min_longitude": view.BBox.MinLongitude,
+			"bbox_max_latitude":  view.BBox.MaxLatitude,
+			"bbox_max_longitude": view.BBox.MaxLongitude,
+			"zoom":               view.Zoom,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if len(records) > 0 {
+		log.Printf("Computed the map view of %d stored GeoJSON boundaries", len(records))
+	}
+
+	if hasLegacyColumn {
+		if err := DB.Exec("ALTER TABLE geo_jsons DROP COLUMN middle_point").Error; err != nil {
+			return err
+		}
+	}
+	return migrateSimplifications()
+}
+
+// migrateSimplifications simplifies the boundaries saved before simplifications were stored
+func migrateSimplifications() error {
+	var records []models.GeoJSON
+	if err := DB.Where("id NOT IN (SELECT geo_json_id FROM geo_json_simplifications)").Find(&records).Error; err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		levels, err := geo.SimplifyLevels([]byte(record.GeoJSONData))
+		if err != nil {
+			log.Printf("GeoJSON %d of region %d cannot be simplified: %v", record.ID, record.RegionID, err)
+			continue
+		}
+
+		tx := DB.Begin()
+		for _, zoom := range geo.SimplifiedZooms {
+			simplification := models.GeoJSONSimplification{GeoJSONID: record.ID, Zoom: zoom, GeoJSONData: string(levels[zoom])}
+			if err := tx.Create(&simplification).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		if err := tx.Commit().Error; err != nil {
+			return err
+		}
+	}
+	if len(records) > 0 {
+		log.Printf("Simplified %d stored GeoJSON boundaries", len(records))
+	}
+	return nil
+}
+
+// parseLegacyMiddlePoint parses a middle point formatted as "[longitude, latitude]"
+func parseLegacyMiddlePoint(value string) (models.Coordinates, bool) {
+	parts := strings.Split(strings.Trim(strings.TrimSpace(value), "[]"), ",")
+	if len(parts) != 2 {
+		return models.Coordinates{}, false
+	}
+	longitude, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	latitude, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil || geo.ValidateCoordinates(latitude, longitude) != nil {
+		return models.Coordinates{}, false
+	}
+	if latitude == 0 && longitude == 0 {
+		return models.Coordinates{}, false
+	}
+	return models.Coordinates{Latitude: latitude, Longitude: longitude}, true
+}
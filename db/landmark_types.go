@@ -0,0 +1,77 @@
+package db
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"landmarksmodule/models"
+)
+
+// typeSpelling is a distinct value of the type column of the landmarks
+type typeSpelling struct {
+	Type  string
+	Count int
+}
+
+// migrateLandmarkTypes maps the free-form types of the landmarks to the taxonomy. The spellings of
+// a type sharing a slug, such as Museum, museum and Museums, are rewritten to that slug, and a
+// type is created for every slug missing from the taxonomy, named after its most frequent spelling.
+// Deleted landmarks are migrated as well, in case they are restored.
+func migrateLandmarkTypes() error {
+	var spellings []typeSpelling
+	err := DB.Raw("SELECT type, COUNT(*) AS count FROM landmarks WHERE type IS NOT NULL AND type <> '' GROUP BY type").Scan(&spellings).Error
+	if err != nil {
+		return err
+	}
+	// The most frequent spelling of a slug comes first
+	sort.Slice(spellings, func(i, j int) bool {
+		if spellings[i].Count != spellings[j].Count {
+			return spellings[i].Count > spellings[j].Count
+		}
+		return spellings[i].Type < spellings[j].Type
+	})
+
+	var existing []models.LandmarkType
+	if err := DB.Find(&existing).Error; err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, landmarkType := range existing {
+		known[landmarkType.Slug] = true
+	}
+
+	tx := DB.Begin()
+	created, rewritten := 0, 0
+	for _, spelling := range spellings {
+		slug := models.LandmarkTypeSlug(spelling.Type)
+		if slug == "" {
+			log.Printf("Landmark type %q has no letters or digits, leaving it unchanged", spelling.Type)
+			continue
+		}
+		if !known[slug] {
+			known[slug] = true
+			landmarkType := models.LandmarkType{Slug: slug, Name: strings.TrimSpace(spelling.Type)}
+			if err := tx.Create(&landmarkType).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			created++
+		}
+		if spelling.Type != slug {
+			if err := tx.Exec("UPDATE landmarks SET type = ? WHERE type = ?", slug, spelling.Type).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			rewritten += spelling.Count
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	if created > 0 || rewritten > 0 {
+		log.Printf("Created %d landmark types and rewrote the type of %d landmarks to their slug", created, rewritten)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// Package export writes places and boundaries in the XML formats read by GPS devices and
+// desktop globes, KML and GPX
+package export
+
+import (
+	"strconv"
+
+	"landmarksmodule/geo"
+)
+
+const (
+	// KMLContentType is the media type of KML documents
+	KMLContentType = "application/vnd.google-earth.kml+xml"
+	// GPXContentType is the media type of GPX documents
+	GPXContentType = "application/gpx+xml"
+)
+
+// Placemark is a named place, located by a point or covering an area
+type Placemark struct {
+	Name        string
+	Description string
+	// Type classifies the place, such as the type of a landmark
+	Type string
+	// Point locates a place, it is nil for an area
+	Point *geo.Point
+	// Polygons cover an area, they are ignored when Point is set
+	Polygons []geo.Polygon
+}
+
+func formatDegrees(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
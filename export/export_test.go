@@ -0,0 +1,223 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"landmarksmodule/geo"
+)
+
+// placemarks are a landmark whose name and description need escaping, and a region of two
+// polygons, the first one with a hole
+var placemarks = []Placemark{
+	{
+		Name:        `Hamam & <Gazi Mehmed Pasha>`,
+		Description: `"Baths" of the 16th century & more`,
+		Type:        "museum",
+		Point:       &geo.Point{Lng: 20.7397, Lat: 42.2139},
+	},
+	{
+		Name: "Prizren",
+		Type: "region",
+		Polygons: []geo.Polygon{
+			{
+				{{Lng: 20, Lat: 42}, {Lng: 21, Lat: 42}, {Lng: 21, Lat: 43}, {Lng: 20, Lat: 43}, {Lng: 20, Lat: 42}},
+				{{Lng: 20.2, Lat: 42.2}, {Lng: 20.2, Lat: 42.4}, {Lng: 20.4, Lat: 42.4}, {Lng: 20.2, Lat: 42.2}},
+			},
+			{
+				{{Lng: 22, Lat: 41.5}, {Lng: 22.5, Lat: 41.5}, {Lng: 22.5, Lat: 41.75}, {Lng: 22, Lat: 41.5}},
+			},
+		},
+	},
+}
+
+// decodedKML holds the members of a KML document read back by the tests
+type decodedKML struct {
+	XMLName    xml.Name `xml:"http://www.opengis.net/kml/2.2 kml"`
+	Name       string   `xml:"Document>name"`
+	Placemarks []struct {
+		Name        string `xml:"name"`
+		Description string `xml:"description"`
+		Data        []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value"`
+		} `xml:"ExtendedData>Data"`
+		Point    string           `xml:"Point>coordinates"`
+		Polygon  *decodedPolygon  `xml:"Polygon"`
+		Polygons []decodedPolygon `xml:"MultiGeometry>Polygon"`
+	} `xml:"Document>Placemark"`
+}
+
+type decodedPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+// decodedGPX holds the members of a GPX document read back by the tests
+type decodedGPX struct {
+	XMLName   xml.Name             `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Version   string               `xml:"version,attr"`
+	Name      string               `xml:"metadata>name"`
+	Waypoints []decodedGPXWaypoint `xml:"wpt"`
+	Tracks    []struct {
+		Name     string `xml:"name"`
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []decodedGPXWaypoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type decodedGPXWaypoint struct {
+	Latitude    float64 `xml:"lat,attr"`
+	Longitude   float64 `xml:"lon,attr"`
+	Name        string  `xml:"name"`
+	Description string  `xml:"desc"`
+	Type        string  `xml:"type"`
+}
+
+// parseKMLCoordinates reads space separated longitude,latitude tuples
+func parseKMLCoordinates(t *testing.T, coordinates string) []geo.Point {
+	t.Helper()
+	var points []geo.Point
+	for _, tuple := range strings.Fields(coordinates) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) != 2 {
+			t.Fatalf("tuple %q is not longitude,latitude", tuple)
+		}
+		lng, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("tuple %q is not numeric", tuple)
+		}
+		points = append(points, geo.Point{Lng: lng, Lat: lat})
+	}
+	return points
+}
+
+func TestWriteKMLRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteKML(&buffer, "Landmarks & <regions>", placemarks); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buffer.String(), xml.Header) {
+		t.Errorf("document does not start with the XML header: %.60s", buffer.String())
+	}
+	if !strings.Contains(buffer.String(), "Hamam &amp; &lt;Gazi Mehmed Pasha&gt;") {
+		t.Errorf("name is not escaped in %s", buffer.String())
+	}
+
+	var document decodedKML
+	if err := xml.Unmarshal(buffer.Bytes(), &document); err != nil {
+		t.Fatalf("%v in %s", err, buffer.String())
+	}
+	if document.Name != "Landmarks & <regions>" || len(document.Placemarks) != 2 {
+		t.Fatalf("document %q has %d placemarks, want 2", document.Name, len(document.Placemarks))
+	}
+
+	landmark := document.Placemarks[0]
+	if landmark.Name != placemarks[0].Name || landmark.Description != placemarks[0].Description {
+		t.Errorf("landmark read back as %q, %q", landmark.Name, landmark.Description)
+	}
+	if len(landmark.Data) != 1 || landmark.Data[0].Name != "type" || landmark.Data[0].Value != "museum" {
+		t.Errorf("landmark has extended data %+v, want its type", landmark.Data)
+	}
+	// KML orders coordinates longitude first
+	if landmark.Point != "20.7397,42.2139" {
+		t.Errorf("landmark at %q, want 20.7397,42.2139", landmark.Point)
+	}
+
+	region := document.Placemarks[1]
+	if region.Polygon != nil || len(region.Polygons) != 2 {
+		t.Fatalf("region has polygon %v and %d polygons, want a MultiGeometry of 2", region.Polygon, len(region.Polygons))
+	}
+	for i, polygon := range placemarks[1].Polygons {
+		decoded := region.Polygons[i]
+		if outer := parseKMLCoordinates(t, decoded.Outer); !reflect.DeepEqual(outer, polygon[0]) {
+			t.Errorf("outer ring of polygon %d read back as %v, want %v", i, outer, polygon[0])
+		}
+		if len(decoded.Inner) != len(polygon)-1 {
+			t.Fatalf("polygon %d has %d holes, want %d", i, len(decoded.Inner), len(polygon)-1)
+		}
+		for j, inner := range decoded.Inner {
+			if hole := parseKMLCoordinates(t, inner); !reflect.DeepEqual(hole, polygon[j+1]) {
+				t.Errorf("hole %d of polygon %d read back as %v, want %v", j, i, hole, polygon[j+1])
+			}
+		}
+	}
+}
+
+func TestWriteKMLSinglePolygon(t *testing.T) {
+	var buffer bytes.Buffer
+	single := Placemark{Name: "Prizren", Polygons: placemarks[1].Polygons[1:]}
+	if err := WriteKML(&buffer, "Prizren", []Placemark{single}); err != nil {
+		t.Fatal(err)
+	}
+	var document decodedKML
+	if err := xml.Unmarshal(buffer.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	region := document.Placemarks[0]
+	if region.Polygon == nil || len(region.Polygons) != 0 || len(region.Data) != 0 {
+		t.Fatalf("region read back as %+v, want a Polygon without extended data", region)
+	}
+	if outer := parseKMLCoordinates(t, region.Polygon.Outer); !reflect.DeepEqual(outer, single.Polygons[0][0]) {
+		t.Errorf("outer ring read back as %v, want %v", outer, single.Polygons[0][0])
+	}
+}
+
+func TestWriteGPXRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteGPX(&buffer, "Landmarks & <regions>", placemarks); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "Hamam &amp; &lt;Gazi Mehmed Pasha&gt;") {
+		t.Errorf("name is not escaped in %s", buffer.String())
+	}
+	// GPX gives the latitude and the longitude as attributes
+	if !strings.Contains(buffer.String(), `<wpt lat="42.2139" lon="20.7397">`) {
+		t.Errorf("waypoint attributes are not lat and lon in %s", buffer.String())
+	}
+
+	var document decodedGPX
+	if err := xml.Unmarshal(buffer.Bytes(), &document); err != nil {
+		t.Fatalf("%v in %s", err, buffer.String())
+	}
+	if document.Version != "1.1" || document.Name != "Landmarks & <regions>" {
+		t.Errorf("document has version %q and name %q", document.Version, document.Name)
+	}
+
+	want := decodedGPXWaypoint{Latitude: 42.2139, Longitude: 20.7397, Name: placemarks[0].Name, Description: placemarks[0].Description, Type: "museum"}
+	if len(document.Waypoints) != 1 || document.Waypoints[0] != want {
+		t.Errorf("waypoints %+v, want %+v", document.Waypoints, want)
+	}
+
+	if len(document.Tracks) != 1 {
+		t.Fatalf("%d tracks, want the region", len(document.Tracks))
+	}
+	track := document.Tracks[0]
+	if track.Name != "Prizren" || track.Type != "region" {
+		t.Errorf("track %q of type %q", track.Name, track.Type)
+	}
+	// Every ring of every polygon is a segment
+	var rings [][]geo.Point
+	for _, polygon := range placemarks[1].Polygons {
+		rings = append(rings, polygon...)
+	}
+	if len(track.Segments) != len(rings) {
+		t.Fatalf("%d segments, want one for each of the %d rings", len(track.Segments), len(rings))
+	}
+	for i, segment := range track.Segments {
+		points := make([]geo.Point, len(segment.Points))
+		for j, p := range segment.Points {
+			points[j] = geo.Point{Lng: p.Longitude, Lat: p.Latitude}
+		}
+		if !reflect.DeepEqual(points, rings[i]) {
+			t.Errorf("segment %d read back as %v, want %v", i, points, rings[i])
+		}
+	}
+}
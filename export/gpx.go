@@ -0,0 +1,72 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+const gpxNamespace = "http://www.topografix.com/GPX/1/1"
+
+type gpxDocument struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Namespace string        `xml:"xmlns,attr"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Name      string        `xml:"metadata>name"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+	Tracks    []gpxTrack    `xml:"trk"`
+}
+
+type gpxWaypoint struct {
+	Latitude    string `xml:"lat,attr"`
+	Longitude   string `xml:"lon,attr"`
+	Name        string `xml:"name,omitempty"`
+	Description string `xml:"desc,omitempty"`
+	Type        string `xml:"type,omitempty"`
+}
+
+type gpxTrack struct {
+	Name        string       `xml:"name"`
+	Description string       `xml:"desc,omitempty"`
+	Type        string       `xml:"type,omitempty"`
+	Segments    []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxWaypoint `xml:"trkpt"`
+}
+
+// WriteGPX writes the placemarks as a GPX 1.1 document of the given name. Points become waypoints
+// and areas tracks, with a segment running along every ring of their polygons.
+func WriteGPX(w io.Writer, name string, placemarks []Placemark) error {
+	document := gpxDocument{Namespace: gpxNamespace, Version: "1.1", Creator: "landmarksmodule", Name: name}
+	for _, placemark := range placemarks {
+		if placemark.Point != nil {
+			document.Waypoints = append(document.Waypoints, gpxWaypoint{
+				Latitude:    formatDegrees(placemark.Point.Lat),
+				Longitude:   formatDegrees(placemark.Point.Lng),
+				Name:        placemark.Name,
+				Description: placemark.Description,
+				Type:        placemark.Type,
+			})
+			continue
+		}
+
+		track := gpxTrack{Name: placemark.Name, Description: placemark.Description, Type: placemark.Type}
+		for _, polygon := range placemark.Polygons {
+			for _, ring := range polygon {
+				segment := gpxSegment{Points: make([]gpxWaypoint, len(ring))}
+				for i, p := range ring {
+					segment.Points[i] = gpxWaypoint{Latitude: formatDegrees(p.Lat), Longitude: formatDegrees(p.Lng)}
+				}
+				track.Segments = append(track.Segments, segment)
+			}
+		}
+		document.Tracks = append(document.Tracks, track)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(document)
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"landmarksmodule/geo"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name          string           `xml:"name"`
+	Description   string           `xml:"description,omitempty"`
+	ExtendedData  *kmlExtendedData `xml:"ExtendedData"`
+	Point         *kmlPoint        `xml:"Point"`
+	Polygon       *kmlPolygon      `xml:"Polygon"`
+	MultiGeometry *kmlMultiPolygon `xml:"MultiGeometry"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlMultiPolygon struct {
+	Polygons []kmlPolygon `xml:"Polygon"`
+}
+
+type kmlPolygon struct {
+	Outer kmlRing       `xml:"outerBoundaryIs>LinearRing"`
+	Inner []kmlBoundary `xml:"innerBoundaryIs"`
+}
+
+// kmlBoundary holds a hole, every hole has its own innerBoundaryIs element
+type kmlBoundary struct {
+	Ring kmlRing `xml:"LinearRing"`
+}
+
+type kmlRing struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// WriteKML writes the placemarks as a KML document of the given name. Points become Point
+// placemarks and areas Polygon or MultiGeometry ones, the type goes into the extended data.
+func WriteKML(w io.Writer, name string, placemarks []Placemark) error {
+	document := kmlDocument{Namespace: kmlNamespace, Name: name, Placemarks: make([]kmlPlacemark, 0, len(placemarks))}
+	for _, placemark := range placemarks {
+		out := kmlPlacemark{Name: placemark.Name, Description: placemark.Description}
+		if placemark.Type != "" {
+			out.ExtendedData = &kmlExtendedData{Data: []kmlData{{Name: "type", Value: placemark.Type}}}
+		}
+
+		switch {
+		case placemark.Point != nil:
+			out.Point = &kmlPoint{Coordinates: kmlCoordinates([]geo.Point{*placemark.Point})}
+		case len(placemark.Polygons) == 1:
+			polygon := kmlPolygonOf(placemark.Polygons[0])
+			out.Polygon = &polygon
+		case len(placemark.Polygons) > 1:
+			out.MultiGeometry = &kmlMultiPolygon{Polygons: make([]kmlPolygon, len(placemark.Polygons))}
+			for i, polygon := range placemark.Polygons {
+				out.MultiGeometry.Polygons[i] = kmlPolygonOf(polygon)
+			}
+		}
+		document.Placemarks = append(document.Placemarks, out)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(document)
+}
+
+func kmlPolygonOf(polygon geo.Polygon) kmlPolygon {
+	var out kmlPolygon
+	for i, ring := range polygon {
+		if i == 0 {
+			out.Outer = kmlRing{Coordinates: kmlCoordinates(ring)}
+		} else {
+			out.Inner = append(out.Inner, kmlBoundary{Ring: kmlRing{Coordinates: kmlCoordinates(ring)}})
+		}
+	}
+	return out
+}
+
+// kmlCoordinates formats positions as the space separated longitude,latitude tuples of KML
+func kmlCoordinates(points []geo.Point) string {
+	tuples := make([]string, len(points))
+	for i, p := range points {
+		tuples[i] = formatDegrees(p.Lng) + "," + formatDegrees(p.Lat)
+	}
+	return strings.Join(tuples, " ")
+}
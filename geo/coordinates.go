@@ -0,0 +1,17 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValidateCoordinates checks that a latitude and a longitude given in degrees exist on earth
+func ValidateCoordinates(lat, lng float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude %v must be between -90 and 90", lat)
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("longitude %v must be between -180 and 180", lng)
+	}
+	return nil
+}
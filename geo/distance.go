@@ -0,0 +1,36 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean radius of the earth used for distance calculations
+const EarthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance in km between two points given in degrees
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Around returns the bounding box of the circle of the given radius around a point. The box spans
+// every longitude when the circle reaches a pole or crosses the antimeridian.
+func Around(lat, lng, radiusKm float64) BBox {
+	angular := radiusKm / EarthRadiusKm
+	dLat := angular * 180 / math.Pi
+	box := BBox{
+		MinLng: -180,
+		MinLat: math.Max(lat-dLat, -90),
+		MaxLng: 180,
+		MaxLat: math.Min(lat+dLat, 90),
+	}
+	if box.MinLat > -90 && box.MaxLat < 90 {
+		dLng := math.Asin(math.Sin(angular)/math.Cos(lat*math.Pi/180)) * 180 / math.Pi
+		if lng-dLng >= -180 && lng+dLng <= 180 {
+			box.MinLng, box.MaxLng = lng-dLng, lng+dLng
+		}
+	}
+	return box
+}
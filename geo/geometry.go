@@ -0,0 +1,235 @@
+// Package geo reads the GeoJSON boundaries of regions and answers point-in-polygon queries
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Point is a position in degrees, in the longitude/latitude order used by GeoJSON
+type Point struct {
+	Lng float64
+	Lat float64
+}
+
+// Polygon is a list of linear rings, the first one is the exterior and the others are holes
+type Polygon [][]Point
+
+// BBox is a bounding box in degrees
+type BBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// emptyBBox is the neutral element of BBox.extend
+func emptyBBox() BBox {
+	return BBox{MinLng: math.Inf(1), MinLat: math.Inf(1), MaxLng: math.Inf(-1), MaxLat: math.Inf(-1)}
+}
+
+func (b BBox) extend(p Point) BBox {
+	return BBox{
+		MinLng: math.Min(b.MinLng, p.Lng),
+		MinLat: math.Min(b.MinLat, p.Lat),
+		MaxLng: math.Max(b.MaxLng, p.Lng),
+		MaxLat: math.Max(b.MaxLat, p.Lat),
+	}
+}
+
+// Contains reports whether the point lies within the box, borders included.
+// A box whose MinLng is greater than its MaxLng crosses the antimeridian.
+func (b BBox) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return p.Lng >= b.MinLng || p.Lng <= b.MaxLng
+	}
+	return p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+// Intersects reports whether the boxes overlap, borders included
+func (b BBox) Intersects(other BBox) bool {
+	if b.MinLat > other.MaxLat || other.MinLat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		east := BBox{MinLng: b.MinLng, MinLat: b.MinLat, MaxLng: 180, MaxLat: b.MaxLat}
+		west := BBox{MinLng: -180, MinLat: b.MinLat, MaxLng: b.MaxLng, MaxLat: b.MaxLat}
+		return east.Intersects(other) || west.Intersects(other)
+	}
+	if other.CrossesAntimeridian() {
+		return other.Intersects(b)
+	}
+	return b.MinLng <= other.MaxLng && other.MinLng <= b.MaxLng
+}
+
+// CrossesAntimeridian reports whether the box wraps around longitude 180
+func (b BBox) CrossesAntimeridian() bool {
+	return b.MinLng > b.MaxLng
+}
+
+// Shape is the area covered by a set of polygons
+type Shape struct {
+	Polygons []Polygon
+	BBox     BBox
+}
+
+// ParseShape collects the Polygon and MultiPolygon geometries of a GeoJSON document, which may be
+// a geometry, a Feature, a FeatureCollection or a GeometryCollection. Other geometries are ignored.
+func ParseShape(data []byte) (*Shape, error) {
+	var object geoJSONObject
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	shape := &Shape{BBox: emptyBBox()}
+	if err := shape.add(&object); err != nil {
+		return nil, err
+	}
+	if len(shape.Polygons) == 0 {
+		return nil, errors.New("no Polygon or MultiPolygon geometry found")
+	}
+	return shape, nil
+}
+
+// geoJSONObject holds the members of any GeoJSON object used by ParseShape
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+	Features    []geoJSONObject `json:"features"`
+	Geometries  []geoJSONObject `json:"geometries"`
+}
+
+func (s *Shape) add(object *geoJSONObject) error {
+	switch object.Type {
+	case "FeatureCollection":
+		for i := range object.Features {
+			if err := s.add(&object.Features[i]); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if object.Geometry != nil {
+			return s.add(object.Geometry)
+		}
+	case "GeometryCollection":
+		for i := range object.Geometries {
+			if err := s.add(&object.Geometries[i]); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &coordinates); err != nil {
+			return fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		return s.addPolygon(coordinates)
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(object.Coordinates, &coordinates); err != nil {
+			return fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+		for _, polygon := range coordinates {
+			if err := s.addPolygon(polygon); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Shape) addPolygon(coordinates [][][]float64) error {
+	polygon := make(Polygon, 0, len(coordinates))
+	for _, positions := range coordinates {
+		if len(positions) < 4 {
+			return errors.New("a polygon ring needs at least four positions")
+		}
+		ring := make([]Point, len(positions))
+		for i, position := range positions {
+			if len(position) < 2 {
+				return errors.New("a position needs a longitude and a latitude")
+			}
+			ring[i] = Point{Lng: position[0], Lat: position[1]}
+		}
+		polygon = append(polygon, ring)
+	}
+	if len(polygon) == 0 {
+		return nil
+	}
+
+	// Holes lie within the exterior ring, which alone determines the bounding box
+	for _, p := range polygon[0] {
+		s.BBox = s.BBox.extend(p)
+	}
+	s.Polygons = append(s.Polygons, polygon)
+	return nil
+}
+
+// Contains reports whether the point lies inside one of the polygons of the shape, outside of their holes
+func (s *Shape) Contains(p Point) bool {
+	if !s.BBox.Contains(p) {
+		return false
+	}
+	for _, polygon := range s.Polygons {
+		if polygon.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the point lies inside the exterior ring of the polygon and outside of its holes
+func (polygon Polygon) Contains(p Point) bool {
+	if len(polygon) == 0 || !ringContains(polygon[0], p) {
+		return false
+	}
+	for _, hole := range polygon[1:] {
+		if ringContains(hole, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains tests the point against a closed ring by casting a ray towards increasing longitudes
+func ringContains(ring []Point, p Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Area returns the planar area of the shape in square degrees, holes excluded.
+// It is only meant to compare the sizes of shapes.
+func (s *Shape) Area() float64 {
+	var area float64
+	for _, polygon := range s.Polygons {
+		for i, ring := range polygon {
+			if i == 0 {
+				area += ringArea(ring)
+			} else {
+				area -= ringArea(ring)
+			}
+		}
+	}
+	return area
+}
+
+// ringArea computes the area enclosed by a ring with the shoelace formula
+func ringArea(ring []Point) float64 {
+	var sum float64
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		sum += ring[j].Lng*ring[i].Lat - ring[i].Lng*ring[j].Lat
+	}
+	return math.Abs(sum) / 2
+}
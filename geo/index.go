@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// cellSize is the size in degrees of the cells of the grid of an Index
+const cellSize = 1.0
+
+type cell struct {
+	x int
+	y int
+}
+
+func cellOf(lng, lat float64) cell {
+	return cell{x: int(math.Floor(lng / cellSize)), y: int(math.Floor(lat / cellSize))}
+}
+
+type indexEntry struct {
+	id    uint
+	shape *Shape
+	area  float64
+}
+
+// Index finds the shapes containing a point. Shapes are registered in the cells of a
+// regular grid covered by their bounding box, so that a lookup only tests the shapes
+// of the cell of the point. An Index must not be modified while it is being queried.
+type Index struct {
+	entries []indexEntry
+	cells   map[cell][]int
+	byID    map[uint]int
+}
+
+// NewIndex returns an empty index
+func NewIndex() *Index {
+	return &Index{cells: map[cell][]int{}, byID: map[uint]int{}}
+}
+
+// Add registers the shape under the given id, which must not be in the index yet
+func (ix *Index) Add(id uint, shape *Shape) {
+	ix.entries = append(ix.entries, indexEntry{id: id, shape: shape, area: shape.Area()})
+	entry := len(ix.entries) - 1
+	ix.byID[id] = entry
+
+	min := cellOf(shape.BBox.MinLng, shape.BBox.MinLat)
+	max := cellOf(shape.BBox.MaxLng, shape.BBox.MaxLat)
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			ix.cells[cell{x, y}] = append(ix.cells[cell{x, y}], entry)
+		}
+	}
+}
+
+// Len returns the number of shapes in the index
+func (ix *Index) Len() int {
+	return len(ix.entries)
+}
+
+// Shape returns the shape registered under the id, or nil if there is none
+func (ix *Index) Shape(id uint) *Shape {
+	entry, ok := ix.byID[id]
+	if !ok {
+		return nil
+	}
+	return ix.entries[entry].shape
+}
+
+// Intersecting returns the ids of the shapes whose bounding box overlaps the box, in the order they were added
+func (ix *Index) Intersecting(box BBox) []uint {
+	var ids []uint
+	for _, entry := range ix.entries {
+		if entry.shape.BBox.Intersects(box) {
+			ids = append(ids, entry.id)
+		}
+	}
+	return ids
+}
+
+// Lookup returns the ids of the shapes containing the point, the smallest shape first
+// so that nested shapes resolve to the most specific one
+func (ix *Index) Lookup(p Point) []uint {
+	var matches []indexEntry
+	for _, entry := range ix.cells[cellOf(p.Lng, p.Lat)] {
+		if ix.entries[entry].shape.Contains(p) {
+			matches = append(matches, ix.entries[entry])
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].area < matches[j].area })
+
+	ids := make([]uint, len(matches))
+	for i, match := range matches {
+		ids[i] = match.id
+	}
+	return ids
+}
@@ -0,0 +1,165 @@
+package geo
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// square returns a GeoJSON polygon of the box, with the boxes of its holes
+func square(minLng, minLat, maxLng, maxLat float64, holes ...[4]float64) string {
+	ring := func(minLng, minLat, maxLng, maxLat float64) string {
+		return fmt.Sprintf("[[%[1]g,%[2]g],[%[3]g,%[2]g],[%[3]g,%[4]g],[%[1]g,%[4]g],[%[1]g,%[2]g]]", minLng, minLat, maxLng, maxLat)
+	}
+	rings := ring(minLng, minLat, maxLng, maxLat)
+	for _, hole := range holes {
+		rings += "," + ring(hole[0], hole[1], hole[2], hole[3])
+	}
+	return `{"type":"Polygon","coordinates":[` + rings + `]}`
+}
+
+func mustParseShape(t *testing.T, geoJSON string) *Shape {
+	t.Helper()
+	shape, err := ParseShape([]byte(geoJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shape
+}
+
+func TestCellOf(t *testing.T) {
+	tests := []struct {
+		lng, lat float64
+		want     cell
+	}{
+		{0, 0, cell{0, 0}},
+		{0.5, 0.5, cell{0, 0}},
+		{1, 1, cell{1, 1}},
+		{0.999, 1.999, cell{0, 1}},
+		{-0.5, -0.5, cell{-1, -1}},
+		{-1, -1, cell{-1, -1}},
+		{-1.001, 42.2, cell{-2, 42}},
+		{180, 90, cell{180, 90}},
+		{-180, -90, cell{-180, -90}},
+	}
+	for _, test := range tests {
+		if got := cellOf(test.lng, test.lat); got != test.want {
+			t.Errorf("cellOf(%g, %g) = %v, want %v", test.lng, test.lat, got, test.want)
+		}
+	}
+}
+
+func TestRingContains(t *testing.T) {
+	shape := mustParseShape(t, square(0, 0, 2, 2))
+	ring := shape.Polygons[0][0]
+	// Counter-clockwise and clockwise rings give the same answers
+	reversed := make([]Point, len(ring))
+	for i, p := range ring {
+		reversed[len(ring)-1-i] = p
+	}
+	// A concave ring in the shape of a U, open to the north
+	u := mustParseShape(t, `{"type":"Polygon","coordinates":[[[0,0],[3,0],[3,3],[2,3],[2,1],[1,1],[1,3],[0,3],[0,0]]]}`).Polygons[0][0]
+
+	tests := []struct {
+		name string
+		ring []Point
+		p    Point
+		want bool
+	}{
+		{"inside", ring, Point{Lng: 1, Lat: 1}, true},
+		{"outside to the east", ring, Point{Lng: 3, Lat: 1}, false},
+		{"outside to the west", ring, Point{Lng: -1, Lat: 1}, false},
+		{"outside to the north", ring, Point{Lng: 1, Lat: 3}, false},
+		{"clockwise inside", reversed, Point{Lng: 1, Lat: 1}, true},
+		{"clockwise outside", reversed, Point{Lng: 3, Lat: 1}, false},
+		// Points on the western and southern edges are inside, those on the eastern and northern
+		// edges are outside, so that a point on the border of adjacent rings is in exactly one
+		{"western edge", ring, Point{Lng: 0, Lat: 1}, true},
+		{"southern edge", ring, Point{Lng: 1, Lat: 0}, true},
+		{"eastern edge", ring, Point{Lng: 2, Lat: 1}, false},
+		{"northern edge", ring, Point{Lng: 1, Lat: 2}, false},
+		{"ray through a vertex", ring, Point{Lng: -1, Lat: 0}, false},
+		{"concave inside the left arm", u, Point{Lng: 0.5, Lat: 2}, true},
+		{"concave inside the gap", u, Point{Lng: 1.5, Lat: 2}, false},
+		{"concave below the gap", u, Point{Lng: 1.5, Lat: 0.5}, true},
+	}
+	for _, test := range tests {
+		if got := ringContains(test.ring, test.p); got != test.want {
+			t.Errorf("%s: ringContains(%v) = %v, want %v", test.name, test.p, got, test.want)
+		}
+	}
+}
+
+func TestShapeContainsExcludesHoles(t *testing.T) {
+	shape := mustParseShape(t, square(0, 0, 4, 4, [4]float64{1, 1, 3, 3}))
+	tests := []struct {
+		p    Point
+		want bool
+	}{
+		{Point{Lng: 0.5, Lat: 0.5}, true},
+		{Point{Lng: 2, Lat: 2}, false},
+		{Point{Lng: 3.5, Lat: 2}, true},
+		{Point{Lng: 5, Lat: 2}, false},
+	}
+	for _, test := range tests {
+		if got := shape.Contains(test.p); got != test.want {
+			t.Errorf("Contains(%v) = %v, want %v", test.p, got, test.want)
+		}
+	}
+	if area := shape.Area(); area != 12 {
+		t.Errorf("Area() = %g, want 12", area)
+	}
+}
+
+func TestIndexLookup(t *testing.T) {
+	index := NewIndex()
+	// A country of two cells by two containing a region and a city, added from the
+	// smallest so that the order of the result does not come from the order of addition
+	index.Add(3, mustParseShape(t, square(20.5, 42.5, 20.6, 42.6)))
+	index.Add(2, mustParseShape(t, square(20, 42, 21, 43)))
+	index.Add(1, mustParseShape(t, square(19.5, 41.5, 21.5, 43.5)))
+	// A lake in a hole of its shore, and two regions sharing the meridian 10 on a cell boundary
+	index.Add(4, mustParseShape(t, square(-5, -5, -1, -1, [4]float64{-4, -4, -2, -2})))
+	index.Add(5, mustParseShape(t, square(-3.5, -3.5, -2.5, -2.5)))
+	index.Add(6, mustParseShape(t, square(9, 0, 10, 1)))
+	index.Add(7, mustParseShape(t, square(10, 0, 11, 1)))
+
+	tests := []struct {
+		name string
+		p    Point
+		want []uint
+	}{
+		{"in the three nested shapes", Point{Lng: 20.55, Lat: 42.55}, []uint{3, 2, 1}},
+		{"in the region outside of the city", Point{Lng: 20.2, Lat: 42.2}, []uint{2, 1}},
+		{"in the country only", Point{Lng: 19.7, Lat: 43.4}, []uint{1}},
+		{"on a cell boundary inside the nested shapes", Point{Lng: 21, Lat: 43}, []uint{1}},
+		{"on a cell boundary inside the region", Point{Lng: 20, Lat: 42.5}, []uint{2, 1}},
+		{"outside of every shape", Point{Lng: 25, Lat: 42}, []uint{}},
+		{"in the shore", Point{Lng: -4.5, Lat: -4.5}, []uint{4}},
+		{"in the hole of the shore", Point{Lng: -2.2, Lat: -2.2}, []uint{}},
+		{"in the lake within the hole", Point{Lng: -3, Lat: -3}, []uint{5}},
+		{"on the shared boundary", Point{Lng: 10, Lat: 0.5}, []uint{7}},
+		{"west of the shared boundary", Point{Lng: 9.999, Lat: 0.5}, []uint{6}},
+	}
+	for _, test := range tests {
+		if got := index.Lookup(test.p); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Lookup(%v) = %v, want %v", test.name, test.p, got, test.want)
+		}
+	}
+}
+
+func TestIndexRegistersShapesInEveryCoveredCell(t *testing.T) {
+	index := NewIndex()
+	index.Add(1, mustParseShape(t, square(-0.5, -0.5, 1.5, 0.5)))
+	for _, c := range []cell{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {0, 0}, {1, 0}} {
+		if entries := index.cells[c]; len(entries) != 1 {
+			t.Errorf("cell %v has entries %v, want the shape", c, entries)
+		}
+	}
+	if len(index.cells) != 6 {
+		t.Errorf("shape registered in %d cells, want 6", len(index.cells))
+	}
+	if index.Len() != 1 || index.Shape(1) == nil || index.Shape(2) != nil {
+		t.Errorf("index has %d shapes, want the shape under id 1", index.Len())
+	}
+}
@@ -0,0 +1,141 @@
+package geo
+
+import "sort"
+
+// segment is an edge of a polygon ring
+type segment struct {
+	ring  int
+	index int
+	a     Point
+	b     Point
+}
+
+func (s segment) minLng() float64 {
+	if s.a.Lng < s.b.Lng {
+		return s.a.Lng
+	}
+	return s.b.Lng
+}
+
+func (s segment) maxLng() float64 {
+	if s.a.Lng > s.b.Lng {
+		return s.a.Lng
+	}
+	return s.b.Lng
+}
+
+// Intersection locates two crossing edges of a polygon, each given by its ring and the index of its first position
+type Intersection struct {
+	Ring1, Edge1 int
+	Ring2, Edge2 int
+}
+
+// Intersections finds the edges of a polygon which touch or cross each other, apart from consecutive
+// edges of a ring sharing their common position. Edges of different rings may only touch.
+// The edges are swept by longitude, so that only edges overlapping in longitude are compared.
+func (polygon Polygon) Intersections(limit int) []Intersection {
+	var segments []segment
+	sizes := make([]int, len(polygon))
+	for r, ring := range polygon {
+		sizes[r] = len(ring) - 1
+		for i := 0; i+1 < len(ring); i++ {
+			segments = append(segments, segment{ring: r, index: i, a: ring[i], b: ring[i+1]})
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].minLng() < segments[j].minLng() })
+
+	var found []Intersection
+	for i, s := range segments {
+		for _, t := range segments[i+1:] {
+			if t.minLng() > s.maxLng() {
+				break
+			}
+			if !crosses(s, t, sizes) {
+				continue
+			}
+			first, second := s, t
+			if second.ring < first.ring || (second.ring == first.ring && second.index < first.index) {
+				first, second = second, first
+			}
+			found = append(found, Intersection{Ring1: first.ring, Edge1: first.index, Ring2: second.ring, Edge2: second.index})
+			if len(found) == limit {
+				return found
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Ring1 != found[j].Ring1 {
+			return found[i].Ring1 < found[j].Ring1
+		}
+		return found[i].Edge1 < found[j].Edge1
+	})
+	return found
+}
+
+// crosses reports whether two edges intersect in a way a valid polygon does not allow
+func crosses(s, t segment, sizes []int) bool {
+	if s.ring != t.ring {
+		return properlyIntersect(s.a, s.b, t.a, t.b)
+	}
+	gap := s.index - t.index
+	if gap < 0 {
+		gap = -gap
+	}
+	if gap == 1 || gap == sizes[s.ring]-1 {
+		// Consecutive edges share a position, they are only invalid when they fold back onto each other
+		return collinearOverlap(s, t)
+	}
+	return intersect(s.a, s.b, t.a, t.b)
+}
+
+// orientation returns the sign of the cross product of b-a and c-a
+func orientation(a, b, c Point) int {
+	cross := (b.Lng-a.Lng)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lng-a.Lng)
+	switch {
+	case cross > 0:
+		return 1
+	case cross < 0:
+		return -1
+	}
+	return 0
+}
+
+// onSegment reports whether c, collinear with a and b, lies between them
+func onSegment(a, b, c Point) bool {
+	return c.Lng >= min(a.Lng, b.Lng) && c.Lng <= max(a.Lng, b.Lng) &&
+		c.Lat >= min(a.Lat, b.Lat) && c.Lat <= max(a.Lat, b.Lat)
+}
+
+// intersect reports whether the segments ab and cd have a point in common
+func intersect(a, b, c, d Point) bool {
+	o1, o2, o3, o4 := orientation(a, b, c), orientation(a, b, d), orientation(c, d, a), orientation(c, d, b)
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+	return (o1 == 0 && onSegment(a, b, c)) || (o2 == 0 && onSegment(a, b, d)) ||
+		(o3 == 0 && onSegment(c, d, a)) || (o4 == 0 && onSegment(c, d, b))
+}
+
+// properlyIntersect reports whether the segments ab and cd cross each other at a single inner point
+func properlyIntersect(a, b, c, d Point) bool {
+	o1, o2, o3, o4 := orientation(a, b, c), orientation(a, b, d), orientation(c, d, a), orientation(c, d, b)
+	return o1*o2 < 0 && o3*o4 < 0
+}
+
+// collinearOverlap reports whether two consecutive edges run back along each other
+func collinearOverlap(s, t segment) bool {
+	if orientation(s.a, s.b, t.a) != 0 || orientation(s.a, s.b, t.b) != 0 {
+		return false
+	}
+	// The shared position is an end of both edges, the other ends must lie on the same side of it
+	var shared, p, q Point
+	switch {
+	case s.b == t.a:
+		shared, p, q = s.b, s.a, t.b
+	case s.a == t.b:
+		shared, p, q = s.a, s.b, t.a
+	default:
+		return false
+	}
+	return (p.Lng-shared.Lng)*(q.Lng-shared.Lng)+(p.Lat-shared.Lat)*(q.Lat-shared.Lat) > 0
+}
@@ -0,0 +1,250 @@
+package geo
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// SimplifiedZooms are the zoom levels boundaries are simplified for when they are saved.
+// Deeper zoom levels use the full boundaries.
+var SimplifiedZooms = []int{0, 2, 4, 6, 8, 10, 12}
+
+// PixelTolerance returns the width in degrees of a pixel at the zoom level, details smaller
+// than it cannot be seen at that zoom level
+func PixelTolerance(zoom int) float64 {
+	return 360 / (tileSize * math.Exp2(float64(zoom)))
+}
+
+// ToleranceZoom returns the lowest zoom level whose pixels are no wider than tolerance degrees
+func ToleranceZoom(tolerance float64) int {
+	return int(math.Max(0, math.Ceil(math.Log2(360/(tileSize*tolerance)))))
+}
+
+// SimplifiedZoom returns the simplification level detailed enough for the zoom level,
+// or false when only the full boundary is
+func SimplifiedZoom(zoom int) (int, bool) {
+	for _, level := range SimplifiedZooms {
+		if level >= zoom {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// SimplifyLevels simplifies a GeoJSON document for each of SimplifiedZooms, returning the
+// simplified documents keyed by zoom level
+func SimplifyLevels(data []byte) (map[int][]byte, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	levels := make(map[int][]byte, len(SimplifiedZooms))
+	for _, zoom := range SimplifiedZooms {
+		simplified, err := json.Marshal(Simplify(document, PixelTolerance(zoom)))
+		if err != nil {
+			return nil, err
+		}
+		levels[zoom] = simplified
+	}
+	return levels, nil
+}
+
+// Simplify returns a copy of a GeoJSON document whose lines and polygon rings are simplified with the
+// Douglas-Peucker algorithm, dropping the details smaller than tolerance degrees. Rings keep at least
+// a triangle, holes and parts of a MultiPolygon smaller than tolerance are left out but for the largest
+// part. Other members and points are kept as they are.
+func Simplify(document map[string]interface{}, tolerance float64) map[string]interface{} {
+	out := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		out[key] = value
+	}
+
+	switch document["type"] {
+	case "FeatureCollection":
+		out["features"] = simplifyMembers(document["features"], tolerance)
+	case "Feature":
+		if geometry, ok := document["geometry"].(map[string]interface{}); ok {
+			out["geometry"] = Simplify(geometry, tolerance)
+		}
+	case "GeometryCollection":
+		out["geometries"] = simplifyMembers(document["geometries"], tolerance)
+	case "LineString":
+		out["coordinates"] = simplifyLine(document["coordinates"], tolerance)
+	case "MultiLineString":
+		lines, _ := document["coordinates"].([]interface{})
+		simplified := make([]interface{}, len(lines))
+		for i, line := range lines {
+			simplified[i] = simplifyLine(line, tolerance)
+		}
+		out["coordinates"] = simplified
+	case "Polygon":
+		out["coordinates"] = simplifyPolygon(document["coordinates"], tolerance)
+	case "MultiPolygon":
+		out["coordinates"] = simplifyMultiPolygon(document["coordinates"], tolerance)
+	}
+	return out
+}
+
+func simplifyMembers(value interface{}, tolerance float64) []interface{} {
+	members, _ := value.([]interface{})
+	simplified := make([]interface{}, len(members))
+	for i, member := range members {
+		if object, ok := member.(map[string]interface{}); ok {
+			simplified[i] = Simplify(object, tolerance)
+		} else {
+			simplified[i] = member
+		}
+	}
+	return simplified
+}
+
+// points reads the longitude and latitude of GeoJSON positions, which were validated by NormalizeBoundary
+func points(value interface{}) ([]interface{}, []Point) {
+	positions, _ := value.([]interface{})
+	out := make([]Point, len(positions))
+	for i, position := range positions {
+		coordinates, _ := position.([]interface{})
+		if len(coordinates) >= 2 {
+			out[i].Lng, _ = coordinates[0].(float64)
+			out[i].Lat, _ = coordinates[1].(float64)
+		}
+	}
+	return positions, out
+}
+
+// kept returns the positions whose keep flag is set
+func kept(positions []interface{}, keep []bool) []interface{} {
+	var out []interface{}
+	for i, position := range positions {
+		if keep[i] {
+			out = append(out, position)
+		}
+	}
+	return out
+}
+
+func simplifyLine(value interface{}, tolerance float64) interface{} {
+	positions, line := points(value)
+	if len(line) < 3 {
+		return value
+	}
+	keep := make([]bool, len(line))
+	keep[0], keep[len(line)-1] = true, true
+	douglasPeucker(line, 0, len(line)-1, tolerance, keep)
+	return kept(positions, keep)
+}
+
+// simplifyRing simplifies a closed ring into a closed ring of at least four positions
+func simplifyRing(value interface{}, tolerance float64) interface{} {
+	positions, ring := points(value)
+	last := len(ring) - 1
+	if len(ring) <= 4 {
+		return value
+	}
+
+	// The first and last positions coincide, the ring is split at the position farthest from them
+	farthest, distance := 0, -1.0
+	for i := 1; i < last; i++ {
+		if d := math.Hypot(ring[i].Lng-ring[0].Lng, ring[i].Lat-ring[0].Lat); d > distance {
+			farthest, distance = i, d
+		}
+	}
+	keep := make([]bool, len(ring))
+	keep[0], keep[farthest], keep[last] = true, true, true
+	douglasPeucker(ring, 0, farthest, tolerance, keep)
+	douglasPeucker(ring, farthest, last, tolerance, keep)
+
+	// Keep a triangle, the position farthest from the segment between the two kept ones
+	count := 0
+	for _, k := range keep {
+		if k {
+			count++
+		}
+	}
+	if count < 4 {
+		third, distance := -1, -1.0
+		for i := 1; i < last; i++ {
+			if i != farthest {
+				if d := pointSegmentDistance(ring[i], ring[0], ring[farthest]); d > distance {
+					third, distance = i, d
+				}
+			}
+		}
+		keep[third] = true
+	}
+	return kept(positions, keep)
+}
+
+func simplifyPolygon(value interface{}, tolerance float64) interface{} {
+	rings, _ := value.([]interface{})
+	if len(rings) == 0 {
+		return value
+	}
+	simplified := []interface{}{simplifyRing(rings[0], tolerance)}
+	for _, hole := range rings[1:] {
+		if _, ring := points(hole); extent(ring) >= tolerance {
+			simplified = append(simplified, simplifyRing(hole, tolerance))
+		}
+	}
+	return simplified
+}
+
+func simplifyMultiPolygon(value interface{}, tolerance float64) interface{} {
+	polygons, _ := value.([]interface{})
+	largest, largestExtent := -1, -1.0
+	var simplified []interface{}
+	for i, polygon := range polygons {
+		rings, _ := polygon.([]interface{})
+		if len(rings) == 0 {
+			continue
+		}
+		_, exterior := points(rings[0])
+		size := extent(exterior)
+		if size > largestExtent {
+			largest, largestExtent = i, size
+		}
+		if size >= tolerance {
+			simplified = append(simplified, simplifyPolygon(polygon, tolerance))
+		}
+	}
+	if len(simplified) == 0 && largest >= 0 {
+		simplified = append(simplified, simplifyPolygon(polygons[largest], tolerance))
+	}
+	return simplified
+}
+
+// extent returns the largest side of the bounding box of the points
+func extent(ring []Point) float64 {
+	box := emptyBBox()
+	for _, p := range ring {
+		box = box.extend(p)
+	}
+	return math.Max(box.MaxLng-box.MinLng, box.MaxLat-box.MinLat)
+}
+
+// douglasPeucker flags the positions between first and last which lie farther than tolerance
+// from the simplified line
+func douglasPeucker(line []Point, first, last int, tolerance float64, keep []bool) {
+	farthest, distance := -1, tolerance
+	for i := first + 1; i < last; i++ {
+		if d := pointSegmentDistance(line[i], line[first], line[last]); d > distance {
+			farthest, distance = i, d
+		}
+	}
+	if farthest >= 0 {
+		keep[farthest] = true
+		douglasPeucker(line, first, farthest, tolerance, keep)
+		douglasPeucker(line, farthest, last, tolerance, keep)
+	}
+}
+
+// pointSegmentDistance returns the planar distance in degrees between p and the segment from a to b
+func pointSegmentDistance(p, a, b Point) float64 {
+	dx, dy := b.Lng-a.Lng, b.Lat-a.Lat
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p.Lng-a.Lng, p.Lat-a.Lat)
+	}
+	t := ((p.Lng-a.Lng)*dx + (p.Lat-a.Lat)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.Lng-(a.Lng+t*dx), p.Lat-(a.Lat+t*dy))
+}
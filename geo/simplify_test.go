@@ -0,0 +1,222 @@
+package geo
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// circle returns a closed ring of n+1 positions around the center, its first position to the east
+func circle(center Point, radius float64, n int) [][]float64 {
+	ring := make([][]float64, n+1)
+	for i := 0; i < n; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		ring[i] = []float64{center.Lng + radius*math.Cos(angle), center.Lat + radius*math.Sin(angle)}
+	}
+	ring[n] = ring[0]
+	return ring
+}
+
+// decodeRings reads the rings of the polygons of a simplified MultiPolygon
+func decodeRings(t *testing.T, data []byte) [][][][]float64 {
+	t.Helper()
+	var geometry struct {
+		Coordinates [][][][]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &geometry); err != nil {
+		t.Fatal(err)
+	}
+	return geometry.Coordinates
+}
+
+// checkRing fails unless the ring is closed and has at least four positions
+func checkRing(t *testing.T, name string, ring [][]float64) {
+	t.Helper()
+	if len(ring) < 4 {
+		t.Errorf("%s has %d positions, want at least 4", name, len(ring))
+		return
+	}
+	if !samePosition(ring[0], ring[len(ring)-1]) {
+		t.Errorf("%s is not closed: %v", name, ring)
+	}
+}
+
+func TestSimplifyLevelsKeepRingsClosed(t *testing.T) {
+	center := Point{Lng: 20.7, Lat: 42.2}
+	document, err := json.Marshal(map[string]interface{}{
+		"type": "MultiPolygon",
+		"coordinates": [][][][]float64{
+			{circle(center, 1, 720), circle(center, 0.5, 360)},
+			{circle(Point{Lng: 23, Lat: 42.2}, 0.05, 90)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels, err := SimplifyLevels(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != len(SimplifiedZooms) {
+		t.Fatalf("%d levels, want one for each of %v", len(levels), SimplifiedZooms)
+	}
+
+	previous := 0
+	for _, zoom := range SimplifiedZooms {
+		polygons := decodeRings(t, levels[zoom])
+		positions := 0
+		for _, polygon := range polygons {
+			for _, ring := range polygon {
+				checkRing(t, "ring", ring)
+				positions += len(ring)
+			}
+		}
+		if positions < previous {
+			t.Errorf("zoom %d keeps %d positions, fewer than the %d of the level below", zoom, positions, previous)
+		}
+		previous = positions
+	}
+
+	// At zoom 0 a pixel is wider than the hole and the small island, at zoom 12 nothing is dropped
+	if polygons := decodeRings(t, levels[0]); len(polygons) != 1 || len(polygons[0]) != 1 {
+		t.Errorf("zoom 0 keeps %d polygons, want only the exterior of the large one", len(polygons))
+	}
+	if polygons := decodeRings(t, levels[12]); len(polygons) != 2 || len(polygons[0]) != 2 {
+		t.Errorf("zoom 12 keeps %d polygons, want both with the hole", len(polygons))
+	}
+}
+
+func TestSimplifyRing(t *testing.T) {
+	square := [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	tests := []struct {
+		name      string
+		ring      [][]float64
+		tolerance float64
+		want      int
+	}{
+		{"square with a negligible bump", [][]float64{{0, 0}, {0.5, -0.001}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}, 0.01, 5},
+		{"square with a visible bump", [][]float64{{0, 0}, {0.5, -0.1}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}, 0.01, 6},
+		{"triangle is kept as it is", [][]float64{{0, 0}, {1, 0}, {0, 1}, {0, 0}}, 10, 4},
+		{"square smaller than the tolerance keeps a triangle", square, 10, 4},
+		{"circle smaller than the tolerance keeps a triangle", circle(Point{}, 0.001, 64), 1, 4},
+		{"circle larger than the tolerance", circle(Point{}, 1, 64), 0.0001, 65},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := toInterfaces(test.ring)
+			_, simplified := points(simplifyRing(value, test.tolerance))
+			ring := make([][]float64, len(simplified))
+			for i, p := range simplified {
+				ring[i] = []float64{p.Lng, p.Lat}
+			}
+			checkRing(t, "simplified ring", ring)
+			if len(ring) != test.want {
+				t.Errorf("simplified ring has %d positions, want %d: %v", len(ring), test.want, ring)
+			}
+			if len(ring) >= 4 && signedRingArea(simplified) == 0 {
+				t.Errorf("simplified ring %v is flat", ring)
+			}
+		})
+	}
+}
+
+func TestSimplifyDropsSmallParts(t *testing.T) {
+	small := circle(Point{Lng: 5, Lat: 5}, 0.001, 16)
+	smaller := circle(Point{Lng: 8, Lat: 8}, 0.0005, 16)
+	large := circle(Point{}, 1, 16)
+
+	polygon := Simplify(map[string]interface{}{"type": "Polygon", "coordinates": polygonOf(large, small)}, 0.01)
+	if rings := polygon["coordinates"].([]interface{}); len(rings) != 1 {
+		t.Errorf("polygon keeps %d rings, want the exterior without the small hole", len(rings))
+	}
+
+	multi := Simplify(map[string]interface{}{"type": "MultiPolygon", "coordinates": []interface{}{
+		polygonOf(smaller), polygonOf(small),
+	}}, 0.01)
+	parts := multi["coordinates"].([]interface{})
+	if len(parts) != 1 {
+		t.Fatalf("multipolygon keeps %d parts, want the largest of the small ones", len(parts))
+	}
+	_, exterior := points(parts[0].([]interface{})[0])
+	if exterior[0] != (Point{Lng: 5.001, Lat: 5}) {
+		t.Errorf("multipolygon keeps the part starting at %v, want the larger one", exterior[0])
+	}
+}
+
+func TestSimplifyKeepsOtherMembers(t *testing.T) {
+	feature := map[string]interface{}{
+		"type":       "Feature",
+		"id":         "prizren",
+		"properties": map[string]interface{}{"name": "Prizren"},
+		"geometry":   map[string]interface{}{"type": "Point", "coordinates": []interface{}{20.7, 42.2}},
+	}
+	if simplified := Simplify(feature, 1); !reflect.DeepEqual(simplified, feature) {
+		t.Errorf("Simplify() = %v, want %v", simplified, feature)
+	}
+}
+
+func TestDouglasPeucker(t *testing.T) {
+	line := []Point{{0, 0}, {1, 0.05}, {2, -0.05}, {3, 2}, {4, 0}, {5, 0}}
+	tests := []struct {
+		tolerance float64
+		want      []bool
+	}{
+		{0.01, []bool{true, true, true, true, true, true}},
+		{0.1, []bool{true, false, true, true, true, true}},
+		{10, []bool{true, false, false, false, false, true}},
+	}
+	for _, test := range tests {
+		keep := make([]bool, len(line))
+		keep[0], keep[len(line)-1] = true, true
+		douglasPeucker(line, 0, len(line)-1, test.tolerance, keep)
+		if !reflect.DeepEqual(keep, test.want) {
+			t.Errorf("tolerance %g keeps %v, want %v", test.tolerance, keep, test.want)
+		}
+	}
+}
+
+func TestSimplifiedZoom(t *testing.T) {
+	previous := 0
+	for zoom := 0; zoom <= SimplifiedZooms[len(SimplifiedZooms)-1]; zoom++ {
+		level, ok := SimplifiedZoom(zoom)
+		if !ok || level < zoom || level < previous {
+			t.Errorf("SimplifiedZoom(%d) = %d, %v, want a level of at least %d and %d", zoom, level, ok, zoom, previous)
+		}
+		previous = level
+	}
+	if level, ok := SimplifiedZoom(SimplifiedZooms[len(SimplifiedZooms)-1] + 1); ok {
+		t.Errorf("SimplifiedZoom beyond the last level = %d, want the full boundary", level)
+	}
+	for i := 1; i < len(SimplifiedZooms); i++ {
+		if SimplifiedZooms[i] <= SimplifiedZooms[i-1] {
+			t.Errorf("SimplifiedZooms %v are not increasing", SimplifiedZooms)
+		}
+		if PixelTolerance(SimplifiedZooms[i]) >= PixelTolerance(SimplifiedZooms[i-1]) {
+			t.Errorf("tolerance of zoom %d is not finer than the one of zoom %d", SimplifiedZooms[i], SimplifiedZooms[i-1])
+		}
+	}
+	for zoom := 0; zoom <= 20; zoom++ {
+		if got := ToleranceZoom(PixelTolerance(zoom)); got != zoom {
+			t.Errorf("ToleranceZoom(PixelTolerance(%d)) = %d", zoom, got)
+		}
+	}
+}
+
+// toInterfaces converts positions to the values json.Unmarshal produces
+func toInterfaces(ring [][]float64) []interface{} {
+	out := make([]interface{}, len(ring))
+	for i, position := range ring {
+		out[i] = []interface{}{position[0], position[1]}
+	}
+	return out
+}
+
+// polygonOf converts rings to a polygon of the values json.Unmarshal produces
+func polygonOf(rings ...[][]float64) []interface{} {
+	out := make([]interface{}, len(rings))
+	for i, ring := range rings {
+		out[i] = toInterfaces(ring)
+	}
+	return out
+}
@@ -0,0 +1,323 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxProblems bounds the number of problems reported for a single document
+const maxProblems = 100
+
+// Problem is a defect of a GeoJSON document, located by the JSON path of the offending member
+type Problem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the problems which make a document unusable as a boundary
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Path + ": " + problem.Message
+	}
+	return "invalid GeoJSON: " + strings.Join(messages, "; ")
+}
+
+// NormalizeBoundary validates a GeoJSON document against RFC 7946 and returns it as a FeatureCollection.
+// A geometry is wrapped in a Feature and a Feature in a FeatureCollection, repeated positions are
+// dropped and polygon rings are rewound so that exteriors run counterclockwise and holes clockwise.
+// Members other than type, id, geometry, properties, features, geometries and coordinates are left out.
+// The document must hold at least one Polygon or MultiPolygon, whose rings must be closed and must
+// not intersect. A *ValidationError lists every problem found.
+func NormalizeBoundary(document map[string]interface{}) (map[string]interface{}, error) {
+	v := &validator{}
+	var features []interface{}
+
+	switch document["type"] {
+	case "FeatureCollection":
+		features = v.features(document, "$")
+	case "Feature":
+		if feature := v.feature(document, "$"); feature != nil {
+			features = []interface{}{feature}
+		}
+	default:
+		if geometry := v.geometry(document, "$"); geometry != nil {
+			features = []interface{}{map[string]interface{}{"type": "Feature", "geometry": geometry, "properties": map[string]interface{}{}}}
+		}
+	}
+
+	if len(v.problems) == 0 && v.polygons == 0 {
+		v.report("$", "no Polygon or MultiPolygon geometry found")
+	}
+	if len(v.problems) > 0 {
+		return nil, &ValidationError{Problems: v.problems}
+	}
+	return map[string]interface{}{"type": "FeatureCollection", "features": features}, nil
+}
+
+type validator struct {
+	problems []Problem
+	polygons int
+}
+
+func (v *validator) report(path, format string, args ...interface{}) {
+	if len(v.problems) < maxProblems {
+		v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (v *validator) object(value interface{}, path string) (map[string]interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.report(path, "must be an object")
+	}
+	return object, ok
+}
+
+func (v *validator) array(value interface{}, path string) ([]interface{}, bool) {
+	array, ok := value.([]interface{})
+	if !ok {
+		v.report(path, "must be an array")
+	}
+	return array, ok
+}
+
+func (v *validator) features(collection map[string]interface{}, path string) []interface{} {
+	members, ok := v.array(collection["features"], path+".features")
+	if !ok {
+		return nil
+	}
+	features := make([]interface{}, 0, len(members))
+	for i, member := range members {
+		memberPath := fmt.Sprintf("%s.features[%d]", path, i)
+		object, ok := v.object(member, memberPath)
+		if !ok {
+			continue
+		}
+		if object["type"] != "Feature" {
+			v.report(memberPath+".type", "must be \"Feature\"")
+			continue
+		}
+		if feature := v.feature(object, memberPath); feature != nil {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+func (v *validator) feature(object map[string]interface{}, path string) map[string]interface{} {
+	feature := map[string]interface{}{"type": "Feature", "geometry": nil, "properties": map[string]interface{}{}}
+
+	switch id := object["id"].(type) {
+	case nil:
+	case string, float64:
+		feature["id"] = id
+	default:
+		v.report(path+".id", "must be a string or a number")
+	}
+
+	switch properties := object["properties"].(type) {
+	case nil:
+	case map[string]interface{}:
+		feature["properties"] = properties
+	default:
+		v.report(path+".properties", "must be an object or null")
+	}
+
+	if object["geometry"] != nil {
+		geometryObject, ok := v.object(object["geometry"], path+".geometry")
+		if !ok {
+			return nil
+		}
+		geometry := v.geometry(geometryObject, path+".geometry")
+		if geometry == nil {
+			return nil
+		}
+		feature["geometry"] = geometry
+	}
+	return feature
+}
+
+func (v *validator) geometry(object map[string]interface{}, path string) map[string]interface{} {
+	geometryType, _ := object["type"].(string)
+	if geometryType == "GeometryCollection" {
+		members, ok := v.array(object["geometries"], path+".geometries")
+		if !ok {
+			return nil
+		}
+		geometries := make([]interface{}, 0, len(members))
+		for i, member := range members {
+			memberPath := fmt.Sprintf("%s.geometries[%d]", path, i)
+			if memberObject, ok := v.object(member, memberPath); ok {
+				if geometry := v.geometry(memberObject, memberPath); geometry != nil {
+					geometries = append(geometries, geometry)
+				}
+			}
+		}
+		return map[string]interface{}{"type": geometryType, "geometries": geometries}
+	}
+
+	coordinatesPath := path + ".coordinates"
+	var coordinates interface{}
+	var ok bool
+	switch geometryType {
+	case "Point":
+		coordinates, ok = v.position(object["coordinates"], coordinatesPath)
+	case "MultiPoint":
+		coordinates, ok = v.positions(object["coordinates"], coordinatesPath, 0)
+	case "LineString":
+		coordinates, ok = v.positions(object["coordinates"], coordinatesPath, 2)
+	case "MultiLineString":
+		coordinates, ok = collect(v, object["coordinates"], coordinatesPath, func(value interface{}, path string) ([][]float64, bool) {
+			return v.positions(value, path, 2)
+		})
+	case "Polygon":
+		coordinates, ok = v.polygon(object["coordinates"], coordinatesPath)
+	case "MultiPolygon":
+		coordinates, ok = collect(v, object["coordinates"], coordinatesPath, v.polygon)
+	case "":
+		v.report(path+".type", "must name a GeoJSON type")
+		return nil
+	default:
+		v.report(path+".type", "unknown geometry type %q", geometryType)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{"type": geometryType, "coordinates": coordinates}
+}
+
+// collect validates every element of an array with the given function
+func collect[T any](v *validator, value interface{}, path string, element func(interface{}, string) (T, bool)) ([]T, bool) {
+	members, ok := v.array(value, path)
+	if !ok {
+		return nil, false
+	}
+	out := make([]T, 0, len(members))
+	valid := true
+	for i, member := range members {
+		item, ok := element(member, fmt.Sprintf("%s[%d]", path, i))
+		valid = valid && ok
+		out = append(out, item)
+	}
+	return out, valid
+}
+
+func (v *validator) position(value interface{}, path string) ([]float64, bool) {
+	members, ok := v.array(value, path)
+	if !ok {
+		return nil, false
+	}
+	if len(members) < 2 || len(members) > 3 {
+		v.report(path, "a position must have a longitude, a latitude and an optional altitude")
+		return nil, false
+	}
+	position := make([]float64, len(members))
+	for i, member := range members {
+		number, ok := member.(float64)
+		if !ok {
+			v.report(fmt.Sprintf("%s[%d]", path, i), "must be a number")
+			return nil, false
+		}
+		position[i] = number
+	}
+	if position[0] < -180 || position[0] > 180 {
+		v.report(path+"[0]", "longitude %g is out of range [-180, 180]", position[0])
+		return nil, false
+	}
+	if position[1] < -90 || position[1] > 90 {
+		v.report(path+"[1]", "latitude %g is out of range [-90, 90]", position[1])
+		return nil, false
+	}
+	return position, true
+}
+
+// positions validates a list of at least minimum positions, dropping repeated consecutive ones
+func (v *validator) positions(value interface{}, path string, minimum int) ([][]float64, bool) {
+	list, ok := collect(v, value, path, v.position)
+	if !ok {
+		return nil, false
+	}
+	deduplicated := list[:0]
+	for _, position := range list {
+		if len(deduplicated) > 0 && samePosition(deduplicated[len(deduplicated)-1], position) {
+			continue
+		}
+		deduplicated = append(deduplicated, position)
+	}
+	if len(deduplicated) < minimum {
+		v.report(path, "must have at least %d distinct positions", minimum)
+		return nil, false
+	}
+	return deduplicated, true
+}
+
+func samePosition(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
+
+func (v *validator) polygon(value interface{}, path string) ([][][]float64, bool) {
+	rings, ok := collect(v, value, path, func(value interface{}, path string) ([][]float64, bool) {
+		ring, ok := v.positions(value, path, 4)
+		if ok && !samePosition(ring[0], ring[len(ring)-1]) {
+			v.report(path, "ring is not closed, its last position must repeat the first one")
+			return nil, false
+		}
+		return ring, ok
+	})
+	if !ok {
+		return nil, false
+	}
+	if len(rings) == 0 {
+		v.report(path, "a polygon needs an exterior ring")
+		return nil, false
+	}
+
+	polygon := make(Polygon, len(rings))
+	for r, ring := range rings {
+		polygon[r] = make([]Point, len(ring))
+		for i, position := range ring {
+			polygon[r][i] = Point{Lng: position[0], Lat: position[1]}
+		}
+	}
+	intersections := polygon.Intersections(maxProblems)
+	for _, x := range intersections {
+		if x.Ring1 == x.Ring2 {
+			v.report(fmt.Sprintf("%s[%d]", path, x.Ring1), "ring intersects itself between the edges starting at positions %d and %d", x.Edge1, x.Edge2)
+		} else {
+			v.report(path, "rings %d and %d cross each other at the edges starting at positions %d and %d", x.Ring1, x.Ring2, x.Edge1, x.Edge2)
+		}
+	}
+	if len(intersections) > 0 {
+		return nil, false
+	}
+
+	// RFC 7946 winding order: exteriors counterclockwise, holes clockwise
+	for r, ring := range polygon {
+		if (r == 0) != (signedRingArea(ring) > 0) {
+			reverse(rings[r])
+		}
+	}
+	v.polygons++
+	return rings, true
+}
+
+// signedRingArea is positive for rings running counterclockwise
+func signedRingArea(ring []Point) float64 {
+	var sum float64
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		sum += ring[j].Lng*ring[i].Lat - ring[i].Lng*ring[j].Lat
+	}
+	return sum / 2
+}
+
+func reverse(ring [][]float64) {
+	for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+		ring[i], ring[j] = ring[j], ring[i]
+	}
+}
@@ -0,0 +1,277 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func normalize(t *testing.T, document string) (map[string]interface{}, error) {
+	t.Helper()
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &object); err != nil {
+		t.Fatal(err)
+	}
+	return NormalizeBoundary(object)
+}
+
+// polygonCoordinates returns the coordinates of the single geometry of a normalized boundary
+func polygonCoordinates(t *testing.T, normalized map[string]interface{}) interface{} {
+	t.Helper()
+	features := normalized["features"].([]interface{})
+	if len(features) != 1 {
+		t.Fatalf("normalized boundary has %d features, want 1", len(features))
+	}
+	geometry := features[0].(map[string]interface{})["geometry"].(map[string]interface{})
+	return geometry["coordinates"]
+}
+
+func TestNormalizeBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     [][][]float64
+	}{
+		{
+			"counterclockwise exterior",
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`,
+			[][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+		},
+		{
+			"clockwise exterior is rewound",
+			`{"type":"Polygon","coordinates":[[[0,0],[0,1],[1,1],[1,0],[0,0]]]}`,
+			[][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+		},
+		{
+			"counterclockwise hole is rewound",
+			`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[1,1],[2,1],[2,2],[1,2],[1,1]]]}`,
+			[][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}, {{1, 1}, {1, 2}, {2, 2}, {2, 1}, {1, 1}}},
+		},
+		{
+			"repeated positions are dropped",
+			`{"type":"Polygon","coordinates":[[[0,0],[0,0],[1,0],[1,1],[1,1],[1,1],[0,1],[0,0]]]}`,
+			[][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+		},
+		{
+			"hole touching the exterior at a position",
+			`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[0,2],[2,3],[2,1],[0,2]]]}`,
+			[][][]float64{{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}, {{0, 2}, {2, 3}, {2, 1}, {0, 2}}},
+		},
+		{
+			"consecutive collinear edges",
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[2,0],[2,2],[0,2],[0,0]]]}`,
+			[][][]float64{{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}}},
+		},
+		{
+			"altitudes are kept",
+			`{"type":"Polygon","coordinates":[[[0,0,5],[1,0,5],[1,1,5],[0,1,5],[0,0,5]]]}`,
+			[][][]float64{{{0, 0, 5}, {1, 0, 5}, {1, 1, 5}, {0, 1, 5}, {0, 0, 5}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := normalize(t, test.document)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := polygonCoordinates(t, normalized); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("coordinates %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBoundaryWrapsGeometries(t *testing.T) {
+	const polygon = `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`
+	for _, document := range []string{
+		polygon,
+		`{"type":"Feature","id":7,"properties":{"name":"Prizren"},"geometry":` + polygon + `}`,
+		`{"type":"FeatureCollection","features":[{"type":"Feature","id":7,"properties":{"name":"Prizren"},"geometry":` + polygon + `}]}`,
+	} {
+		normalized, err := normalize(t, document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if normalized["type"] != "FeatureCollection" {
+			t.Errorf("%s normalized to a %v", document, normalized["type"])
+		}
+		feature := normalized["features"].([]interface{})[0].(map[string]interface{})
+		if feature["type"] != "Feature" || feature["properties"] == nil {
+			t.Errorf("%s normalized to feature %v", document, feature)
+		}
+	}
+}
+
+func TestNormalizeBoundaryProblems(t *testing.T) {
+	const square = `[[0,0],[1,0],[1,1],[0,1],[0,0]]`
+	tests := []struct {
+		name     string
+		document string
+		// want lists the paths of the problems along with a part of their messages
+		want []Problem
+	}{
+		{
+			"unclosed ring",
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`,
+			[]Problem{{"$.coordinates[0]", "not closed"}},
+		},
+		{
+			"ring too short once repeated positions are dropped",
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,0],[0,0]]]}`,
+			[]Problem{{"$.coordinates[0]", "at least 4 distinct positions"}},
+		},
+		{
+			"bow-tie",
+			`{"type":"Polygon","coordinates":[[[0,0],[2,2],[2,0],[0,2],[0,0]]]}`,
+			[]Problem{{"$.coordinates[0]", "positions 0 and 2"}},
+		},
+		{
+			"ring-closing edge crossing another edge",
+			`{"type":"Polygon","coordinates":[[[0,0],[2,0],[0,2],[2,2],[0,0]]]}`,
+			[]Problem{{"$.coordinates[0]", "positions 1 and 3"}},
+		},
+		{
+			"hole crossing the exterior",
+			`{"type":"Polygon","coordinates":[` + square + `,[[0.5,0.5],[1.5,0.5],[1.5,0.8],[0.5,0.8],[0.5,0.5]]]}`,
+			[]Problem{{"$.coordinates", "rings 0 and 1 cross"}, {"$.coordinates", "rings 0 and 1 cross"}},
+		},
+		{
+			"invalid ring of a multipolygon",
+			`{"type":"MultiPolygon","coordinates":[[` + square + `],[[[5,5],[6,5],[6,6],[5,6]]]]}`,
+			[]Problem{{"$.coordinates[1][0]", "not closed"}},
+		},
+		{
+			"latitude out of range",
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,91],[0,1],[0,0]]]}`,
+			[]Problem{{"$.coordinates[0][2][1]", "latitude 91"}},
+		},
+		{
+			"longitude out of range",
+			`{"type":"Polygon","coordinates":[[[0,0],[181,0],[1,1],[0,1],[0,0]]]}`,
+			[]Problem{{"$.coordinates[0][1][0]", "longitude 181"}},
+		},
+		{
+			"position without latitude",
+			`{"type":"Polygon","coordinates":[[[0],[1,0],[1,1],[0,1],[0,0]]]}`,
+			[]Problem{{"$.coordinates[0][0]", "a position must have"}},
+		},
+		{
+			"polygon without rings",
+			`{"type":"Polygon","coordinates":[]}`,
+			[]Problem{{"$.coordinates", "exterior ring"}},
+		},
+		{
+			"no polygon",
+			`{"type":"Point","coordinates":[20.7,42.2]}`,
+			[]Problem{{"$", "no Polygon or MultiPolygon"}},
+		},
+		{
+			"unknown geometry type",
+			`{"type":"Circle","coordinates":[20.7,42.2]}`,
+			[]Problem{{"$.type", "unknown geometry type"}},
+		},
+		{
+			"invalid feature id and properties",
+			`{"type":"Feature","id":true,"properties":[],"geometry":{"type":"Polygon","coordinates":[` + square + `]}}`,
+			[]Problem{{"$.id", "string or a number"}, {"$.properties", "object or null"}},
+		},
+		{
+			"collection member which is not a feature",
+			`{"type":"FeatureCollection","features":[{"type":"Polygon","coordinates":[` + square + `]},{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}}]}`,
+			[]Problem{{"$.features[0].type", "\"Feature\""}, {"$.features[1].geometry.coordinates[0]", "not closed"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := normalize(t, test.document)
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("error %v, want a *ValidationError", err)
+			}
+			if len(invalid.Problems) != len(test.want) {
+				t.Fatalf("problems %+v, want %+v", invalid.Problems, test.want)
+			}
+			for i, problem := range invalid.Problems {
+				if problem.Path != test.want[i].Path || !strings.Contains(problem.Message, test.want[i].Message) {
+					t.Errorf("problem %+v, want one at %s mentioning %q", problem, test.want[i].Path, test.want[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestIntersections(t *testing.T) {
+	ring := func(coordinates ...float64) []Point {
+		points := make([]Point, len(coordinates)/2)
+		for i := range points {
+			points[i] = Point{Lng: coordinates[2*i], Lat: coordinates[2*i+1]}
+		}
+		return points
+	}
+	exterior := ring(0, 0, 4, 0, 4, 4, 0, 4, 0, 0)
+
+	tests := []struct {
+		name    string
+		polygon Polygon
+		want    []Intersection
+	}{
+		{"square", Polygon{exterior}, nil},
+		{"consecutive collinear edges", Polygon{ring(0, 0, 1, 0, 2, 0, 2, 2, 0, 2, 0, 0)}, nil},
+		{"bow-tie", Polygon{ring(0, 0, 2, 2, 2, 0, 0, 2, 0, 0)}, []Intersection{{0, 0, 0, 2}}},
+		{"ring-closing edge crossing", Polygon{ring(0, 0, 2, 0, 0, 2, 2, 2, 0, 0)}, []Intersection{{0, 1, 0, 3}}},
+		{"non-consecutive edges touching", Polygon{ring(0, 0, 4, 0, 4, 4, 2, 0, 0, 4, 0, 0)}, []Intersection{{0, 0, 0, 2}, {0, 0, 0, 3}}},
+		// The second edge runs back along the first one, and the third edge starts on the first one
+		{"spike", Polygon{ring(0, 0, 2, 0, 1, 0, 0, 1, 0, 0)}, []Intersection{{0, 0, 0, 1}, {0, 0, 0, 2}}},
+		// The ring-closing edge runs back along the first edge, which is consecutive to it
+		{"spike on the ring-closing edge", Polygon{ring(1, 0, 2, 0, 2, 1, 3, 0, 1, 0)}, []Intersection{{0, 0, 0, 3}, {0, 1, 0, 3}}},
+		{"hole inside", Polygon{exterior, ring(1, 1, 1, 2, 2, 2, 2, 1, 1, 1)}, nil},
+		{"hole touching the exterior", Polygon{exterior, ring(0, 2, 2, 3, 2, 1, 0, 2)}, nil},
+		{"hole sharing an edge with the exterior", Polygon{exterior, ring(0, 1, 1, 2, 0, 3, 0, 1)}, nil},
+		{"hole crossing the exterior", Polygon{exterior, ring(-1, 1, 2, 1, 2, 3, -1, 3, -1, 1)}, []Intersection{{0, 3, 1, 0}, {0, 3, 1, 2}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.polygon.Intersections(maxProblems)
+			sort.Slice(got, func(i, j int) bool {
+				if got[i].Edge1 != got[j].Edge1 {
+					return got[i].Edge1 < got[j].Edge1
+				}
+				return got[i].Ring2 < got[j].Ring2 || got[i].Ring2 == got[j].Ring2 && got[i].Edge2 < got[j].Edge2
+			})
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Intersections() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIntersectionsLimit(t *testing.T) {
+	// A zigzag whose every rising edge crosses the long closing edge
+	var zigzag []Point
+	for i := 0; i <= 10; i++ {
+		zigzag = append(zigzag, Point{Lng: float64(i), Lat: float64(i % 2 * 2)})
+	}
+	zigzag = append(zigzag, Point{Lng: 0, Lat: 1}, zigzag[0])
+	if got := (Polygon{zigzag}).Intersections(3); len(got) != 3 {
+		t.Errorf("Intersections(3) found %d intersections, want 3", len(got))
+	}
+}
+
+func TestSignedRingArea(t *testing.T) {
+	counterclockwise := []Point{{0, 0}, {2, 0}, {2, 1}, {0, 1}, {0, 0}}
+	if area := signedRingArea(counterclockwise); area != 2 {
+		t.Errorf("signedRingArea of a counterclockwise ring = %g, want 2", area)
+	}
+	positions := [][]float64{{0, 0}, {2, 0}, {2, 1}, {0, 1}, {0, 0}}
+	reverse(positions)
+	clockwise := make([]Point, len(positions))
+	for i, position := range positions {
+		clockwise[i] = Point{Lng: position[0], Lat: position[1]}
+	}
+	if area := signedRingArea(clockwise); area != -2 {
+		t.Errorf("signedRingArea of a clockwise ring = %g, want -2", area)
+	}
+}
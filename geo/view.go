@@ -0,0 +1,103 @@
+package geo
+
+import "math"
+
+const (
+	// tileSize is the width in pixels of a web map tile
+	tileSize = 256
+	// defaultViewport is the size in pixels of the viewport assumed by DefaultZoom
+	defaultViewport = 512
+	// maxDefaultZoom is the street level zoom, the deepest one returned by DefaultZoom
+	maxDefaultZoom = 18
+)
+
+// Centroid returns the area-weighted centroid of the polygons of the shape, holes excluded.
+// The center of the bounding box is returned for shapes without area.
+func (s *Shape) Centroid() Point {
+	var lng, lat, area float64
+	for _, polygon := range s.Polygons {
+		for i, ring := range polygon {
+			ringLng, ringLat, signedArea := ringCentroid(ring)
+			weight := math.Abs(signedArea)
+			if i > 0 {
+				weight = -weight
+			}
+			lng += ringLng * weight
+			lat += ringLat * weight
+			area += weight
+		}
+	}
+	if area <= 0 {
+		return s.BBox.Center()
+	}
+	return Point{Lng: lng / area, Lat: lat / area}
+}
+
+// ringCentroid returns the centroid and the signed area of a ring. The vertices are taken relative
+// to the first one, so that the products of the shoelace formula stay small and do not lose the
+// precision of the coordinates.
+func ringCentroid(ring []Point) (float64, float64, float64) {
+	if len(ring) == 0 {
+		return 0, 0, 0
+	}
+	origin := ring[0]
+	var lng, lat, area float64
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		aLng, aLat := ring[j].Lng-origin.Lng, ring[j].Lat-origin.Lat
+		bLng, bLat := ring[i].Lng-origin.Lng, ring[i].Lat-origin.Lat
+		cross := aLng*bLat - bLng*aLat
+		lng += (aLng + bLng) * cross
+		lat += (aLat + bLat) * cross
+		area += cross
+	}
+	area /= 2
+	if area == 0 {
+		return 0, 0, 0
+	}
+	return origin.Lng + lng/(6*area), origin.Lat + lat/(6*area), area
+}
+
+// Center returns the middle of the box
+func (b BBox) Center() Point {
+	center := Point{Lng: (b.MinLng + b.MaxLng) / 2, Lat: (b.MinLat + b.MaxLat) / 2}
+	if b.CrossesAntimeridian() {
+		center.Lng += 180
+		if center.Lng > 180 {
+			center.Lng -= 360
+		}
+	}
+	return center
+}
+
+// FitZoom returns the deepest web map zoom level, at most maxZoom, at which the whole box
+// fits in a viewport of the given size in pixels
+func FitZoom(box BBox, width, height float64, maxZoom int) int {
+	lngSpan := box.MaxLng - box.MinLng
+	if box.CrossesAntimeridian() {
+		lngSpan += 360
+	}
+	// Spans as fractions of the width and height of the world map
+	xSpan := lngSpan / 360
+	ySpan := mercatorY(box.MinLat) - mercatorY(box.MaxLat)
+
+	zoom := float64(maxZoom)
+	if xSpan > 0 {
+		zoom = math.Min(zoom, math.Log2(width/(tileSize*xSpan)))
+	}
+	if ySpan > 0 {
+		zoom = math.Min(zoom, math.Log2(height/(tileSize*ySpan)))
+	}
+	return int(math.Max(0, math.Floor(zoom)))
+}
+
+// DefaultZoom returns the zoom level at which the box fills a typical map viewport
+func DefaultZoom(box BBox) int {
+	return FitZoom(box, defaultViewport, defaultViewport, maxDefaultZoom)
+}
+
+// mercatorY returns the web mercator ordinate of a latitude, from 0 at the north edge of the map to 1 at its south edge
+func mercatorY(lat float64) float64 {
+	const maxLatitude = 85.05112878
+	rad := math.Max(-maxLatitude, math.Min(maxLatitude, lat)) * math.Pi / 180
+	return 0.5 - math.Log(math.Tan(math.Pi/4+rad/2))/(2*math.Pi)
+}
@@ -0,0 +1,28 @@
+package geo
+
+import "testing"
+
+func TestCentroidOfBox(t *testing.T) {
+	shape, err := ParseShape([]byte(`{"type":"Polygon","coordinates":[[[20.6,42.1],[20.9,42.1],[20.9,42.3],[20.6,42.3],[20.6,42.1]]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := shape.Centroid(), (Point{Lng: 20.75, Lat: 42.2}); got != want {
+		t.Errorf("Centroid() = %v, want %v", got, want)
+	}
+}
+
+func TestCentroidExcludesHoles(t *testing.T) {
+	// A 4x4 square with a 2x2 hole in its east half, which moves the centroid west
+	shape, err := ParseShape([]byte(`{"type":"Polygon","coordinates":[
+		[[0,0],[4,0],[4,4],[0,4],[0,0]],
+		[[2,1],[4,1],[4,3],[2,3],[2,1]]
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (16*2 - 4*3) / 12 = 5/3
+	if got, want := shape.Centroid(), (Point{Lng: 5.0 / 3, Lat: 2}); got != want {
+		t.Errorf("Centroid() = %v, want %v", got, want)
+	}
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
-	"landmarksmodule/db"
+	"errors"
+	"landmarksmodule/export"
+	"landmarksmodule/geo"
 	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/search"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,141 +18,241 @@ func CreateCity(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid city data"})
 		return
 	}
-	var region models.Region
+	if !validCoordinates(c, city.Latitude, city.Longitude) {
+		return
+	}
 
 	// Retrieve the region associated with the city's region_id
-	if err := db.DB.First(&region, city.RegionID).Error; err != nil {
+	if _, err := repos.Regions.Get(city.RegionID); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
 		return
 	}
-	db.DB.Create(&city)
+	if err := repos.Cities.Create(&city); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create city"})
+		return
+	}
+	textSearch.invalidate()
 
 	c.JSON(http.StatusCreated, city)
 }
 
 func GetCities(c *gin.Context) {
-	var cities []models.City
-	db.DB.Find(&cities)
+	p, err := parseSortedPagination(c, repository.CitySortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+
+	cities, total, err := repos.Cities.List(p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cities"})
+		return
+	}
 
-	c.JSON(http.StatusOK, cities)
+	cities, next := pageOf(p, cities, cityIDOf)
+	respondCities(c, cities, next, total)
 }
 
-func GetCityByID(c *gin.Context) {
-	var city models.City
-	id := c.Param("id")
-	db.DB.First(&city, id)
+// respondCities writes a page of a city listing as JSON, or as KML or GPX placemarks when exported
+func respondCities(c *gin.Context, cities []models.City, next string, total int64) {
+	if !exporting(c) {
+		respondPage(c, cities, next, total)
+		return
+	}
+	if !localize(c, cities) {
+		return
+	}
 
-	if city.ID == 0 {
+	placemarks := make([]export.Placemark, len(cities))
+	for i, city := range cities {
+		placemarks[i] = export.Placemark{
+			Name:  city.Name,
+			Type:  "city",
+			Point: &geo.Point{Lng: city.Longitude, Lat: city.Latitude},
+		}
+	}
+	setPageLinks(c, next)
+	respondPlacemarks(c, "Cities", placemarks)
+}
+
+// findCity loads the city named by the id path parameter, responding with an error if it fails
+func findCity(c *gin.Context) (*models.City, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+		return nil, false
+	}
+
+	city, err := repos.Cities.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve city"})
+		return nil, false
+	}
+	return city, true
+}
+
+func GetCityByID(c *gin.Context) {
+	city, ok := findCity(c)
+	if !ok {
 		return
 	}
 
+	if !localize(c, city) {
+		return
+	}
 	c.JSON(http.StatusOK, city)
 }
 
 func UpdateCity(c *gin.Context) {
-	var city models.City
-	id := c.Param("id")
-
-	if err := db.DB.First(&city, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+	city, ok := findCity(c)
+	if !ok {
 		return
 	}
 
-	if err := c.BindJSON(&city); err != nil {
+	if err := c.BindJSON(city); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid city data"})
 		return
 	}
-
-	var region models.Region
+	if !validCoordinates(c, city.Latitude, city.Longitude) {
+		return
+	}
 
 	// Retrieve the region associated with the city's region_id
-	if err := db.DB.First(&region, city.RegionID).Error; err != nil {
+	if _, err := repos.Regions.Get(city.RegionID); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
 		return
 	}
 
-	db.DB.Save(&city)
+	if err := repos.Cities.Update(city); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update city"})
+		return
+	}
+	textSearch.invalidate()
 
 	c.JSON(http.StatusOK, city)
 }
 
 func DeleteCity(c *gin.Context) {
-	var city models.City
-	id := c.Param("id")
-
-	if err := db.DB.First(&city, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+	city, ok := findCity(c)
+	if !ok {
 		return
 	}
 
-	db.DB.Delete(&city)
+	if err := repos.Cities.Delete(city); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete city"})
+		return
+	}
+	textSearch.invalidate()
 
 	c.Status(http.StatusNoContent)
 }
 
 func SearchCities(c *gin.Context) {
+	p, err := parseSortedPagination(c, repository.CitySortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+
 	var cities []models.City
+	var total int64
 	query := c.Query("name")
 
 	if query != "" {
-		db.DB.Where("name LIKE ?", "%"+query+"%").Find(&cities)
+		cities, total, err = repos.Cities.Search(query, p.query())
+		// Fall back on the search index, which ignores diacritics and typos
+		if err == nil && total == 0 {
+			var ids []uint
+			if ids, err = matchingIDs(query, search.City); err == nil && len(ids) > 0 {
+				filter := repository.CityFilter{IDs: ids}
+				if len(p.sort) > 0 {
+					cities, total, err = repos.Cities.Filter(filter, p.query())
+				} else if cities, total, err = repos.Cities.Filter(filter, repository.Page{}); err == nil {
+					// Without a requested order the cities keep their ranking in the index
+					cities = rankedPage(p, cities, ids, cityIDOf)
+				}
+			}
+		}
 	} else {
 		// If no query parameter is provided, return all cities
-		db.DB.Find(&cities)
+		cities, total, err = repos.Cities.List(p.query())
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search cities"})
+		return
 	}
 
-	c.JSON(http.StatusOK, cities)
+	cities, next := pageOf(p, cities, cityIDOf)
+	respondCities(c, cities, next, total)
 }
 
 func FilterCities(c *gin.Context) {
-	var cities []models.City
+	p, err := parseSortedPagination(c, repository.CitySortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+
+	var filter repository.CityFilter
+
+	// Define the query parameters and the filter fields they set
+	intParams := map[string]**int{
+		"min_population": &filter.MinPopulation,
+		"max_population": &filter.MaxPopulation,
+	}
+	floatParams := map[string]**float64{
+		"min_area":      &filter.MinArea,
+		"max_area":      &filter.MaxArea,
+		"min_latitude":  &filter.MinLatitude,
+		"max_latitude":  &filter.MaxLatitude,
+		"min_longitude": &filter.MinLongitude,
+		"max_longitude": &filter.MaxLongitude,
+	}
 
-	// Define the query parameters and their corresponding SQL clauses
-	params := map[string]string{
-		"min_population": "population >= ?",
-		"max_population": "population <= ?",
-		"min_area":       "area >= ?",
-		"max_area":       "area <= ?",
-		"min_latitude":   "CAST(latitude AS DECIMAL) >= ?",
-		"max_latitude":   "CAST(latitude AS DECIMAL) <= ?",
-		"min_longitude":  "CAST(longitude AS DECIMAL) >= ?",
-		"max_longitude":  "CAST(longitude AS DECIMAL) <= ?",
-	}
-
-	query := db.DB
-	for param, clause := range params {
-		if value := c.Query(param); value != "" {
-			query = query.Where(clause, value)
+	for param, field := range intParams {
+		if *field, err = queryInt(c, param); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	for param, field := range floatParams {
+		if *field, err = queryFloat(c, param); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
-	if err := query.Find(&cities).Error; err != nil {
+	cities, total, err := repos.Cities.Filter(filter, p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, cities)
+	cities, next := pageOf(p, cities, cityIDOf)
+	respondCities(c, cities, next, total)
 }
 
 // GetRegionOfCity retrieves the region associated with a city by its region_id
 func GetRegionOfCity(c *gin.Context) {
-	var city models.City
-	id := c.Param("id")
-
 	// Retrieve the city from the database
-	if err := db.DB.First(&city, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+	city, ok := findCity(c)
+	if !ok {
 		return
 	}
 
-	var region models.Region
-
 	// Retrieve the region associated with the city's region_id
-	if err := db.DB.First(&region, city.RegionID).Error; err != nil {
+	region, err := repos.Regions.Get(city.RegionID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
 		return
 	}
 
+	if !localize(c, region) {
+		return
+	}
 	c.JSON(http.StatusOK, region)
 }
@@ -1,44 +1,75 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"landmarksmodule/db"
 	"landmarksmodule/models"
+	"landmarksmodule/repository"
 	"net/http"
 	"strconv"
 )
 
-// GetCountries retrieves all countries and their regions
+// GetCountries retrieves a page of countries and their regions, see parseGeometryDetail for
+// the query parameters choosing the detail of the region boundaries
 func GetCountries(c *gin.Context) {
-	var countries []models.Country
+	p, err := parsePagination(c, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	detail, err := parseGeometryDetail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Retrieve all countries
-	if err := db.DB.Find(&countries).Error; err != nil {
+	// Retrieve the requested page of countries
+	countries, total, err := repos.Countries.List(p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve countries", "details": err.Error()})
 		return
 	}
+	countries, next := pageOf(p, countries, countryIDOf)
 
-	// For each country, retrieve the associated regions and their GeoJSON data
+	// Retrieve the regions of every country of the page and their GeoJSON data at once
+	ids := make([]uint, len(countries))
 	for i := range countries {
-		var regions []models.Region
-		if err := db.DB.Where("country_id = ?", countries[i].ID).Find(&regions).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions for country", "details": err.Error()})
-			return
-		}
+		ids[i] = countries[i].ID
+	}
+	regions, err := repos.Regions.ListByCountries(ids)
+	if err == nil {
+		err = repos.GeoJSON.LoadRegionGeoJSON(regions, detail)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions for country", "details": err.Error()})
+		return
+	}
 
-		// For each region, retrieve the GeoJSON data
-		for j := range regions {
-			geoJSON, err := db.GetGeoJSONByRegionID(regions[j].ID)
-			if err == nil && geoJSON != nil {
-				regions[j].GeoJSON = geoJSON.GeoJSONData
-			}
+	// Assign the retrieved regions to their country
+	byCountry := map[uint][]models.Region{}
+	for _, region := range regions {
+		byCountry[region.CountryID] = append(byCountry[region.CountryID], region)
+	}
+	for i := range countries {
+		countries[i].Regions = byCountry[countries[i].ID]
+		if countries[i].Regions == nil {
+			countries[i].Regions = []models.Region{}
 		}
-
-		// Assign the retrieved regions to the country
-		countries[i].Regions = regions
 	}
 
-	c.JSON(http.StatusOK, countries)
+	respondPage(c, countries, next, total)
+}
+
+// loadCountryRegions retrieves the regions of a country along with their GeoJSON data in the given detail
+func loadCountryRegions(countryID uint, detail repository.GeometryDetail) ([]models.Region, error) {
+	regions, err := repos.Regions.ListByCountry(countryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := repos.GeoJSON.LoadRegionGeoJSON(regions, detail); err != nil {
+		return nil, err
+	}
+	return regions, nil
 }
 
 // CreateCountry creates a new country
@@ -48,34 +79,60 @@ func CreateCountry(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid country data"})
 		return
 	}
-	db.DB.Create(&country)
+	if !validCoordinates(c, country.Latitude, country.Longitude) {
+		return
+	}
+	if err := repos.Countries.Create(&country); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create country", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusCreated, country)
 }
 
+// findCountry loads the country named by the id path parameter, responding with an error if it fails
+func findCountry(c *gin.Context) (*models.Country, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found"})
+		return nil, false
+	}
+
+	country, err := repos.Countries.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Country not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve country"})
+		return nil, false
+	}
+	return country, true
+}
+
 // GetCountryByID retrieves a country by its ID
 func GetCountryByID(c *gin.Context) {
-	var country models.Country
-	id := c.Param("id")
-	db.DB.First(&country, id)
+	detail, err := parseGeometryDetail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	var regions []models.Region
-	if err := db.DB.Where("country_id = ?", country.ID).Find(&regions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions for country"})
+	country, ok := findCountry(c)
+	if !ok {
 		return
 	}
-	for i := range regions {
-		geoJSON, err := db.GetGeoJSONByRegionID(regions[i].ID)
-		if err == nil && geoJSON != nil {
-			regions[i].GeoJSON = geoJSON.GeoJSONData
-		}
+
+	regions, err := loadCountryRegions(country.ID, detail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions for country"})
+		return
 	}
 	country.Regions = regions
-	if country.ID == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found"})
+
+	if !localize(c, country) {
 		return
 	}
-
 	c.JSON(http.StatusOK, country)
 }
 
@@ -87,72 +144,96 @@ func GetCountryByLatLong(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid latitude or longitude"})
 		return
 	}
-
-	var country models.Country
-	query := `
-		SELECT *,
-		       (6371 * acos(cos(radians(?)) *
-		                    cos(radians(latitude)) *
-		                    cos(radians(longitude) - radians(?)) +
-		                    sin(radians(?)) *
-		                    sin(radians(latitude)))) AS distance
-		FROM countries
-		ORDER BY distance
-		LIMIT 1`
-
-	if err := db.DB.Raw(query, latitude, longitude, latitude).Scan(&country).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found", "details": err.Error()})
+	detail, err := parseGeometryDetail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var regions []models.Region
-	if err := db.DB.Where("country_id = ?", country.ID).Find(&regions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions for country", "details": err.Error()})
+	// Prefer the country of the region whose boundary contains the point, the closest
+	// country centroid is only a fallback for points outside of every known boundary
+	var country *models.Country
+	region, err := locateRegion(latitude, longitude)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up region", "details": err.Error()})
 		return
 	}
-
-	for i := range regions {
-		geoJSON, err := db.GetGeoJSONByRegionID(regions[i].ID)
-		if err == nil && geoJSON != nil {
-			regions[i].GeoJSON = geoJSON.GeoJSONData
+	if region != nil && region.CountryID != 0 {
+		country, err = repos.Countries.Get(region.CountryID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve country", "details": err.Error()})
+			return
+		}
+	}
+	if country == nil {
+		country, err = repos.Countries.Nearest(latitude, longitude)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Country not found", "details": err.Error()})
+			return
 		}
 	}
+
+	regions, err := loadCountryRegions(country.ID, detail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions for country", "details": err.Error()})
+		return
+	}
 	country.Regions = regions
 
+	if !localize(c, country) {
+		return
+	}
 	c.JSON(http.StatusOK, country)
 }
 
 // UpdateCountry updates a country's information
 func UpdateCountry(c *gin.Context) {
-	var country models.Country
-	id := c.Param("id")
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found", "details": err.Error()})
+		return
+	}
 
-	if err := db.DB.First(&country, id).Error; err != nil {
+	country, err := repos.Countries.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found", "details": err.Error()})
 		return
 	}
 
-	if err := c.BindJSON(&country); err != nil {
+	if err := c.BindJSON(country); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid country data", "details": err.Error()})
 		return
 	}
+	if !validCoordinates(c, country.Latitude, country.Longitude) {
+		return
+	}
 
-	db.DB.Save(&country)
+	if err := repos.Countries.Update(country); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update country", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, country)
 }
 
 // DeleteCountry deletes a country by its ID
 func DeleteCountry(c *gin.Context) {
-	var country models.Country
-	id := c.Param("id")
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found", "details": err.Error()})
+		return
+	}
 
-	if err := db.DB.First(&country, id).Error; err != nil {
+	country, err := repos.Countries.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Country not found", "details": err.Error()})
 		return
 	}
 
-	db.DB.Delete(&country)
+	if err := repos.Countries.Delete(country); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete country", "details": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Country deleted successfully"})
 }
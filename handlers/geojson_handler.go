@@ -2,166 +2,302 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"landmarksmodule/db"
+	"landmarksmodule/geo"
 	"landmarksmodule/models"
+	"landmarksmodule/tiles"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// CreateGeoJSON stores the boundary of a region, see bindBoundary for the accepted bodies
 func CreateGeoJSON(c *gin.Context) {
-	var region models.Region
-	id := c.Param("region_id")
-
 	// Retrieve the region from the database
-	if err := db.DB.First(&region, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+	region, ok := findGeoJSONRegion(c, "region_id")
+	if !ok {
 		return
 	}
 
-	// Parse the JSON request body
-	var geoJSONData map[string]interface{}
-	if err := c.BindJSON(&geoJSONData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid GeoJSON data"})
+	// Validate and normalize the boundary
+	boundary, ok := bindBoundary(c)
+	if !ok {
 		return
 	}
 
-	// Marshal the GeoJSON data
-	geoJSONBytes, err := json.Marshal(geoJSONData)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal GeoJSON"})
+	// Create a new GeoJSON record
+	geoJSONRecord := models.GeoJSON{
+		RegionID:    region.ID,
+		GeoJSONData: boundary.data,
+		MapView:     boundary.view,
+		UpdatedAt:   time.Now(), // Set updated timestamp
+	}
+
+	if err := repos.GeoJSON.Create(&geoJSONRecord); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save GeoJSON to database"})
+		return
+	}
+	if err := repos.GeoJSON.ReplaceSimplifications(geoJSONRecord.ID, boundary.simplifications); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save simplified GeoJSON to database"})
 		return
 	}
+	locator.invalidate()
+	tiles.Invalidate()
+
+	// Respond with the GeoJSON record ID
+	c.JSON(http.StatusOK, gin.H{"geojson_id": geoJSONRecord.ID})
+}
+
+// boundaryUpload is a validated region boundary with its map view
+type boundaryUpload struct {
+	// data is the boundary normalized to a FeatureCollection
+	data string
+	view models.MapView
+	// simplifications are the versions of data simplified for lower zoom levels
+	simplifications []models.GeoJSONSimplification
+}
+
+// boundaryMetadata are the members of the request body which describe the map view rather than the boundary
+var boundaryMetadata = map[string]bool{"middle_point": true, "bbox": true, "zoom": true}
+
+// bindBoundary reads a region boundary from the request body, responding with an error if it fails.
+// The body is either {"geojson": <GeoJSON>, "middle_point": ..., "bbox": ..., "zoom": z} or, as formerly,
+// a GeoJSON object carrying the map view members itself. middle_point is [longitude, latitude] or
+// {"latitude": ..., "longitude": ...} and bbox is [west, south, east, north]. The map view members are
+// optional, missing ones are computed from the boundary: the centroid, the bounding box and the zoom
+// level showing the whole boundary. Every problem of the body is reported at once with a 422 status,
+// located by its JSON path.
+func bindBoundary(c *gin.Context) (*boundaryUpload, bool) {
+	// Parse the JSON request body
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid GeoJSON data", "details": err.Error()})
+		return nil, false
+	}
 
-	// Extract middle point coordinates from request body
-	var middlePoint [2]float64
-	if middlePointJSON, ok := geoJSONData["middle_point"].([]interface{}); ok && len(middlePointJSON) == 2 {
-		if x, ok := middlePointJSON[0].(float64); ok {
-			middlePoint[0] = x
+	// Separate the map view from the GeoJSON document
+	var problems []geo.Problem
+	document, wrapped := body["geojson"].(map[string]interface{})
+	if !wrapped {
+		if _, present := body["geojson"]; present {
+			problems = append(problems, geo.Problem{Path: "$.geojson", Message: "must be an object"})
 		}
-		if y, ok := middlePointJSON[1].(float64); ok {
-			middlePoint[1] = y
+		document = map[string]interface{}{}
+		for key, value := range body {
+			if !boundaryMetadata[key] {
+				document[key] = value
+			}
 		}
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid middle_point format"})
-		return
 	}
 
-	// Extract zoom level from request body
-	var zoom float64
-	if zoomJSON, ok := geoJSONData["zoom"].(float64); ok {
-		zoom = zoomJSON
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zoom format"})
-		return
+	var middlePoint *models.Coordinates
+	if value, present := body["middle_point"]; present {
+		point, err := parseMiddlePoint(value)
+		if err != nil {
+			problems = append(problems, geo.Problem{Path: "$.middle_point", Message: err.Error()})
+		}
+		middlePoint = &point
 	}
 
-	// Convert middle point to string format
-	middlePointString := fmt.Sprintf("[%f, %f]", middlePoint[0], middlePoint[1])
+	var box *geo.BBox
+	if value, present := body["bbox"]; present {
+		parsed, err := parseBoundingBox(value)
+		if err != nil {
+			problems = append(problems, geo.Problem{Path: "$.bbox", Message: err.Error()})
+		}
+		box = &parsed
+	}
 
-	// Create a new GeoJSON record
-	geoJSONRecord := models.GeoJSON{
-		RegionID:    region.ID,
-		GeoJSONData: string(geoJSONBytes),
-		MiddlePoint: middlePointString,
-		Zoom:        zoom,
-		UpdatedAt:   time.Now(), // Set updated timestamp
+	var zoom *float64
+	if value, present := body["zoom"]; present {
+		number, ok := value.(float64)
+		if !ok || number < 0 || number > maxMapZoom {
+			problems = append(problems, geo.Problem{Path: "$.zoom", Message: fmt.Sprintf("must be a number between 0 and %d", maxMapZoom)})
+		}
+		zoom = &number
 	}
 
-	if err := db.DB.Create(&geoJSONRecord).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save GeoJSON to database"})
-		return
+	// Paths of the document are relative to the geojson member when it is wrapped
+	normalized, err := geo.NormalizeBoundary(document)
+	var invalid *geo.ValidationError
+	if errors.As(err, &invalid) {
+		for _, problem := range invalid.Problems {
+			if wrapped {
+				problem.Path = "$.geojson" + strings.TrimPrefix(problem.Path, "$")
+			}
+			problems = append(problems, problem)
+		}
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate GeoJSON"})
+		return nil, false
 	}
 
-	// Respond with the GeoJSON record ID
-	c.JSON(http.StatusOK, gin.H{"geojson_id": geoJSONRecord.ID})
-}
+	if len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid GeoJSON", "details": problems})
+		return nil, false
+	}
 
-func GetAllGeoJSON(c *gin.Context) {
-	var geoJSONRecords []models.GeoJSON
+	// Marshal the normalized GeoJSON data
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal GeoJSON"})
+		return nil, false
+	}
+	shape, err := geo.ParseShape(data)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse normalized GeoJSON"})
+		return nil, false
+	}
+	simplifications, err := simplifyBoundary(string(data))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simplify GeoJSON"})
+		return nil, false
+	}
 
-	// Retrieve all GeoJSON records from the database
-	if err := db.DB.Find(&geoJSONRecords).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve GeoJSON records"})
-		return
+	// Complete the map view from the boundary
+	if middlePoint == nil {
+		centroid := shape.Centroid()
+		middlePoint = &models.Coordinates{Latitude: centroid.Lat, Longitude: centroid.Lng}
+	}
+	if box == nil {
+		box = &shape.BBox
+	}
+	if zoom == nil {
+		defaultZoom := float64(geo.DefaultZoom(*box))
+		zoom = &defaultZoom
 	}
 
-	// Respond with the list of GeoJSON records
-	c.JSON(http.StatusOK, geoJSONRecords)
+	return &boundaryUpload{
+		data: string(data),
+		view: models.MapView{
+			MiddlePoint: *middlePoint,
+			BBox: models.BoundingBox{
+				MinLatitude:  box.MinLat,
+				MinLongitude: box.MinLng,
+				MaxLatitude:  box.MaxLat,
+				MaxLongitude: box.MaxLng,
+			},
+			Zoom: *zoom,
+		},
+		simplifications: simplifications,
+	}, true
 }
 
-func UpdateGeoJSON(c *gin.Context) {
-	var region models.Region
-	id := c.Param("id")
+// parseMiddlePoint reads a middle point given as [longitude, latitude] or as {"latitude": ..., "longitude": ...}
+func parseMiddlePoint(value interface{}) (models.Coordinates, error) {
+	var point models.Coordinates
+	var okLat, okLng bool
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) == 2 {
+			point.Longitude, okLng = v[0].(float64)
+			point.Latitude, okLat = v[1].(float64)
+		}
+	case map[string]interface{}:
+		point.Latitude, okLat = v["latitude"].(float64)
+		point.Longitude, okLng = v["longitude"].(float64)
+	}
+	if !okLat || !okLng {
+		return point, errors.New("must be [longitude, latitude] or an object with latitude and longitude")
+	}
+	return point, geo.ValidateCoordinates(point.Latitude, point.Longitude)
+}
 
-	// Retrieve the region from the database
-	if err := db.DB.First(&region, id).Error; err != nil {
+// parseBoundingBox reads a GeoJSON bbox, [west, south, east, north]
+func parseBoundingBox(value interface{}) (geo.BBox, error) {
+	edges, ok := value.([]interface{})
+	if !ok || len(edges) != 4 {
+		return geo.BBox{}, errors.New("must be [west, south, east, north]")
+	}
+	parts := make([]string, len(edges))
+	for i, edge := range edges {
+		number, ok := edge.(float64)
+		if !ok {
+			return geo.BBox{}, errors.New("must be [west, south, east, north]")
+		}
+		parts[i] = strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return parseBBox(strings.Join(parts, ","))
+}
+
+// findGeoJSONRegion loads the region named by the given path parameter, responding with an error if it fails
+func findGeoJSONRegion(c *gin.Context, param string) (*models.Region, bool) {
+	id, err := parseID(c.Param(param))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
-		return
+		return nil, false
 	}
 
-	// Parse the JSON request body
-	var geoJSONData map[string]interface{}
-	if err := c.BindJSON(&geoJSONData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid GeoJSON data"})
-		return
+	region, err := repos.Regions.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+		return nil, false
 	}
+	return region, true
+}
 
-	// Marshal the GeoJSON data
-	geoJSONBytes, err := json.Marshal(geoJSONData)
+func GetAllGeoJSON(c *gin.Context) {
+	p, err := parsePagination(c, true)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal GeoJSON"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Extract middle point coordinates from request body
-	var middlePoint [2]float64
-	if middlePointJSON, ok := geoJSONData["middle_point"].([]interface{}); ok && len(middlePointJSON) == 2 {
-		if x, ok := middlePointJSON[0].(float64); ok {
-			middlePoint[0] = x
-		}
-		if y, ok := middlePointJSON[1].(float64); ok {
-			middlePoint[1] = y
-		}
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid middle_point format"})
+	// Retrieve the requested page of GeoJSON records from the database
+	geoJSONRecords, total, err := repos.GeoJSON.List(p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve GeoJSON records"})
 		return
 	}
 
-	// Extract zoom level from request body
-	var zoom float64
-	if zoomJSON, ok := geoJSONData["zoom"].(float64); ok {
-		zoom = zoomJSON
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zoom format"})
+	// Respond with the page of GeoJSON records
+	geoJSONRecords, next := pageOf(p, geoJSONRecords, geoJSONIDOf)
+	respondPage(c, geoJSONRecords, next, total)
+}
+
+// UpdateGeoJSON replaces the boundary of a region, see bindBoundary for the accepted bodies
+func UpdateGeoJSON(c *gin.Context) {
+	// Retrieve the region from the database
+	region, ok := findGeoJSONRegion(c, "id")
+	if !ok {
 		return
 	}
 
-	// Convert middle point to string format
-	middlePointString := fmt.Sprintf("[%f, %f]", middlePoint[0], middlePoint[1])
+	// Validate and normalize the boundary
+	boundary, ok := bindBoundary(c)
+	if !ok {
+		return
+	}
 
 	// Check if a GeoJSON record already exists for the region
-	var existingGeoJSON models.GeoJSON
-	if err := db.DB.Where("region_id = ?", region.ID).First(&existingGeoJSON).Error; err != nil {
+	existingGeoJSON, err := repos.GeoJSON.GetByRegion(region.ID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "GeoJSON not found"})
 		return
 	}
 
 	// Update the existing record
-	existingGeoJSON.GeoJSONData = string(geoJSONBytes)
-	existingGeoJSON.MiddlePoint = middlePointString
-	existingGeoJSON.Zoom = zoom
+	existingGeoJSON.GeoJSONData = boundary.data
+	existingGeoJSON.MapView = boundary.view
 	existingGeoJSON.UpdatedAt = time.Now() // Update updatedAt timestamp
 
-	if err := db.DB.Save(&existingGeoJSON).Error; err != nil {
+	if err := repos.GeoJSON.Update(existingGeoJSON); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update GeoJSON in database"})
 		return
 	}
+	if err := repos.GeoJSON.ReplaceSimplifications(existingGeoJSON.ID, boundary.simplifications); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save simplified GeoJSON to database"})
+		return
+	}
+	locator.invalidate()
+	tiles.Invalidate()
 
 	// Update the region's updatedAt timestamp
 	region.UpdatedAt = time.Now()
-	if err := db.DB.Save(&region).Error; err != nil {
+	if err := repos.Regions.Update(region); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update region's updatedAt in database"})
 		return
 	}
@@ -173,12 +309,15 @@ func UpdateGeoJSON(c *gin.Context) {
 const TimeFormat = "2006-01-02T15:04:05Z07:00"
 
 func GetGeoJSONFromDB(c *gin.Context) {
-	regionID := c.Param("id")
-
-	var geoJSONRecord models.GeoJSON
+	regionID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "GeoJSON not found"})
+		return
+	}
 
 	// Retrieve the GeoJSON record from the database
-	if err := db.DB.Where("region_id = ?", regionID).First(&geoJSONRecord).Error; err != nil {
+	geoJSONRecord, err := repos.GeoJSON.GetByRegion(regionID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "GeoJSON not found"})
 		return
 	}
@@ -202,6 +341,9 @@ func GetGeoJSONFromDB(c *gin.Context) {
 
 	response := gin.H{
 		"geojson_data": geoJSONData,
+		"middle_point": geoJSONRecord.MiddlePoint,
+		"bbox":         geoJSONRecord.BBox,
+		"zoom":         geoJSONRecord.Zoom,
 		"created_at":   geoJSONRecord.CreatedAt,
 	}
 
@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"landmarksmodule/models"
+)
+
+func TestCreateGeoJSONReportsProblems(t *testing.T) {
+	s := newServer(t)
+	region := &models.Region{Name: "Prizren"}
+	if err := s.repos.Regions.Create(region); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/geojson/%d", region.ID)
+	bowTie := map[string]interface{}{"type": "Polygon", "coordinates": [][][]float64{{{0, 0}, {2, 2}, {2, 0}, {0, 2}, {0, 0}}}}
+	unclosed := map[string]interface{}{"type": "Polygon", "coordinates": [][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}}
+
+	tests := []struct {
+		name  string
+		body  map[string]interface{}
+		paths []string
+	}{
+		{"bow-tie", bowTie, []string{"$.coordinates[0]"}},
+		{"wrapped bow-tie", map[string]interface{}{"geojson": bowTie}, []string{"$.geojson.coordinates[0]"}},
+		{"unclosed ring and invalid zoom", map[string]interface{}{"geojson": unclosed, "zoom": 99}, []string{"$.zoom", "$.geojson.coordinates[0]"}},
+		{"geojson member which is not an object", map[string]interface{}{"geojson": "Prizren"}, []string{"$.geojson", "$.type"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var response struct {
+				Details []struct {
+					Path string `json:"path"`
+				} `json:"details"`
+			}
+			s.expect(http.StatusUnprocessableEntity, http.MethodPost, path, test.body, &response)
+			var paths []string
+			for _, problem := range response.Details {
+				paths = append(paths, problem.Path)
+			}
+			if !reflect.DeepEqual(paths, test.paths) {
+				t.Errorf("problems at %v, want %v", paths, test.paths)
+			}
+		})
+	}
+
+	var created map[string]uint
+	square := map[string]interface{}{"type": "Polygon", "coordinates": [][][]float64{{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}}}
+	s.expect(http.StatusOK, http.MethodPost, path, map[string]interface{}{"geojson": square}, &created)
+	if created["geojson_id"] == 0 {
+		t.Errorf("valid boundary created as %v", created)
+	}
+}
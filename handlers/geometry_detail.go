@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"strconv"
+)
+
+// parseGeometryDetail reads the query parameters choosing how region boundaries are returned.
+// geometry=false omits them, leaving the map view only. zoom returns them simplified for a zoom
+// level and tolerance returns them without the details smaller than the tolerance in degrees,
+// both using the nearest simplification saved along with the boundary.
+func parseGeometryDetail(c *gin.Context) (repository.GeometryDetail, error) {
+	var detail repository.GeometryDetail
+	if value := c.Query("geometry"); value != "" {
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			return detail, errors.New("geometry query parameter must be true or false")
+		}
+		detail.Omit = !include
+	}
+
+	zoom, err := queryInt(c, "zoom")
+	if err != nil {
+		return detail, err
+	}
+	tolerance, err := queryFloat(c, "tolerance")
+	if err != nil {
+		return detail, err
+	}
+
+	var level int
+	switch {
+	case zoom != nil && tolerance != nil:
+		return detail, errors.New("zoom and tolerance query parameters cannot be combined")
+	case zoom != nil:
+		if *zoom < 0 || *zoom > maxMapZoom {
+			return detail, fmt.Errorf("zoom must be an integer between 0 and %d", maxMapZoom)
+		}
+		level = *zoom
+	case tolerance != nil:
+		if *tolerance <= 0 {
+			return detail, errors.New("tolerance must be greater than 0")
+		}
+		level = geo.ToleranceZoom(*tolerance)
+	default:
+		return detail, nil
+	}
+
+	if simplified, ok := geo.SimplifiedZoom(level); ok {
+		detail.Zoom = &simplified
+	}
+	return detail, nil
+}
+
+// simplifyBoundary simplifies a normalized boundary for every level of geo.SimplifiedZooms
+func simplifyBoundary(data string) ([]models.GeoJSONSimplification, error) {
+	levels, err := geo.SimplifyLevels([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	simplifications := make([]models.GeoJSONSimplification, 0, len(levels))
+	for _, zoom := range geo.SimplifiedZooms {
+		simplifications = append(simplifications, models.GeoJSONSimplification{Zoom: zoom, GeoJSONData: string(levels[zoom])})
+	}
+	return simplifications, nil
+}
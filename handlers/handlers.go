@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/config"
+	"landmarksmodule/geo"
+	"landmarksmodule/repository"
+	"net/http"
+	"strconv"
+)
+
+// repos gives the handlers access to the stored data
+var repos repository.Repositories
+
+// landmarkSettings controls how landmarks are placed in cities
+var landmarkSettings config.LandmarksConfig
+
+// Init sets the repositories and settings used by the handlers, dropping the indexes built
+// from the previous repositories
+func Init(r repository.Repositories, landmarks config.LandmarksConfig) {
+	repos = r
+	landmarkSettings = landmarks
+	locator.invalidate()
+	textSearch.invalidate()
+}
+
+// parseID parses a numeric identifier taken from the path or the request body
+func parseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid id %q", value)
+	}
+	return uint(id), nil
+}
+
+// validCoordinates checks the coordinates of a request body, responding with an error if they are out of range
+func validCoordinates(c *gin.Context, latitude, longitude float64) bool {
+	if err := geo.ValidateCoordinates(latitude, longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coordinates", "details": err.Error()})
+		return false
+	}
+	return true
+}
+
+// queryInt parses an optional integer query parameter, returning nil when it is absent
+func queryInt(c *gin.Context, name string) (*int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s query parameter", name)
+	}
+	return &i, nil
+}
+
+// queryFloat parses an optional decimal query parameter, returning nil when it is absent
+func queryFloat(c *gin.Context, name string) (*float64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s query parameter", name)
+	}
+	return &f, nil
+}
@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"landmarksmodule/config"
+	"landmarksmodule/handlers"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/repository/memory"
+	"landmarksmodule/routes"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// page is the pagination envelope of the listings
+type page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	Total      int64   `json:"total"`
+}
+
+// server serves the API over memory repositories
+type server struct {
+	t      testing.TB
+	router *gin.Engine
+	repos  repository.Repositories
+}
+
+func newServer(t *testing.T) *server {
+	t.Helper()
+	repos := memory.New()
+	handlers.Init(repos, config.Default().Landmarks)
+	return &server{t: t, router: routes.NewRouter(), repos: repos}
+}
+
+// do sends a request with body encoded as JSON unless it is nil, and returns the response
+func (s *server) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader *bytes.Reader
+	switch body := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(body))
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	request := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// expect sends a request, checks the status of the response and decodes its body into result
+// unless it is nil
+func (s *server) expect(status int, method, path string, body, result interface{}) {
+	s.t.Helper()
+	response := s.do(method, path, body)
+	if response.Code != status {
+		s.t.Fatalf("%s %s: status %d, want %d: %s", method, path, response.Code, status, response.Body)
+	}
+	if result != nil {
+		if err := json.Unmarshal(response.Body.Bytes(), result); err != nil {
+			s.t.Fatalf("%s %s: %v: %s", method, path, err, response.Body)
+		}
+	}
+}
+
+// city stores a city with a region, for landmarks to be placed in
+func (s *server) city() *models.City {
+	s.t.Helper()
+	region := &models.Region{Name: "Prizren"}
+	if err := s.repos.Regions.Create(region); err != nil {
+		s.t.Fatal(err)
+	}
+	city := &models.City{Name: "Prizren", Latitude: 42.2139, Longitude: 20.7397, RegionID: region.ID}
+	if err := s.repos.Cities.Create(city); err != nil {
+		s.t.Fatal(err)
+	}
+	return city
+}
+
+// landmarkType adds a type to the taxonomy
+func (s *server) landmarkType(slug string) {
+	s.t.Helper()
+	if err := s.repos.LandmarkTypes.Create(&models.LandmarkType{Slug: slug, Name: slug}); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// landmark stores a landmark in a city
+func (s *server) landmark(name string, cityID uint) *models.Landmark {
+	s.t.Helper()
+	landmark := &models.Landmark{Name: name, CityID: cityID}
+	if err := s.repos.Landmarks.Create(landmark); err != nil {
+		s.t.Fatal(err)
+	}
+	return landmark
+}
+
+// collect follows the next cursors of a listing from path, which already has a query, and
+// returns the ids of all of its rows along with the number of pages
+func collect[T any](s *server, path string, id func(row T) uint) ([]uint, int) {
+	s.t.Helper()
+	var ids []uint
+	pages := 0
+	for next := path; ; pages++ {
+		var response page[T]
+		s.expect(http.StatusOK, http.MethodGet, next, nil, &response)
+		for _, row := range response.Data {
+			ids = append(ids, id(row))
+		}
+		if response.NextCursor == nil {
+			return ids, pages + 1
+		}
+		next = path + "&cursor=" + *response.NextCursor
+	}
+}
+
+// distinct reports whether the ids are all different
+func distinct(ids []uint) bool {
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
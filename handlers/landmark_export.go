@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/export"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// listingFormatKey is the context key holding the format a listing is exported in,
+// it is unset for the plain JSON listings
+const listingFormatKey = "listing_format"
+
+const (
+	formatGeoJSON = "geojson"
+	formatKML     = "kml"
+	formatGPX     = "gpx"
+)
+
+// exportFormats maps the path extensions of the exports to their formats
+var exportFormats = map[string]string{
+	".geojson": formatGeoJSON,
+	".kml":     formatKML,
+	".gpx":     formatGPX,
+}
+
+// Export serves a listing in the format named by the extension of the request path, such as
+// /landmarks/filter.kml for FilterLandmarks
+func Export(listing gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if format, ok := exportFormats[path.Ext(c.Request.URL.Path)]; ok {
+			c.Set(listingFormatKey, format)
+		}
+		listing(c)
+	}
+}
+
+// exporting reports whether the listing is exported in a format other than JSON
+func exporting(c *gin.Context) bool {
+	return c.GetString(listingFormatKey) != ""
+}
+
+// exportParam returns a path parameter without its export extension, such as the .geojson of
+// /landmarks/city/3.geojson, selecting the export format named by the extension
+func exportParam(c *gin.Context, name string) string {
+	value := c.Param(name)
+	for extension, format := range exportFormats {
+		if id, ok := strings.CutSuffix(value, extension); ok {
+			c.Set(listingFormatKey, format)
+			return id
+		}
+	}
+	return value
+}
+
+// respondLandmarks writes a page of a landmark listing in the format chosen for the request
+func respondLandmarks(c *gin.Context, landmarks []models.Landmark, next string, total int64) {
+	writeLandmarks(c, landmarks, nil, next, total)
+}
+
+// respondFacetedLandmarks writes a page of a filtered landmark listing along with the facet counts
+// of the filter, which the KML and GPX exports leave out
+func respondFacetedLandmarks(c *gin.Context, landmarks []models.Landmark, filter repository.LandmarkFilter, next string, total int64) {
+	var facets *repository.LandmarkFacets
+	if format := c.GetString(listingFormatKey); format == "" || format == formatGeoJSON {
+		var err error
+		if facets, err = repos.Landmarks.Facets(filter); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count landmark facets"})
+			return
+		}
+	}
+	writeLandmarks(c, landmarks, facets, next, total)
+}
+
+// writeLandmarks writes a page of landmarks and their optional facet counts in the format chosen for the request
+func writeLandmarks(c *gin.Context, landmarks []models.Landmark, facets *repository.LandmarkFacets, next string, total int64) {
+	format := c.GetString(listingFormatKey)
+	if format != "" && !localize(c, landmarks) {
+		return
+	}
+	switch format {
+	case formatGeoJSON:
+		respondLandmarksGeoJSON(c, landmarks, facets, next, total)
+	case formatKML, formatGPX:
+		placemarks := make([]export.Placemark, len(landmarks))
+		for i, landmark := range landmarks {
+			placemarks[i] = export.Placemark{
+				Name:        landmark.Name,
+				Description: landmark.Description,
+				Type:        landmark.Type,
+				Point:       &geo.Point{Lng: landmark.Longitude, Lat: landmark.Latitude},
+			}
+		}
+		setPageLinks(c, next)
+		respondPlacemarks(c, "Landmarks", placemarks)
+	default:
+		respondFacetedPage(c, landmarks, facets, next, total)
+	}
+}
+
+type featureCollection struct {
+	Type     string            `json:"type"`
+	Features []landmarkFeature `json:"features"`
+	// Foreign members carrying the pagination and the facet counts of the listing
+	NextCursor *string                    `json:"next_cursor"`
+	Total      int64                      `json:"total"`
+	Facets     *repository.LandmarkFacets `json:"facets,omitempty"`
+}
+
+type landmarkFeature struct {
+	Type       string             `json:"type"`
+	ID         uint               `json:"id"`
+	Geometry   pointGeometry      `json:"geometry"`
+	Properties landmarkProperties `json:"properties"`
+}
+
+type pointGeometry struct {
+	Type string `json:"type"`
+	// Coordinates are the longitude and the latitude, in this order
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type landmarkProperties struct {
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	Information   string    `json:"information"`
+	Description   string    `json:"description"`
+	CityID        uint      `json:"city_id"`
+	PhotoLinks    []string  `json:"photo_links"`
+	AverageRating float64   `json:"average_rating"`
+	ReviewCount   int64     `json:"review_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// respondLandmarksGeoJSON writes a page of landmarks as a FeatureCollection of points, with the
+// photo links and review statistics of every landmark loaded at once
+func respondLandmarksGeoJSON(c *gin.Context, landmarks []models.Landmark, facets *repository.LandmarkFacets, next string, total int64) {
+	if err := repos.Photos.LoadLandmarkPhotoLinks(landmarks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for landmarks"})
+		return
+	}
+
+	ids := make([]uint, len(landmarks))
+	for i := range landmarks {
+		ids[i] = landmarks[i].ID
+	}
+	stats, err := repos.Reviews.StatsByLandmarks(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate review statistics"})
+		return
+	}
+
+	collection := featureCollection{Type: "FeatureCollection", Features: make([]landmarkFeature, len(landmarks)), Total: total, Facets: facets}
+	for i, landmark := range landmarks {
+		photoLinks := landmark.PhotoLinks
+		if photoLinks == nil {
+			photoLinks = []string{}
+		}
+		collection.Features[i] = landmarkFeature{
+			Type: "Feature",
+			ID:   landmark.ID,
+			Geometry: pointGeometry{
+				Type:        "Point",
+				Coordinates: [2]float64{landmark.Longitude, landmark.Latitude},
+			},
+			Properties: landmarkProperties{
+				Name:          landmark.Name,
+				Type:          landmark.Type,
+				Information:   landmark.Information,
+				Description:   landmark.Description,
+				CityID:        landmark.CityID,
+				PhotoLinks:    photoLinks,
+				AverageRating: stats[landmark.ID].AverageRating,
+				ReviewCount:   stats[landmark.ID].ReviewCount,
+				CreatedAt:     landmark.CreatedAt,
+				UpdatedAt:     landmark.UpdatedAt,
+			},
+		}
+	}
+	if next != "" {
+		collection.NextCursor = &next
+	}
+	setPageLinks(c, next)
+
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, collection)
+}
+
+// respondPlacemarks writes placemarks as a KML or GPX document of the given name, in the format
+// chosen for the request. The XML encoder escapes the names and descriptions.
+func respondPlacemarks(c *gin.Context, name string, placemarks []export.Placemark) {
+	write, contentType := export.WriteKML, export.KMLContentType
+	if c.GetString(listingFormatKey) == formatGPX {
+		write, contentType = export.WriteGPX, export.GPXContentType
+	}
+
+	var document bytes.Buffer
+	if err := write(&document, name, placemarks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + name})
+		return
+	}
+	c.Data(http.StatusOK, contentType, document.Bytes())
+}
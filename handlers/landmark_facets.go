@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/repository"
+	"strconv"
+	"strings"
+)
+
+// queryList reads a multi-valued query parameter, given as a comma separated list, repeated, or both
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// queryIDs reads a multi-valued query parameter of ids
+func queryIDs(c *gin.Context, name string) ([]uint, error) {
+	var ids []uint
+	for _, value := range queryList(c, name) {
+		id, err := parseID(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s query parameter", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseLandmarkFilter reads the filters of the landmark search and filter listings. The type,
+// city_id, region_id and rating parameters accept several values, see queryList, and match the
+// landmarks having any of them. Ratings are the buckets of repository.RatingBuckets.
+func parseLandmarkFilter(c *gin.Context) (repository.LandmarkFilter, error) {
+	filter := repository.LandmarkFilter{Types: queryList(c, "type")}
+
+	var err error
+	if filter.CityIDs, err = queryIDs(c, "city_id"); err != nil {
+		return filter, err
+	}
+	if filter.RegionIDs, err = queryIDs(c, "region_id"); err != nil {
+		return filter, err
+	}
+
+	for _, value := range queryList(c, "rating") {
+		bucket := repository.RatingBucket(value)
+		known := false
+		for _, b := range repository.RatingBuckets {
+			known = known || b == bucket
+		}
+		if !known {
+			return filter, fmt.Errorf("invalid rating query parameter %q, expected one of %v", value, repository.RatingBuckets)
+		}
+		filter.RatingBuckets = append(filter.RatingBuckets, bucket)
+	}
+
+	if value := c.Query("has_photos"); value != "" {
+		hasPhotos, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("invalid has_photos query parameter")
+		}
+		filter.HasPhotos = &hasPhotos
+	}
+
+	// Define the query parameters and the filter fields they set
+	floatParams := map[string]**float64{
+		"min_latitude":  &filter.MinLatitude,
+		"max_latitude":  &filter.MaxLatitude,
+		"min_longitude": &filter.MinLongitude,
+		"max_longitude": &filter.MaxLongitude,
+	}
+	for param, field := range floatParams {
+		if *field, err = queryFloat(c, param); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
-	"landmarksmodule/db"
 	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/search"
+	"landmarksmodule/tiles"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
-// CreateLandmark handles creating a new landmark without photos
+// CreateLandmark handles creating a new landmark without photos. The city is resolved from
+// the coordinates when city_id is omitted.
 func CreateLandmark(c *gin.Context) {
 	var input models.Landmark
 
@@ -24,108 +29,139 @@ func CreateLandmark(c *gin.Context) {
 		return
 	}
 
-	// Validate city existence
-	var city models.City
-	if err := db.DB.First(&city, input.CityID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "City with the specified city_id does not exist"})
+	if !validCoordinates(c, input.Latitude, input.Longitude) {
+		return
+	}
+	if !validLandmarkType(c, &input) {
+		return
+	}
+
+	// Resolve or validate the city
+	if !placeLandmark(c, &input) {
 		return
 	}
 
 	// Create the landmark
-	if err := db.DB.Create(&input).Error; err != nil {
+	if err := repos.Landmarks.Create(&input); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create landmark"})
 		return
 	}
+	tiles.Invalidate()
+	textSearch.invalidate()
 
 	c.JSON(http.StatusCreated, input)
 }
 
 func GetLandmarks(c *gin.Context) {
-	var landmarks []models.Landmark
+	p, err := parseSortedPagination(c, repository.LandmarkSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
 
 	// Retrieve landmarks from the database
-	if err := db.DB.Find(&landmarks).Error; err != nil {
+	landmarks, total, err := repos.Landmarks.List(p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmarks"})
 		return
 	}
+	landmarks, next := pageOf(p, landmarks, landmarkIDOf)
 
-	// Check if the 'reviews' query parameter is present
-	includeReviews := c.Query("reviews") != ""
-
-	// Populate PhotoLinks and omit Photos
-	for i := range landmarks {
-		var photos []models.LandmarkPhoto
-		if err := db.DB.Where("landmark_id = ?", landmarks[i].ID).Find(&photos).Error; err != nil {
+	// Populate PhotoLinks and omit Photos, exports load them on their own
+	if !exporting(c) {
+		if err := repos.Photos.LoadLandmarkPhotoLinks(landmarks); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for landmark"})
 			return
 		}
+	}
 
-		var photoLinks []string
-		for _, photo := range photos {
-			photoLinks = append(photoLinks, photo.Path)
+	// Retrieve reviews only if 'reviews' query parameter is present
+	if c.Query("reviews") != "" && !exporting(c) {
+		// Retrieve top 10 reviews of every landmark of the page
+		if err := loadLatestReviews(landmarks, 10); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews for landmark"})
+			return
 		}
+	}
 
-		landmarks[i].Photos = nil // Clear Photos field
-		landmarks[i].PhotoLinks = photoLinks
+	respondLandmarks(c, landmarks, next, total)
+}
 
-		// Retrieve reviews only if 'reviews' query parameter is present
-		if includeReviews {
-			// Retrieve top 10 reviews
-			var reviews []models.Review
-			if err := db.DB.Where("landmark_id = ?", landmarks[i].ID).Order("created_at desc").Limit(10).Find(&reviews).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews for landmark"})
-				return
-			}
+// loadLandmarkReviews retrieves the newest reviews of a landmark with their photo links
+func loadLandmarkReviews(landmarkID uint, limit int) ([]models.Review, error) {
+	reviews, _, err := repos.Reviews.ListByLandmark(landmarkID, repository.Page{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
 
-			// For each review, populate PhotoLinks and omit Photos
-			for j := range reviews {
-				var reviewPhotos []models.ReviewPhoto
-				if err := db.DB.Where("review_id = ?", reviews[j].ID).Find(&reviewPhotos).Error; err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
-					return
-				}
-
-				var reviewPhotoLinks []string
-				for _, photo := range reviewPhotos {
-					reviewPhotoLinks = append(reviewPhotoLinks, photo.Path)
-				}
-				reviews[j].Photos = nil // Clear Photos field
-				reviews[j].PhotoLinks = reviewPhotoLinks
-			}
+	// For each review, populate PhotoLinks and omit Photos
+	if err := repos.Photos.LoadReviewPhotoLinks(reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
 
-			// Add reviews to the landmark
-			landmarks[i].Reviews = reviews
-		}
+// loadLatestReviews fills Reviews of every landmark with its newest reviews and their photo links,
+// loading the reviews and photos of all the landmarks at once
+func loadLatestReviews(landmarks []models.Landmark, limit int) error {
+	ids := make([]uint, len(landmarks))
+	for i := range landmarks {
+		ids[i] = landmarks[i].ID
 	}
 
-	c.JSON(http.StatusOK, landmarks)
+	reviews, err := repos.Reviews.ListLatestByLandmarks(ids, limit)
+	if err != nil {
+		return err
+	}
+	if err := repos.Photos.LoadReviewPhotoLinks(reviews); err != nil {
+		return err
+	}
+
+	byLandmark := map[uint][]models.Review{}
+	for _, review := range reviews {
+		byLandmark[review.LandmarkID] = append(byLandmark[review.LandmarkID], review)
+	}
+	for i := range landmarks {
+		landmarks[i].Reviews = byLandmark[landmarks[i].ID]
+	}
+	return nil
 }
 
-func GetLandmarkByID(c *gin.Context) {
-	var landmark models.Landmark
-	id := c.Param("id")
-	log.Printf("Fetching landmark with ID: %s", id)
-	if err := db.DB.First(&landmark, id).Error; err != nil {
-		log.Println("Landmark not found or an error occurred:", err)
+// findLandmark loads the landmark named by the id path parameter, responding with an error if it fails
+func findLandmark(c *gin.Context) (*models.Landmark, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark not found"})
+		return nil, false
+	}
+
+	landmark, err := repos.Landmarks.Get(id)
+	if err != nil {
+		log.Println("Landmark not found or an error occurred:", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Landmark not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark"})
+		return nil, false
+	}
+	return landmark, true
+}
+
+func GetLandmarkByID(c *gin.Context) {
+	log.Printf("Fetching landmark with ID: %s", c.Param("id"))
+	landmark, ok := findLandmark(c)
+	if !ok {
 		return
 	}
 
 	// Retrieve photos for the landmark
-	var photos []models.LandmarkPhoto
-	if err := db.DB.Where("landmark_id = ?", landmark.ID).Find(&photos).Error; err != nil {
+	landmarks := []models.Landmark{*landmark}
+	if err := repos.Photos.LoadLandmarkPhotoLinks(landmarks); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for landmark"})
 		return
 	}
 
-	var photoLinks []string
-	for _, photo := range photos {
-		photoLinks = append(photoLinks, photo.Path)
-	}
-
-	landmark.Photos = nil // Clear Photos field
-	landmark.PhotoLinks = photoLinks
-
 	// Get the limit for reviews from the query parameter, default to 10 if not provided
 	limitQuery := c.DefaultQuery("limit", "10")
 	limit, err := strconv.Atoi(limitQuery)
@@ -135,87 +171,72 @@ func GetLandmarkByID(c *gin.Context) {
 	}
 
 	// Retrieve reviews for the landmark with the specified limit
-	var reviews []models.Review
-	if err := db.DB.Where("landmark_id = ?", landmark.ID).Order("created_at desc").Limit(limit).Find(&reviews).Error; err != nil {
+	reviews, err := loadLandmarkReviews(landmark.ID, limit)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews for landmark"})
 		return
 	}
 
-	// Populate PhotoLinks for each review
-	for i := range reviews {
-		var reviewPhotos []models.ReviewPhoto
-		if err := db.DB.Where("review_id = ?", reviews[i].ID).Find(&reviewPhotos).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
-			return
-		}
-
-		var reviewPhotoLinks []string
-		for _, photo := range reviewPhotos {
-			reviewPhotoLinks = append(reviewPhotoLinks, photo.Path)
-		}
-
-		reviews[i].Photos = nil // Clear Photos field
-		reviews[i].PhotoLinks = reviewPhotoLinks
-	}
-
 	// Add the reviews to the landmark
-	landmark.Reviews = reviews
+	landmarks[0].Reviews = reviews
 
-	c.JSON(http.StatusOK, landmark)
+	if !localize(c, landmarks) {
+		return
+	}
+	c.JSON(http.StatusOK, landmarks[0])
 }
 
 func GetLandmarkDetails(c *gin.Context) {
-	var (
-		landmark      models.Landmark
-		reviews       []models.Review
-		reviewCount   int64
-		averageRating float64
-		userReview    models.Review // User review for the landmark
-	)
-
-	landmarkID := c.Param("id")
+	var userReview models.Review // User review for the landmark
+
 	deviceID := c.Query("device_id") // Get device ID from query parameter
 
-	log.Printf("Fetching details for landmark with ID: %s", landmarkID)
+	log.Printf("Fetching details for landmark with ID: %s", c.Param("id"))
 
 	// Fetch Landmark with Photos
-	if err := db.DB.Preload("Photos").First(&landmark, landmarkID).Error; err != nil {
-		log.Println("Landmark not found or an error occurred:", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark not found"})
+	landmark, ok := findLandmark(c)
+	if !ok {
 		return
 	}
+	photos, err := repos.Photos.ListLandmarkPhotosByLandmark(landmark.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for landmark"})
+		return
+	}
+	landmark.Photos = photos
 
 	// Fetch Reviews
-	db.DB.Where("landmark_id = ?", landmarkID).Find(&reviews)
-
-	// Fetch Review Count
-	db.DB.Model(&models.Review{}).Where("landmark_id = ?", landmarkID).Count(&reviewCount)
+	reviews, _, err := repos.Reviews.ListByLandmark(landmark.ID, repository.Page{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews for landmark"})
+		return
+	}
 
-	// Fetch Average Rating
-	var result struct {
-		AverageRating float64
+	// Fetch Review Count and Average Rating
+	stats, err := repos.Reviews.Stats(landmark.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate review statistics"})
+		return
 	}
-	db.DB.Model(&models.Review{}).
-		Select("AVG(rating) as average_rating").
-		Where("landmark_id = ?", landmarkID).
-		Scan(&result)
-	averageRating = result.AverageRating
 
 	// If device ID is provided, fetch reviews by device ID for the landmark
 	if deviceID != "" {
-		var userReviews []models.Review
-		db.DB.Where("landmark_id = ? AND device_id = ?", landmarkID, deviceID).Find(&userReviews)
-		if len(userReviews) > 0 {
+		userReviews, err := repos.Reviews.ListByLandmarkAndDevice(landmark.ID, deviceID)
+		if err == nil && len(userReviews) > 0 {
 			userReview = userReviews[0] // Assuming there's only one review per user for a landmark
 		}
 	}
 
+	if !localize(c, landmark) {
+		return
+	}
+
 	// Construct JSON response
 	response := gin.H{
 		"landmark":       landmark,
 		"reviews":        reviews,
-		"review_count":   reviewCount,
-		"average_rating": averageRating,
+		"review_count":   stats.ReviewCount,
+		"average_rating": stats.AverageRating,
 	}
 
 	// Include the user's review only if device ID is provided
@@ -229,189 +250,177 @@ func GetLandmarkDetails(c *gin.Context) {
 
 // UpdateLandmark updates a landmark by ID
 func UpdateLandmark(c *gin.Context) {
-	id := c.Param("id")
-	var landmark models.Landmark
-
 	// Check if the landmark exists
-	if err := db.DB.First(&landmark, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark not found"})
+	landmark, ok := findLandmark(c)
+	if !ok {
 		return
 	}
 
 	// Bind updated landmark data from JSON request body
-	if err := c.ShouldBindJSON(&landmark); err != nil {
+	if err := c.ShouldBindJSON(landmark); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON data"})
 		return
 	}
 
-	// Validate city existence
-	var city models.City
-	if err := db.DB.First(&city, landmark.CityID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "City with the specified city_id does not exist"})
+	if !validCoordinates(c, landmark.Latitude, landmark.Longitude) {
+		return
+	}
+	if !validLandmarkType(c, landmark) {
+		return
+	}
+
+	// Resolve or validate the city
+	if !placeLandmark(c, landmark) {
 		return
 	}
 
 	// Save updated landmark data
-	if err := db.DB.Save(&landmark).Error; err != nil {
+	if err := repos.Landmarks.Update(landmark); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update landmark"})
 		return
 	}
+	tiles.Invalidate()
+	textSearch.invalidate()
 
 	c.JSON(http.StatusOK, landmark)
 }
 
 func DeleteLandmark(c *gin.Context) {
-	var landmark models.Landmark
-	id := c.Param("id")
-
-	if err := db.DB.First(&landmark, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "landmark not found"})
+	landmark, ok := findLandmark(c)
+	if !ok {
 		return
 	}
 
-	db.DB.Delete(&landmark)
+	if err := repos.Landmarks.Delete(landmark); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete landmark"})
+		return
+	}
+	tiles.Invalidate()
+	textSearch.invalidate()
 
 	c.Status(http.StatusNoContent)
 }
 
+// SearchLandmarks returns the landmarks whose name or description contains the keyword parameter.
+// When none does, it returns the landmarks matching its words in the search index, which ignores
+// diacritics and typos. The results are narrowed by the filters of FilterLandmarks and come with
+// their facet counts.
 func SearchLandmarks(c *gin.Context) {
-	var landmarks []models.Landmark
-	keyword := c.Query("keyword")
-
-	db.DB.Where("name LIKE ?", "%"+keyword+"%").
-		Or("description LIKE ?", "%"+keyword+"%").
-		Find(&landmarks)
+	p, err := parseSortedPagination(c, repository.LandmarkSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+	filter, err := parseLandmarkFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Keyword = c.Query("keyword")
+
+	landmarks, total, err := repos.Landmarks.Filter(filter, p.query())
+	if err == nil && total == 0 && strings.TrimSpace(filter.Keyword) != "" {
+		var ids []uint
+		if ids, err = matchingIDs(filter.Keyword, search.Landmark); err == nil && len(ids) > 0 {
+			filter.Keyword, filter.IDs = "", ids
+			if len(p.sort) > 0 {
+				landmarks, total, err = repos.Landmarks.Filter(filter, p.query())
+			} else if landmarks, total, err = repos.Landmarks.Filter(filter, repository.Page{}); err == nil {
+				// Without a requested order the landmarks keep their ranking in the index
+				landmarks = rankedPage(p, landmarks, ids, landmarkIDOf)
+			}
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search landmarks"})
+		return
+	}
 
-	c.JSON(http.StatusOK, landmarks)
+	landmarks, next := pageOf(p, landmarks, landmarkIDOf)
+	respondFacetedLandmarks(c, landmarks, filter, next, total)
 }
 
+// FilterLandmarks returns the landmarks matching the filters read by parseLandmarkFilter, along
+// with their facet counts
 func FilterLandmarks(c *gin.Context) {
-	var landmarks []models.Landmark
-
-	// Define the query parameters and their corresponding SQL clauses
-	params := map[string]string{
-		"city_id":       "city_id = ?",
-		"type":          "type = ?",
-		"min_latitude":  "CAST(latitude AS DECIMAL) >= ?",
-		"max_latitude":  "CAST(latitude AS DECIMAL) <= ?",
-		"min_longitude": "CAST(longitude AS DECIMAL) >= ?",
-		"max_longitude": "CAST(longitude AS DECIMAL) <= ?",
-	}
-
-	query := db.DB
-	for param, clause := range params {
-		if value := c.Query(param); value != "" {
-			query = query.Where(clause, value)
-		}
+	p, err := parseSortedPagination(c, repository.LandmarkSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+	filter, err := parseLandmarkFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := query.Find(&landmarks).Error; err != nil {
+	landmarks, total, err := repos.Landmarks.Filter(filter, p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmarks"})
 		return
 	}
 
-	if len(landmarks) == 0 {
+	if total == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No landmarks found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, landmarks)
+	landmarks, next := pageOf(p, landmarks, landmarkIDOf)
+	respondFacetedLandmarks(c, landmarks, filter, next, total)
 }
 
 func GetAllLandmarksOfCity(c *gin.Context) {
-	var landmarks []models.Landmark
-	cityID := c.Param("city_id")
+	p, err := parseSortedPagination(c, repository.LandmarkSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
 
-	var city models.City
-	if err := db.DB.First(&city, cityID).Error; err != nil {
+	cityID, err := parseID(exportParam(c, "city_id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "City with the specified city_id does not exist"})
 		return
 	}
 
-	db.DB.Where("city_id = ?", cityID).Find(&landmarks)
-
-	c.JSON(http.StatusOK, landmarks)
-}
-
-func GetAllLandmarksOfRegion(c *gin.Context) {
-	var landmarks []models.Landmark
-	regionID := c.Param("region_id")
-
-	var region models.Region
-	if err := db.DB.First(&region, regionID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Region with the specified region_id does not exist"})
+	if _, err := repos.Cities.Get(cityID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "City with the specified city_id does not exist"})
 		return
 	}
 
-	db.DB.Joins("JOIN cities ON landmarks.city_id = cities.id").
-		Where("cities.region_id = ?", regionID).
-		Find(&landmarks)
-
-	c.JSON(http.StatusOK, landmarks)
-}
-func GetSuggestedLandmarks(c *gin.Context) {
-	// Parse user location from query parameters
-	latitudeStr := c.Query("latitude")
-	longitudeStr := c.Query("longitude")
-	if latitudeStr == "" || longitudeStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User location (latitude and longitude) must be provided"})
+	landmarks, total, err := repos.Landmarks.ListByCity(cityID, p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmarks"})
 		return
 	}
 
-	latitude, err := strconv.ParseFloat(latitudeStr, 64)
+	landmarks, next := pageOf(p, landmarks, landmarkIDOf)
+	respondLandmarks(c, landmarks, next, total)
+}
+
+func GetAllLandmarksOfRegion(c *gin.Context) {
+	p, err := parseSortedPagination(c, repository.LandmarkSortFields)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid latitude value"})
+		respondInvalidListing(c, err)
 		return
 	}
 
-	longitude, err := strconv.ParseFloat(longitudeStr, 64)
+	regionID, err := parseID(exportParam(c, "region_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid longitude value"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Region with the specified region_id does not exist"})
 		return
 	}
 
-	maxDistance := 20.0 // distance in km
-
-	// Retrieve landmarks that are within the maxDistance, ordered by proximity, rating, and review count
-	var landmarks []models.Landmark
-	if err := db.DB.Raw(`
-		SELECT 
-			l.*, 
-			AVG(r.rating) as average_rating, 
-			COUNT(r.id) as review_count,
-			(6371 * acos(cos(radians(?)) * cos(radians(l.latitude)) * cos(radians(l.longitude) - radians(?)) + sin(radians(?)) * sin(radians(l.latitude)))) as distance
-		FROM 
-			landmarks l
-		LEFT JOIN 
-			reviews r ON l.id = r.landmark_id
-		GROUP BY 
-			l.id
-		HAVING 
-			distance <= ?
-		ORDER BY 
-			distance ASC, average_rating DESC, review_count DESC
-	`, latitude, longitude, latitude, maxDistance).Scan(&landmarks).Error; err != nil {
-		log.Println("Failed to retrieve suggested landmarks:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve suggested landmarks"})
+	if _, err := repos.Regions.Get(regionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Region with the specified region_id does not exist"})
 		return
 	}
 
-	// Populate PhotoLinks for each landmark
-	for i := range landmarks {
-		var photos []models.LandmarkPhoto
-		if err := db.DB.Where("landmark_id = ?", landmarks[i].ID).Find(&photos).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for landmark"})
-			return
-		}
-
-		var photoLinks []string
-		for _, photo := range photos {
-			photoLinks = append(photoLinks, photo.Path)
-		}
-
-		landmarks[i].Photos = nil // Clear Photos field
-		landmarks[i].PhotoLinks = photoLinks
+	landmarks, total, err := repos.Landmarks.ListByRegion(regionID, p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmarks"})
+		return
 	}
 
-	c.JSON(http.StatusOK, landmarks)
+	landmarks, next := pageOf(p, landmarks, landmarkIDOf)
+	respondLandmarks(c, landmarks, next, total)
 }
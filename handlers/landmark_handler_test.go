@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"landmarksmodule/models"
+)
+
+func TestLandmarkCRUD(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+	s.landmarkType("museum")
+
+	var created models.Landmark
+	s.expect(http.StatusCreated, http.MethodPost, "/landmarks", map[string]interface{}{
+		"name": "Archaeological Museum", "type": "Museum", "city_id": city.ID,
+	}, &created)
+	if created.ID == 0 || created.Type != "museum" {
+		t.Fatalf("created %+v, want an id and the museum slug", created)
+	}
+
+	path := fmt.Sprintf("/landmarks/%d", created.ID)
+	var fetched models.Landmark
+	s.expect(http.StatusOK, http.MethodGet, path, nil, &fetched)
+	if fetched.Name != "Archaeological Museum" || fetched.CityID != city.ID {
+		t.Errorf("fetched %+v", fetched)
+	}
+
+	var updated models.Landmark
+	s.expect(http.StatusOK, http.MethodPut, path, map[string]interface{}{"name": "Museum of Prizren"}, &updated)
+	if updated.Name != "Museum of Prizren" || updated.Type != "museum" {
+		t.Errorf("updated %+v, want the new name and the same type", updated)
+	}
+	s.expect(http.StatusOK, http.MethodGet, path, nil, &fetched)
+	if fetched.Name != "Museum of Prizren" {
+		t.Errorf("fetched %q after the update", fetched.Name)
+	}
+
+	s.expect(http.StatusNoContent, http.MethodDelete, path, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodGet, path, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodDelete, path, nil, nil)
+}
+
+func TestLandmarkErrors(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"unknown id", http.MethodGet, "/landmarks/999", nil, http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/landmarks/abc", nil, http.StatusNotFound},
+		{"update unknown id", http.MethodPut, "/landmarks/999", map[string]interface{}{"name": "x"}, http.StatusNotFound},
+		{"no content type", http.MethodPost, "/landmarks", nil, http.StatusBadRequest},
+		{"invalid json", http.MethodPost, "/landmarks", "{", http.StatusBadRequest},
+		{"no city nor coordinates", http.MethodPost, "/landmarks", map[string]interface{}{"name": "x"}, http.StatusBadRequest},
+		{"unknown city", http.MethodPost, "/landmarks", map[string]interface{}{"name": "x", "city_id": 999}, http.StatusBadRequest},
+		{"invalid coordinates", http.MethodPost, "/landmarks", map[string]interface{}{"name": "x", "city_id": city.ID, "latitude": 100, "longitude": 20}, http.StatusBadRequest},
+		{"unknown type", http.MethodPost, "/landmarks", map[string]interface{}{"name": "x", "city_id": city.ID, "type": "castle"}, http.StatusUnprocessableEntity},
+		{"no city at coordinates", http.MethodPost, "/landmarks", map[string]interface{}{"name": "x", "latitude": 42.2, "longitude": 20.7}, http.StatusUnprocessableEntity},
+		{"invalid limit", http.MethodGet, "/landmarks?limit=0", nil, http.StatusBadRequest},
+		{"invalid cursor", http.MethodGet, "/landmarks?cursor=garbage", nil, http.StatusBadRequest},
+		{"cursor and page", http.MethodGet, "/landmarks?cursor=abc&page=2", nil, http.StatusBadRequest},
+		{"invalid sort", http.MethodGet, "/landmarks?sort=secret", nil, http.StatusBadRequest},
+		{"suggestions without location", http.MethodGet, "/landmarks/suggested?latitude=42.2", nil, http.StatusBadRequest},
+		{"suggestions latitude out of range", http.MethodGet, "/landmarks/suggested?latitude=500&longitude=20.7", nil, http.StatusBadRequest},
+		{"suggestions longitude out of range", http.MethodGet, "/landmarks/suggested?latitude=42.2&longitude=200", nil, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := s.do(test.method, test.path, test.body)
+			if response.Code != test.status {
+				t.Errorf("status %d, want %d: %s", response.Code, test.status, response.Body)
+			}
+		})
+	}
+}
+
+func TestLandmarkPagination(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+	for i := 0; i < 5; i++ {
+		s.landmark(fmt.Sprintf("Landmark %d", i), city.ID)
+	}
+
+	var first page[models.Landmark]
+	s.expect(http.StatusOK, http.MethodGet, "/landmarks?limit=2", nil, &first)
+	if len(first.Data) != 2 || first.Total != 5 || first.NextCursor == nil {
+		t.Fatalf("first page has %d landmarks of %d, cursor %v", len(first.Data), first.Total, first.NextCursor)
+	}
+
+	ids, pages := collect(s, "/landmarks?limit=2", func(landmark models.Landmark) uint { return landmark.ID })
+	if len(ids) != 5 || pages != 3 || !distinct(ids) {
+		t.Errorf("cursors gave ids %v in %d pages, want the 5 landmarks in 3 pages", ids, pages)
+	}
+
+	var third page[models.Landmark]
+	s.expect(http.StatusOK, http.MethodGet, "/landmarks?limit=2&page=3", nil, &third)
+	if len(third.Data) != 1 || third.NextCursor != nil {
+		t.Errorf("page 3 has %d landmarks and cursor %v, want the last one", len(third.Data), third.NextCursor)
+	}
+}
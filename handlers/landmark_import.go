@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/tiles"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportRows bounds the number of landmarks of a single import
+const maxImportRows = 10000
+
+// maxExternalRefLength is the size of the external_ref column of landmarks
+const maxExternalRefLength = 64
+
+// importColumns maps the accepted CSV headers, compared ignoring case, to the fields they set
+var importColumns = map[string]string{
+	"name":         "name",
+	"type":         "type",
+	"information":  "information",
+	"description":  "description",
+	"latitude":     "latitude",
+	"lat":          "latitude",
+	"longitude":    "longitude",
+	"lng":          "longitude",
+	"lon":          "longitude",
+	"city_id":      "city_id",
+	"city":         "city",
+	"external_ref": "external_ref",
+}
+
+// ImportProblem is an error or a warning about a line of an imported file, line 1 being the header
+type ImportProblem struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport tells the outcome of a landmark import. Landmarks are only stored when no row has
+// an error, all of them at once.
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// Rows is the number of data rows read, Valid the number of them without errors
+	Rows  int `json:"rows"`
+	Valid int `json:"valid"`
+	// Imported counts the landmarks created or updated
+	Imported int             `json:"imported"`
+	Errors   []ImportProblem `json:"errors"`
+	Warnings []ImportProblem `json:"warnings"`
+	repository.UpsertCounts
+}
+
+func (r *ImportReport) fail(row int, column, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, ImportProblem{Row: row, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ImportReport) warn(row int, column, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, ImportProblem{Row: row, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// ImportLandmarks handles the upload of a CSV file of landmarks in the file form field.
+// The dry_run parameter checks the file without creating anything.
+func ImportLandmarks(c *gin.Context) {
+	dryRunParam := c.Query("dry_run")
+	if dryRunParam == "" {
+		dryRunParam = c.PostForm("dry_run")
+	}
+	dryRun := false
+	if dryRunParam != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(dryRunParam); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file is required in the file form field"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read CSV file"})
+		return
+	}
+	defer file.Close()
+
+	report, err := ImportLandmarksCSV(file, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import landmarks", "details": err.Error()})
+		return
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	case dryRun:
+		c.JSON(http.StatusOK, report)
+	default:
+		c.JSON(http.StatusCreated, report)
+	}
+}
+
+// ImportLandmarksCSV reads landmarks from a CSV file whose header names the columns, see importColumns.
+// The city of a row is given by city_id or by the city name, or resolved from the coordinates like
+// CreateLandmark does. A row whose external_ref is already stored updates that landmark instead of
+// creating one, see repository.ApplyImport for the fields it sets, so that a file can be imported
+// again. Every row is checked and its problems reported, then the landmarks are stored in a single
+// transaction unless a row has an error or dryRun is set. The error is only returned when the stored
+// data cannot be read or written.
+func ImportLandmarksCSV(r io.Reader, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, Errors: []ImportProblem{}, Warnings: []ImportProblem{}}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		report.fail(1, "", "cannot read the header: %v", err)
+		return report, nil
+	}
+	columns := importHeader(header, report)
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	importer := landmarkImporter{
+		report:       report,
+		citiesByID:   map[uint]*models.City{},
+		citiesByName: map[string][]models.City{},
+		types:        map[string]string{},
+		refs:         map[string]int{},
+	}
+	var landmarks []models.Landmark
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Rows++
+			report.fail(parseErr.StartLine, "", "%v", parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		row, _ := reader.FieldPos(0)
+
+		report.Rows++
+		if report.Rows > maxImportRows {
+			report.fail(row, "", "a file may hold at most %d landmarks", maxImportRows)
+			break
+		}
+		fields := map[string]string{}
+		for i, value := range record {
+			if i < len(columns) && columns[i] != "" {
+				fields[columns[i]] = strings.TrimSpace(value)
+			}
+		}
+		if len(record) > len(header) {
+			report.warn(row, "", "%d values beyond the last column are ignored", len(record)-len(header))
+		}
+
+		landmark, ok, err := importer.landmark(row, fields)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			report.Valid++
+			landmarks = append(landmarks, landmark)
+		}
+	}
+
+	if len(report.Errors) > 0 || dryRun || len(landmarks) == 0 {
+		return report, nil
+	}
+	if report.UpsertCounts, err = repos.Landmarks.UpsertByExternalRef(landmarks); err != nil {
+		return nil, err
+	}
+	tiles.Invalidate()
+	textSearch.invalidate()
+	report.Imported = report.Created + report.Updated
+	return report, nil
+}
+
+// importHeader maps every column of the header to the field it sets, or to an empty string when it is ignored
+func importHeader(header []string, report *ImportReport) []string {
+	columns := make([]string, len(header))
+	seen := map[string]string{}
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets often start UTF-8 files with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.TrimSpace(name)
+		field, ok := importColumns[strings.ToLower(name)]
+		if !ok {
+			report.warn(1, name, "unknown column is ignored")
+			continue
+		}
+		if previous, ok := seen[field]; ok {
+			report.fail(1, name, "column sets the same field as column %s", previous)
+			continue
+		}
+		seen[field] = name
+		columns[i] = field
+	}
+	if _, ok := seen["name"]; !ok {
+		report.fail(1, "", "a name column is required")
+	}
+	return columns
+}
+
+// landmarkImporter checks the rows of an import, caching the cities and the types they refer to
+type landmarkImporter struct {
+	report       *ImportReport
+	citiesByID   map[uint]*models.City
+	citiesByName map[string][]models.City
+	// types maps the types of the rows to their slugs, or to an empty string when they are unknown
+	types map[string]string
+	// refs maps the external references of the rows to the first row giving them
+	refs map[string]int
+}
+
+// landmark reads the landmark of a row, reporting its problems. ok is false when the row has an error.
+func (im *landmarkImporter) landmark(row int, fields map[string]string) (models.Landmark, bool, error) {
+	errorCount := len(im.report.Errors)
+	landmark := models.Landmark{
+		Name:        fields["name"],
+		Type:        fields["type"],
+		Information: fields["information"],
+		Description: fields["description"],
+	}
+	if landmark.Name == "" {
+		im.report.fail(row, "name", "name is required")
+	}
+	if ref := fields["external_ref"]; ref != "" {
+		if first, ok := im.refs[ref]; ok {
+			im.report.fail(row, "external_ref", "external_ref %q is already given on row %d", ref, first)
+		} else if len(ref) > maxExternalRefLength {
+			im.report.fail(row, "external_ref", "external_ref may have at most %d bytes", maxExternalRefLength)
+		} else {
+			im.refs[ref] = row
+		}
+		landmark.ExternalRef = &ref
+	}
+	if landmark.Type != "" {
+		slug, ok := im.types[landmark.Type]
+		if !ok {
+			var err error
+			if slug, err = knownLandmarkType(landmark.Type); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return landmark, false, err
+			}
+			im.types[landmark.Type] = slug
+		}
+		if slug == "" {
+			im.report.fail(row, "type", "%q is not a landmark type", landmark.Type)
+		}
+		landmark.Type = slug
+	}
+
+	latitude, longitude := fields["latitude"], fields["longitude"]
+	located := latitude != "" && longitude != ""
+	if (latitude != "") != (longitude != "") {
+		im.report.fail(row, "", "latitude and longitude must be given together")
+	}
+	if located {
+		var err error
+		if landmark.Latitude, err = strconv.ParseFloat(latitude, 64); err != nil {
+			im.report.fail(row, "latitude", "latitude %q is not a number", latitude)
+			located = false
+		}
+		if landmark.Longitude, err = strconv.ParseFloat(longitude, 64); err != nil {
+			im.report.fail(row, "longitude", "longitude %q is not a number", longitude)
+			located = false
+		}
+	}
+	if located {
+		if err := geo.ValidateCoordinates(landmark.Latitude, landmark.Longitude); err != nil {
+			im.report.fail(row, "", "%v", err)
+			located = false
+		}
+	}
+
+	city, err := im.city(row, fields)
+	if err != nil {
+		return landmark, false, err
+	}
+	named := city != nil
+	if city == nil && located && len(im.report.Errors) == errorCount {
+		if city, err = resolveCity(landmark.Latitude, landmark.Longitude); errors.Is(err, errNoCityAtCoordinates) {
+			im.report.fail(row, "", "could not resolve the city from the coordinates: %v", err)
+		} else if err != nil {
+			return landmark, false, err
+		}
+	}
+	if city == nil && len(im.report.Errors) == errorCount {
+		im.report.fail(row, "", "city_id or city is required unless latitude and longitude are given")
+	}
+	if len(im.report.Errors) > errorCount {
+		return landmark, false, nil
+	}
+	landmark.CityID = city.ID
+
+	if located && named && landmarkSettings.CityValidation != cityValidationOff {
+		problem, err := cityMismatch(city, landmark.Latitude, landmark.Longitude)
+		if err != nil {
+			return landmark, false, err
+		}
+		if problem != "" && landmarkSettings.CityValidation == cityValidationReject {
+			im.report.fail(row, "", "the city contradicts the coordinates: %s", problem)
+			return landmark, false, nil
+		}
+		if problem != "" {
+			im.report.warn(row, "", "the city contradicts the coordinates: %s", problem)
+		}
+	}
+	return landmark, true, nil
+}
+
+// city returns the city named by the city_id or city column of a row, or nil when both are empty
+func (im *landmarkImporter) city(row int, fields map[string]string) (*models.City, error) {
+	var byID *models.City
+	if value := fields["city_id"]; value != "" {
+		id, err := parseID(value)
+		if err != nil {
+			im.report.fail(row, "city_id", "city_id %q is not a valid id", value)
+			return nil, nil
+		}
+		city, ok := im.citiesByID[id]
+		if !ok {
+			city, err = repos.Cities.Get(id)
+			if errors.Is(err, repository.ErrNotFound) {
+				city = nil
+			} else if err != nil {
+				return nil, err
+			}
+			im.citiesByID[id] = city
+		}
+		if city == nil {
+			im.report.fail(row, "city_id", "city %d does not exist", id)
+			return nil, nil
+		}
+		byID = city
+	}
+
+	name := fields["city"]
+	if name == "" {
+		return byID, nil
+	}
+	key := strings.ToLower(name)
+	cities, ok := im.citiesByName[key]
+	if !ok {
+		var err error
+		if cities, err = repos.Cities.ListByName(name); err != nil {
+			return nil, err
+		}
+		im.citiesByName[key] = cities
+	}
+
+	switch {
+	case byID != nil:
+		if !strings.EqualFold(byID.Name, name) {
+			im.report.fail(row, "city", "city %d is named %q, not %q", byID.ID, byID.Name, name)
+			return nil, nil
+		}
+		return byID, nil
+	case len(cities) == 0:
+		im.report.fail(row, "city", "no city is named %q", name)
+		return nil, nil
+	case len(cities) > 1:
+		im.report.fail(row, "city", "%d cities are named %q, give city_id instead", len(cities), name)
+		return nil, nil
+	}
+	return &cities[0], nil
+}
@@ -0,0 +1,182 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"landmarksmodule/handlers"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+// importCSV uploads the lines as the CSV file of a landmark import and returns the status and the report
+func (s *server) importCSV(query string, lines ...string) (int, handlers.ImportReport) {
+	s.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "landmarks.csv")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	file.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	form.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/landmarks/import"+query, &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	response := httptest.NewRecorder()
+	s.router.ServeHTTP(response, request)
+
+	var report handlers.ImportReport
+	if err := json.Unmarshal(response.Body.Bytes(), &report); err != nil {
+		s.t.Fatalf("%v: %s", err, response.Body)
+	}
+	return response.Code, report
+}
+
+// storedLandmarks returns the landmarks keyed by name
+func (s *server) storedLandmarks() map[string]models.Landmark {
+	s.t.Helper()
+	landmarks, _, err := s.repos.Landmarks.List(repository.Page{})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	byName := map[string]models.Landmark{}
+	for _, landmark := range landmarks {
+		byName[landmark.Name] = landmark
+	}
+	return byName
+}
+
+func TestImportLandmarksReportsEveryBadRow(t *testing.T) {
+	s := newServer(t)
+	s.city()
+	s.landmarkType("museum")
+
+	status, report := s.importCSV("",
+		"name,type,city,city_id,latitude,longitude,external_ref",
+		"Archaeological Museum,museum,Prizren,,42.2139,20.7397,csv:1",
+		",museum,Prizren,,,,",
+		"Fortress,castle,Prizren,,,,",
+		"Hamam,museum,Gjakova,,,,",
+		"Shadervan,museum,,999,,,",
+		"Stone Bridge,museum,Prizren,,north,20.7,",
+		"Mosque,museum,Prizren,,42.2,,",
+		"Museum Annex,museum,Prizren,,,,csv:1",
+		"Clock Tower,museum,Prizren,,500,20.7,",
+		`Old "Bridge",museum,Prizren,,,,`,
+	)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("status %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+	if report.Rows != 10 || report.Valid != 1 || report.Imported != 0 {
+		t.Errorf("report of %d rows, %d valid and %d imported, want 10, 1 and 0", report.Rows, report.Valid, report.Imported)
+	}
+
+	type located struct {
+		row    int
+		column string
+	}
+	var errors []located
+	for _, problem := range report.Errors {
+		errors = append(errors, located{problem.Row, problem.Column})
+	}
+	want := []located{
+		{3, "name"},
+		{4, "type"},
+		{5, "city"},
+		{6, "city_id"},
+		{7, "latitude"},
+		{8, ""},
+		{9, "external_ref"},
+		{10, ""},
+		{11, ""},
+	}
+	if !reflect.DeepEqual(errors, want) {
+		t.Errorf("errors %+v, want errors at %+v", report.Errors, want)
+	}
+	if len(s.storedLandmarks()) != 0 {
+		t.Error("landmarks were created despite the errors")
+	}
+}
+
+func TestImportLandmarksUpsertsByExternalRef(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+	s.landmarkType("museum")
+	s.landmarkType("bridge")
+
+	status, report := s.importCSV("",
+		"name,type,city,external_ref",
+		"Archaeological Museum,museum,Prizren,csv:1",
+		"Stone Bridge,bridge,Prizren,csv:2",
+		"Hamam,museum,Prizren,",
+	)
+	if status != http.StatusCreated || report.Created != 3 || report.Imported != 3 {
+		t.Fatalf("first import: status %d and report %+v, want 3 landmarks created", status, report)
+	}
+	first := s.storedLandmarks()
+
+	// The museum is renamed, the bridge is the same and a row without reference is added again
+	status, report = s.importCSV("",
+		"name,type,city,external_ref",
+		"Museum of Prizren,museum,Prizren,csv:1",
+		"Stone Bridge,bridge,Prizren,csv:2",
+		"Hamam,museum,Prizren,",
+	)
+	want := repository.UpsertCounts{Created: 1, Updated: 1, Unchanged: 1}
+	if status != http.StatusCreated || report.UpsertCounts != want || report.Imported != 2 {
+		t.Fatalf("second import: status %d and report %+v, want counts %+v", status, report, want)
+	}
+
+	if _, total, err := s.repos.Landmarks.List(repository.Page{}); err != nil || total != 4 {
+		t.Errorf("%d landmarks stored, want the 3 first ones and the second Hamam: %v", total, err)
+	}
+	second := s.storedLandmarks()
+	museum, ok := second["Museum of Prizren"]
+	if !ok || museum.ID != first["Archaeological Museum"].ID || *museum.ExternalRef != "csv:1" || museum.CityID != city.ID {
+		t.Errorf("museum stored as %+v, want the first one renamed", museum)
+	}
+	if _, ok := second["Archaeological Museum"]; ok {
+		t.Error("the museum was duplicated under its former name")
+	}
+	if bridge := second["Stone Bridge"]; bridge.ID != first["Stone Bridge"].ID {
+		t.Errorf("bridge stored as %+v, want the first one unchanged", bridge)
+	}
+}
+
+func TestImportLandmarksDryRunAndWarnings(t *testing.T) {
+	s := newServer(t)
+	s.city()
+
+	status, report := s.importCSV("?dry_run=true",
+		"\ufeffName,City,Notes",
+		"Stone Bridge,Prizren,Ottoman",
+		"Hamam,Prizren,Ottoman,extra",
+	)
+	if status != http.StatusOK || !report.DryRun || report.Valid != 2 || report.Imported != 0 {
+		t.Errorf("dry run: status %d and report %+v, want 2 valid rows and nothing imported", status, report)
+	}
+	wantWarnings := []handlers.ImportProblem{
+		{Row: 1, Column: "Notes", Message: "unknown column is ignored"},
+		{Row: 3, Message: "1 values beyond the last column are ignored"},
+	}
+	if !reflect.DeepEqual(report.Warnings, wantWarnings) {
+		t.Errorf("warnings %+v, want %+v", report.Warnings, wantWarnings)
+	}
+	if len(s.storedLandmarks()) != 0 {
+		t.Error("landmarks were created by a dry run")
+	}
+
+	status, report = s.importCSV("", "city,latitude,lat", "Prizren,42.2,42.2")
+	if status != http.StatusUnprocessableEntity || len(report.Errors) != 2 || report.Errors[0].Row != 1 || report.Errors[1].Row != 1 || report.Rows != 0 {
+		t.Errorf("header without name and with two latitudes: status %d and report %+v, want two errors on row 1", status, report)
+	}
+
+	s.expect(http.StatusBadRequest, http.MethodPost, "/landmarks/import", nil, nil)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxMapPoints is the number of landmarks of a viewport above which they are clustered
+	maxMapPoints = 500
+	// maxMapZoom is the deepest zoom level of the map client
+	maxMapZoom = 22
+	// clusterCellsPerTile is the number of grid cells along each side of a 256 pixel map tile,
+	// so that clusters are about 64 pixels apart
+	clusterCellsPerTile = 4
+)
+
+// mapLandmark is the part of a landmark a map client needs to draw its marker
+type mapLandmark struct {
+	ID        uint    `json:"id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// parseBBox parses a bbox query parameter of the form west,south,east,north.
+// A west edge greater than the east edge crosses the antimeridian.
+func parseBBox(value string) (geo.BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return geo.BBox{}, errors.New("bbox must have the form west,south,east,north")
+	}
+
+	var edges [4]float64
+	for i, part := range parts {
+		edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return geo.BBox{}, fmt.Errorf("invalid bbox edge %q", part)
+		}
+		edges[i] = edge
+	}
+
+	box := geo.BBox{MinLng: edges[0], MinLat: edges[1], MaxLng: edges[2], MaxLat: edges[3]}
+	if err := geo.ValidateCoordinates(box.MinLat, box.MinLng); err != nil {
+		return geo.BBox{}, err
+	}
+	if err := geo.ValidateCoordinates(box.MaxLat, box.MaxLng); err != nil {
+		return geo.BBox{}, err
+	}
+	if box.MinLat > box.MaxLat {
+		return geo.BBox{}, errors.New("the south edge of bbox must not be above its north edge")
+	}
+	return box, nil
+}
+
+// clusterCellSize returns the size in degrees of the clustering grid cells at a zoom level
+func clusterCellSize(zoom int) float64 {
+	return 360 / math.Exp2(float64(zoom)) / clusterCellsPerTile
+}
+
+// GetLandmarkMap returns the landmarks of a map viewport given by the bbox and zoom query parameters.
+// Crowded viewports get grid clusters with counts and centroids instead of individual landmarks.
+func GetLandmarkMap(c *gin.Context) {
+	box, err := parseBBox(c.Query("bbox"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bbox query parameter", "details": err.Error()})
+		return
+	}
+
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil || zoom < 0 || zoom > maxMapZoom {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("zoom must be an integer between 0 and %d", maxMapZoom)})
+		return
+	}
+
+	query := repository.MapQuery{Box: box, Type: c.Query("type")}
+	landmarks, total, err := repos.Landmarks.InBox(query, maxMapPoints)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmarks"})
+		return
+	}
+
+	if total <= maxMapPoints {
+		if !localize(c, landmarks) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"zoom":      zoom,
+			"total":     total,
+			"clustered": false,
+			"landmarks": mapLandmarks(landmarks),
+		})
+		return
+	}
+
+	clusters, err := repos.Landmarks.Clusters(query, clusterCellSize(zoom))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cluster landmarks"})
+		return
+	}
+	if clusters == nil {
+		clusters = []repository.Cluster{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"zoom":      zoom,
+		"total":     total,
+		"clustered": true,
+		"clusters":  clusters,
+	})
+}
+
+func mapLandmarks(landmarks []models.Landmark) []mapLandmark {
+	markers := make([]mapLandmark, len(landmarks))
+	for i, landmark := range landmarks {
+		markers[i] = mapLandmark{
+			ID:        landmark.ID,
+			Name:      landmark.Name,
+			Type:      landmark.Type,
+			Latitude:  landmark.Latitude,
+			Longitude: landmark.Longitude,
+		}
+	}
+	return markers
+}
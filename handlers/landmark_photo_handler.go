@@ -1,37 +1,28 @@
 package handlers
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"landmarksmodule/db"
 	"landmarksmodule/models"
+	"landmarksmodule/storage"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 )
 
-var s3Client *s3.Client
-
-func init() {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+// CreateLandmarkPhoto handles the upload of landmark photos to the configured storage
+func CreateLandmarkPhoto(c *gin.Context) {
+	landmarkID, err := parseID(c.PostForm("landmark_id"))
 	if err != nil {
-		panic(fmt.Sprintf("unable to load SDK config, %v", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark with the specified landmark_id does not exist"})
+		return
 	}
-	s3Client = s3.NewFromConfig(cfg)
-}
-
-// CreateLandmarkPhoto handles the upload and storage of landmark photos to S3
-func CreateLandmarkPhoto(c *gin.Context) {
-	landmarkID := c.PostForm("landmark_id")
-	var landmark models.Landmark
-	if err := db.DB.First(&landmark, landmarkID).Error; err != nil {
+	landmark, err := repos.Landmarks.Get(landmarkID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark with the specified landmark_id does not exist"})
 		return
 	}
@@ -58,26 +49,23 @@ func CreateLandmarkPhoto(c *gin.Context) {
 	landmarkName := strings.ReplaceAll(landmark.Name, " ", "_")
 	fileName := fmt.Sprintf("%s_%d%s", landmarkName, time.Now().UnixNano(), filepath.Ext(file.Filename))
 
-	// Upload file to S3 with directory structure
+	// Upload file to the storage with directory structure
 	uploadPath := fmt.Sprintf("%s/%s", landmarkName, fileName)
-	uploadErr := uploadFileToS3(fileBytes, uploadPath)
+	photoURL, uploadErr := uploadPhoto(c.Request.Context(), fileBytes, uploadPath)
 	if uploadErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo to S3"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
 		return
 	}
 
-	// Construct the S3 URL for the uploaded file
-	s3URL := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", "golang-backend-photos", uploadPath)
-
 	// Create a new LandmarkPhoto record
 	photo := models.LandmarkPhoto{
 		LandmarkID: landmark.ID,
 		Name:       file.Filename,
-		Path:       s3URL,
+		Path:       photoURL,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
-	if err := db.DB.Create(&photo).Error; err != nil {
+	if err := repos.Photos.CreateLandmarkPhoto(&photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create landmark photo"})
 		return
 	}
@@ -86,20 +74,41 @@ func CreateLandmarkPhoto(c *gin.Context) {
 }
 
 func GetAllLandmarkPhotos(c *gin.Context) {
-	var photos []models.LandmarkPhoto
-	if err := db.DB.Find(&photos).Error; err != nil {
+	p, err := parsePagination(c, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	photos, total, err := repos.Photos.ListLandmarkPhotos(p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark photos"})
 		return
 	}
 
-	c.JSON(http.StatusOK, photos)
+	photos, next := pageOf(p, photos, landmarkPhotoIDOf)
+	respondPage(c, photos, next, total)
 }
 
-func GetLandmarkPhotoByID(c *gin.Context) {
-	id := c.Param("id")
-	var photo models.LandmarkPhoto
-	if err := db.DB.First(&photo, id).Error; err != nil {
+// findLandmarkPhoto loads the photo named by the id path parameter, responding with an error if it fails
+func findLandmarkPhoto(c *gin.Context) (*models.LandmarkPhoto, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark photo not found"})
+		return nil, false
+	}
+
+	photo, err := repos.Photos.GetLandmarkPhoto(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark photo not found"})
+		return nil, false
+	}
+	return photo, true
+}
+
+func GetLandmarkPhotoByID(c *gin.Context) {
+	photo, ok := findLandmarkPhoto(c)
+	if !ok {
 		return
 	}
 
@@ -107,26 +116,61 @@ func GetLandmarkPhotoByID(c *gin.Context) {
 }
 
 func UpdateLandmarkPhoto(c *gin.Context) {
-	id := c.Param("id")
-	var photo models.LandmarkPhoto
-
-	if err := db.DB.First(&photo, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark photo not found"})
+	photo, ok := findLandmarkPhoto(c)
+	if !ok {
 		return
 	}
 
-	var input models.LandmarkPhoto
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo data"})
-		return
-	}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		// Replace the stored file, keeping the same path
+		file, err := c.FormFile("image")
+		if err != nil && !errors.Is(err, http.ErrMissingFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo data"})
+			return
+		}
+
+		if file != nil {
+			fileBytes, err := file.Open()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read photo file"})
+				return
+			}
+			defer fileBytes.Close()
+
+			landmark, err := repos.Landmarks.Get(photo.LandmarkID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark associated with the photo not found"})
+				return
+			}
+
+			uploadPath, ok := storage.KeyFromURL(storage.Store, photo.Path)
+			if !ok {
+				landmarkName := strings.ReplaceAll(landmark.Name, " ", "_")
+				uploadPath = fmt.Sprintf("%s/%s_%d%s", landmarkName, landmarkName, time.Now().UnixNano(), filepath.Ext(file.Filename))
+			}
+			photoURL, uploadErr := uploadPhoto(c.Request.Context(), fileBytes, uploadPath)
+			if uploadErr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
+				return
+			}
+
+			photo.Name = file.Filename
+			photo.Path = photoURL
+		}
+	} else {
+		var input models.LandmarkPhoto
+		if err := c.BindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo data"})
+			return
+		}
 
-	// Update fields
-	photo.Name = input.Name
-	photo.Path = input.Path
+		// Update fields
+		photo.Name = input.Name
+		photo.Path = input.Path
+	}
 	photo.UpdatedAt = time.Now()
 
-	if err := db.DB.Save(&photo).Error; err != nil {
+	if err := repos.Photos.UpdateLandmarkPhoto(photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update landmark photo"})
 		return
 	}
@@ -135,21 +179,18 @@ func UpdateLandmarkPhoto(c *gin.Context) {
 }
 
 func DeleteLandmarkPhoto(c *gin.Context) {
-	id := c.Param("id")
-	var photo models.LandmarkPhoto
-
-	if err := db.DB.First(&photo, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark photo not found"})
+	photo, ok := findLandmarkPhoto(c)
+	if !ok {
 		return
 	}
 
-	if err := db.DB.Delete(&photo).Error; err != nil {
+	if err := repos.Photos.DeleteLandmarkPhoto(photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete landmark photo"})
 		return
 	}
 
-	// Optionally, delete the file from the disk
-	if err := os.Remove(photo.Path); err != nil {
+	// Delete the file from the storage
+	if err := deletePhoto(c.Request.Context(), photo.Path); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete photo file"})
 		return
 	}
@@ -157,10 +198,14 @@ func DeleteLandmarkPhoto(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 func GetLandmarkPhotosByLandmarkID(c *gin.Context) {
-	landmarkID := c.Param("id")
+	landmarkID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No photos found for the specified landmark"})
+		return
+	}
 
-	var photos []models.LandmarkPhoto
-	if err := db.DB.Where("landmark_id = ?", landmarkID).Find(&photos).Error; err != nil {
+	photos, err := repos.Photos.ListLandmarkPhotosByLandmark(landmarkID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark photos"})
 		return
 	}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"log"
+	"net/http"
+)
+
+// Modes of landmarkSettings.CityValidation
+const (
+	cityValidationOff    = "off"
+	cityValidationWarn   = "warn"
+	cityValidationReject = "reject"
+)
+
+// errNoCityAtCoordinates is returned when no city can be chosen for a point
+var errNoCityAtCoordinates = errors.New("no region boundary with cities contains the coordinates")
+
+// hasCoordinates reports whether coordinates were given, omitted ones are both left at 0
+func hasCoordinates(latitude, longitude float64) bool {
+	return latitude != 0 || longitude != 0
+}
+
+// placeLandmark makes sure the landmark belongs to an existing city, responding with an error if it fails.
+// A missing city_id is resolved from the coordinates, a given one is checked against them.
+func placeLandmark(c *gin.Context, landmark *models.Landmark) bool {
+	latitude, longitude := landmark.Latitude, landmark.Longitude
+	located := hasCoordinates(latitude, longitude)
+
+	if landmark.CityID == 0 {
+		if !located {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "city_id is required unless latitude and longitude are given"})
+			return false
+		}
+
+		city, err := resolveCity(latitude, longitude)
+		if errors.Is(err, errNoCityAtCoordinates) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Could not resolve city_id from the coordinates", "details": err.Error()})
+			return false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve city_id from the coordinates"})
+			return false
+		}
+		landmark.CityID = city.ID
+		return true
+	}
+
+	// Validate city existence
+	city, err := repos.Cities.Get(landmark.CityID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "City with the specified city_id does not exist"})
+		return false
+	}
+	if !located || landmarkSettings.CityValidation == cityValidationOff {
+		return true
+	}
+
+	problem, err := cityMismatch(city, latitude, longitude)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate city_id against the coordinates"})
+		return false
+	}
+	if problem == "" {
+		return true
+	}
+
+	if landmarkSettings.CityValidation == cityValidationReject {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "city_id contradicts the coordinates", "details": problem})
+		return false
+	}
+	log.Printf("Landmark %q placed in city %d although %s", landmark.Name, city.ID, problem)
+	c.Header("Warning", fmt.Sprintf("199 - %q", "city_id contradicts the coordinates: "+problem))
+	return true
+}
+
+// resolveCity returns the city closest to the point among the cities of the region containing it
+func resolveCity(latitude, longitude float64) (*models.City, error) {
+	ids, err := locator.lookup(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nested regions are tried from the smallest one, until one of them has cities
+	for _, regionID := range ids {
+		city, err := repos.Cities.Nearest(latitude, longitude, regionID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		return city, err
+	}
+	return nil, errNoCityAtCoordinates
+}
+
+// cityMismatch explains why the point cannot belong to the city, or returns an empty string if it can
+func cityMismatch(city *models.City, latitude, longitude float64) (string, error) {
+	boundary, err := locator.boundary(city.RegionID)
+	if err != nil {
+		return "", err
+	}
+	if boundary != nil && !boundary.Contains(geo.Point{Lng: longitude, Lat: latitude}) {
+		return fmt.Sprintf("the coordinates lie outside the boundary of region %d of city %d", city.RegionID, city.ID), nil
+	}
+
+	maxDistance := landmarkSettings.MaxCityDistanceKm
+	if maxDistance > 0 && hasCoordinates(city.Latitude, city.Longitude) {
+		if distance := geo.DistanceKm(latitude, longitude, city.Latitude, city.Longitude); distance > maxDistance {
+			return fmt.Sprintf("the coordinates lie %.1f km away from city %d, more than %.1f km", distance, city.ID, maxDistance), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/tiles"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// languageTag matches the language tags keying the labels of a landmark type, such as sq or sr-Latn
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// landmarkTypeNode is a landmark type along with its subtypes
+type landmarkTypeNode struct {
+	models.LandmarkType
+	Children []landmarkTypeNode `json:"children"`
+}
+
+func GetLandmarkTypes(c *gin.Context) {
+	p, err := parsePagination(c, true)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+
+	landmarkTypes, total, err := repos.LandmarkTypes.List(p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark types"})
+		return
+	}
+	landmarkTypes, next := pageOf(p, landmarkTypes, landmarkTypeIDOf)
+	respondPage(c, landmarkTypes, next, total)
+}
+
+// GetLandmarkTypeTree returns the whole taxonomy as a list of the top level types, every type
+// holding its subtypes in children
+func GetLandmarkTypeTree(c *gin.Context) {
+	landmarkTypes, _, err := repos.LandmarkTypes.List(repository.Page{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark types"})
+		return
+	}
+
+	children := map[uint][]models.LandmarkType{}
+	var roots []models.LandmarkType
+	for _, landmarkType := range landmarkTypes {
+		if landmarkType.ParentID == nil {
+			roots = append(roots, landmarkType)
+			continue
+		}
+		children[*landmarkType.ParentID] = append(children[*landmarkType.ParentID], landmarkType)
+	}
+
+	var nodes func(landmarkTypes []models.LandmarkType) []landmarkTypeNode
+	nodes = func(landmarkTypes []models.LandmarkType) []landmarkTypeNode {
+		result := make([]landmarkTypeNode, len(landmarkTypes))
+		for i, landmarkType := range landmarkTypes {
+			result[i] = landmarkTypeNode{LandmarkType: landmarkType, Children: nodes(children[landmarkType.ID])}
+		}
+		return result
+	}
+	c.JSON(http.StatusOK, nodes(roots))
+}
+
+// findLandmarkType loads the landmark type named by the id path parameter, responding with an error if it fails
+func findLandmarkType(c *gin.Context) (*models.LandmarkType, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Landmark type not found"})
+		return nil, false
+	}
+
+	landmarkType, err := repos.LandmarkTypes.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Landmark type not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark type"})
+		return nil, false
+	}
+	return landmarkType, true
+}
+
+func GetLandmarkTypeByID(c *gin.Context) {
+	landmarkType, ok := findLandmarkType(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, landmarkType)
+}
+
+// CreateLandmarkType creates a landmark type. The slug is derived from the name when it is omitted.
+func CreateLandmarkType(c *gin.Context) {
+	var input models.LandmarkType
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid landmark type data"})
+		return
+	}
+	input.ID = 0
+
+	if !checkLandmarkType(c, &input) {
+		return
+	}
+
+	if err := repos.LandmarkTypes.Create(&input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create landmark type"})
+		return
+	}
+	c.JSON(http.StatusCreated, input)
+}
+
+// UpdateLandmarkType updates a landmark type by ID. Changing its slug changes the type of its landmarks along.
+func UpdateLandmarkType(c *gin.Context) {
+	landmarkType, ok := findLandmarkType(c)
+	if !ok {
+		return
+	}
+	id, slug := landmarkType.ID, landmarkType.Slug
+
+	// Labels are replaced as a whole rather than merged with the stored ones
+	landmarkType.Labels = nil
+	if err := c.ShouldBindJSON(landmarkType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid landmark type data"})
+		return
+	}
+	landmarkType.ID = id
+
+	if !checkLandmarkType(c, landmarkType) {
+		return
+	}
+
+	if err := repos.LandmarkTypes.Update(landmarkType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update landmark type"})
+		return
+	}
+	if landmarkType.Slug != slug {
+		tiles.Invalidate()
+		textSearch.invalidate()
+	}
+
+	c.JSON(http.StatusOK, landmarkType)
+}
+
+// DeleteLandmarkType deletes a landmark type by ID, unless it has subtypes or landmarks
+func DeleteLandmarkType(c *gin.Context) {
+	landmarkType, ok := findLandmarkType(c)
+	if !ok {
+		return
+	}
+
+	landmarkTypes, _, err := repos.LandmarkTypes.List(repository.Page{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark types"})
+		return
+	}
+	for _, child := range landmarkTypes {
+		if child.ParentID != nil && *child.ParentID == landmarkType.ID {
+			c.JSON(http.StatusConflict, gin.H{"error": "Landmark type has subtypes", "details": fmt.Sprintf("%s is the parent of %s", landmarkType.Slug, child.Slug)})
+			return
+		}
+	}
+
+	_, total, err := repos.Landmarks.Filter(repository.LandmarkFilter{Types: []string{landmarkType.Slug}}, repository.Page{Limit: 1})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmarks"})
+		return
+	}
+	if total > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Landmark type is in use", "details": fmt.Sprintf("%d landmarks have the type %s", total, landmarkType.Slug)})
+		return
+	}
+
+	if err := repos.LandmarkTypes.Delete(landmarkType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete landmark type"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// checkLandmarkType normalizes the slug of a created or updated type and checks that it is unique,
+// that its parent exists without making a cycle and that its labels are keyed by language tags
+func checkLandmarkType(c *gin.Context, landmarkType *models.LandmarkType) bool {
+	landmarkType.Name = strings.TrimSpace(landmarkType.Name)
+	if landmarkType.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return false
+	}
+	if landmarkType.Slug == "" {
+		landmarkType.Slug = landmarkType.Name
+	}
+	landmarkType.Slug = models.LandmarkTypeSlug(landmarkType.Slug)
+	if landmarkType.Slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slug must hold a letter or a digit"})
+		return false
+	}
+
+	existing, err := repos.LandmarkTypes.GetBySlug(landmarkType.Slug)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark type"})
+		return false
+	}
+	if err == nil && existing.ID != landmarkType.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Landmark type already exists", "details": fmt.Sprintf("landmark type %d has the slug %s", existing.ID, existing.Slug)})
+		return false
+	}
+
+	// The ancestors of the parent are walked up to the top to rule out cycles
+	for parentID := landmarkType.ParentID; parentID != nil; {
+		if landmarkType.ID != 0 && *parentID == landmarkType.ID {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid parent_id", "details": "a landmark type cannot be its own ancestor"})
+			return false
+		}
+		parent, err := repos.LandmarkTypes.Get(*parentID)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid parent_id", "details": fmt.Sprintf("landmark type %d does not exist", *parentID)})
+			return false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark type"})
+			return false
+		}
+		parentID = parent.ParentID
+	}
+
+	if landmarkType.Labels == nil {
+		landmarkType.Labels = map[string]string{}
+	}
+	for language, label := range landmarkType.Labels {
+		if !languageTag.MatchString(language) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid labels", "details": fmt.Sprintf("%q is not a language tag", language)})
+			return false
+		}
+		if strings.TrimSpace(label) == "" {
+			delete(landmarkType.Labels, language)
+		}
+	}
+	return true
+}
+
+// knownLandmarkType returns the slug of the landmark type given by its slug or its name, or
+// repository.ErrNotFound when the taxonomy has no such type
+func knownLandmarkType(value string) (string, error) {
+	slug := models.LandmarkTypeSlug(value)
+	if slug == "" {
+		return "", repository.ErrNotFound
+	}
+	if _, err := repos.LandmarkTypes.GetBySlug(slug); err != nil {
+		return "", err
+	}
+	return slug, nil
+}
+
+// validLandmarkType replaces the type of a created or updated landmark by its slug, rejecting the
+// types missing from the taxonomy. Landmarks may have no type.
+func validLandmarkType(c *gin.Context, landmark *models.Landmark) bool {
+	if strings.TrimSpace(landmark.Type) == "" {
+		landmark.Type = ""
+		return true
+	}
+	slug, err := knownLandmarkType(landmark.Type)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unknown landmark type", "details": fmt.Sprintf("%q is not a landmark type, see /landmark-types", landmark.Type)})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve landmark type"})
+		return false
+	}
+	landmark.Type = slug
+	return true
+}
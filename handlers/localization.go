@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/locale"
+	"landmarksmodule/models"
+	"landmarksmodule/search"
+	"net/http"
+)
+
+// localesKey is the context key of the locales negotiated for a request
+const localesKey = "locales"
+
+// searchEntities maps the kinds of search results to the translated entities
+var searchEntities = map[search.Kind]string{
+	search.Landmark: models.EntityLandmark,
+	search.City:     models.EntityCity,
+	search.Region:   models.EntityRegion,
+}
+
+// NegotiateLocale reads the locales of a request from the lang query parameter, then from the
+// Accept-Language header, for the read endpoints to translate their responses with localize
+func NegotiateLocale(c *gin.Context) {
+	locales, err := locale.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid lang query parameter", "details": err.Error()})
+		return
+	}
+	c.Header("Vary", "Accept-Language")
+	c.Set(localesKey, locales)
+	c.Next()
+}
+
+// requestLocales returns the locales negotiated for a request, the most preferred first
+func requestLocales(c *gin.Context) []string {
+	locales, _ := c.Get(localesKey)
+	list, _ := locales.([]string)
+	return list
+}
+
+// localize replaces the translated fields of the landmarks, cities, regions and countries of a
+// response, and the names of the search results, by their text in the locales of the request,
+// responding with an error if it fails. Every field falls back on its text in the next locale,
+// and keeps its stored text when no locale has a translation of it. Other data are left as they are.
+func localize(c *gin.Context, data interface{}) bool {
+	locales := requestLocales(c)
+	if len(locales) == 0 {
+		return true
+	}
+
+	var err error
+	switch data := data.(type) {
+	case []models.Landmark:
+		err = localizeLandmarks(pointers(data), locales)
+	case *models.Landmark:
+		err = localizeLandmarks([]*models.Landmark{data}, locales)
+	case []models.City:
+		err = localizeCities(pointers(data), locales)
+	case *models.City:
+		err = localizeCities([]*models.City{data}, locales)
+	case []models.Region:
+		err = localizeRegions(pointers(data), locales)
+	case *models.Region:
+		err = localizeRegions([]*models.Region{data}, locales)
+	case []models.Country:
+		err = localizeCountries(pointers(data), locales)
+	case *models.Country:
+		err = localizeCountries([]*models.Country{data}, locales)
+	case []search.Hit:
+		err = localizeResults(data, locales, func(hit *search.Hit) (search.Kind, uint, *string) {
+			return hit.Kind, hit.ID, &hit.Name
+		})
+	case []search.Suggestion:
+		err = localizeResults(data, locales, func(suggestion *search.Suggestion) (search.Kind, uint, *string) {
+			return suggestion.Kind, suggestion.ID, &suggestion.Name
+		})
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve translations"})
+		return false
+	}
+	return true
+}
+
+func localizeLandmarks(landmarks []*models.Landmark, locales []string) error {
+	return localizeRows(models.EntityLandmark, landmarks, locales, func(landmark *models.Landmark) (uint, map[string]*string) {
+		return landmark.ID, map[string]*string{
+			"name":        &landmark.Name,
+			"information": &landmark.Information,
+			"description": &landmark.Description,
+		}
+	})
+}
+
+func localizeCities(cities []*models.City, locales []string) error {
+	return localizeRows(models.EntityCity, cities, locales, func(city *models.City) (uint, map[string]*string) {
+		return city.ID, map[string]*string{"name": &city.Name}
+	})
+}
+
+func localizeRegions(regions []*models.Region, locales []string) error {
+	return localizeRows(models.EntityRegion, regions, locales, func(region *models.Region) (uint, map[string]*string) {
+		return region.ID, map[string]*string{"name": &region.Name}
+	})
+}
+
+// localizeCountries translates the countries along with their regions
+func localizeCountries(countries []*models.Country, locales []string) error {
+	var regions []*models.Region
+	for _, country := range countries {
+		regions = append(regions, pointers(country.Regions)...)
+	}
+	if err := localizeRegions(regions, locales); err != nil {
+		return err
+	}
+	return localizeRows(models.EntityCountry, countries, locales, func(country *models.Country) (uint, map[string]*string) {
+		return country.ID, map[string]*string{"name": &country.Name}
+	})
+}
+
+// localizeRows replaces the translated fields of rows of an entity, loading the translations of all
+// of them at once. fields returns the id of a row and its translated fields keyed by name.
+func localizeRows[T any](entity string, rows []*T, locales []string, fields func(row *T) (uint, map[string]*string)) error {
+	if len(rows) == 0 || len(locales) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i], _ = fields(row)
+	}
+	translations, err := repos.Translations.ListByEntities(entity, ids, locales)
+	if err != nil {
+		return err
+	}
+
+	// Keep the translation in the most preferred locale of every field
+	rank := map[string]int{}
+	for i, preferred := range locales {
+		rank[preferred] = i
+	}
+	type fieldKey struct {
+		id    uint
+		field string
+	}
+	best := map[fieldKey]models.Translation{}
+	for _, translation := range translations {
+		key := fieldKey{id: translation.EntityID, field: translation.Field}
+		if current, ok := best[key]; !ok || rank[translation.Locale] < rank[current.Locale] {
+			best[key] = translation
+		}
+	}
+
+	for _, row := range rows {
+		id, texts := fields(row)
+		for field, text := range texts {
+			if translation, ok := best[fieldKey{id: id, field: field}]; ok && translation.Text != "" {
+				*text = translation.Text
+			}
+		}
+	}
+	return nil
+}
+
+// localizeResults translates the names of search results, result giving the kind, id and name of one
+func localizeResults[T any](results []T, locales []string, result func(row *T) (search.Kind, uint, *string)) error {
+	for kind, entity := range searchEntities {
+		var rows []*T
+		for i := range results {
+			if rowKind, _, _ := result(&results[i]); rowKind == kind {
+				rows = append(rows, &results[i])
+			}
+		}
+		err := localizeRows(entity, rows, locales, func(row *T) (uint, map[string]*string) {
+			_, id, name := result(row)
+			return id, map[string]*string{"name": name}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pointers returns pointers to the elements of rows, for them to be modified in place
+func pointers[T any](rows []T) []*T {
+	result := make([]*T, len(rows))
+	for i := range rows {
+		result[i] = &rows[i]
+	}
+	return result
+}
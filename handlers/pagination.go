@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// pagination is the window of a listing requested through the limit, cursor and page query parameters
+type pagination struct {
+	limit   int
+	afterID uint
+	offset  int
+	// keyset is true when the next cursor points after the id of the last row instead of at an offset
+	keyset bool
+	sort   []repository.SortField
+}
+
+// pageResponse is the envelope of every paginated listing
+type pageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor *string     `json:"next_cursor"`
+	Total      int64       `json:"total"`
+	// Facets counts the values of the filters of the filtered landmark listings
+	Facets *repository.LandmarkFacets `json:"facets,omitempty"`
+}
+
+// parsePagination reads the limit, cursor and page query parameters. keyset tells whether the
+// listing is ordered by id, in which case cursors point after the last row instead of counting rows.
+func parsePagination(c *gin.Context, keyset bool) (*pagination, error) {
+	p := &pagination{limit: defaultPageLimit, keyset: keyset}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return nil, errors.New("invalid limit query parameter")
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		p.limit = limit
+	}
+
+	cursor, page := c.Query("cursor"), c.Query("page")
+	if cursor != "" && page != "" {
+		return nil, errors.New("cursor and page query parameters cannot be combined")
+	}
+
+	if page != "" {
+		number, err := strconv.Atoi(page)
+		if err != nil || number <= 0 {
+			return nil, errors.New("invalid page query parameter")
+		}
+		p.offset = (number - 1) * p.limit
+		p.keyset = false
+	}
+
+	if cursor != "" {
+		kind, value, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, errors.New("invalid cursor query parameter")
+		}
+		switch {
+		case kind == "id" && keyset:
+			p.afterID = uint(value)
+		case kind == "offset":
+			p.offset = int(value)
+			p.keyset = false
+		default:
+			return nil, errors.New("invalid cursor query parameter")
+		}
+	}
+
+	return p, nil
+}
+
+// query returns the page to request from a repository, one row more than the limit
+// so that the presence of a next page can be detected
+func (p *pagination) query() repository.Page {
+	return repository.Page{Limit: p.limit + 1, AfterID: p.afterID, Offset: p.offset, Sort: p.sort}
+}
+
+// pageOf drops the extra row requested by query and returns the cursor of the next page,
+// which is empty on the last page
+func pageOf[T any](p *pagination, rows []T, id func(row *T) uint) ([]T, string) {
+	if rows == nil {
+		rows = []T{}
+	}
+	if len(rows) <= p.limit {
+		return rows, ""
+	}
+
+	rows = rows[:p.limit]
+	if p.keyset {
+		return rows, encodeCursor("id", uint64(id(&rows[len(rows)-1])))
+	}
+	return rows, encodeCursor("offset", uint64(p.offset+p.limit))
+}
+
+// respondPage writes a page of a listing wrapped in the pagination envelope, with Link headers
+func respondPage(c *gin.Context, data interface{}, next string, total int64) {
+	respondFacetedPage(c, data, nil, next, total)
+}
+
+// respondFacetedPage is respondPage for a listing with facet counts
+func respondFacetedPage(c *gin.Context, data interface{}, facets *repository.LandmarkFacets, next string, total int64) {
+	if !localize(c, data) {
+		return
+	}
+	response := pageResponse{Data: data, Total: total, Facets: facets}
+	if next != "" {
+		response.NextCursor = &next
+	}
+	setPageLinks(c, next)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// setPageLinks sets the Link header pointing at the first and the next page of a listing
+func setPageLinks(c *gin.Context, next string) {
+	links := []string{fmt.Sprintf("<%s>; rel=\"first\"", pageURL(c, ""))}
+	if next != "" {
+		links = append(links, fmt.Sprintf("<%s>; rel=\"next\"", pageURL(c, next)))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// pageURL returns the URL of the current request pointing at the page of the given cursor
+func pageURL(c *gin.Context, cursor string) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Del("page")
+	query.Del("cursor")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+func encodeCursor(kind string, value uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + strconv.FormatUint(value, 10)))
+}
+
+func decodeCursor(cursor string) (string, uint64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	kind, value, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", 0, errors.New("malformed cursor")
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return kind, number, nil
+}
+
+// Row identifiers used to build keyset cursors
+func countryIDOf(r *models.Country) uint             { return r.ID }
+func regionIDOf(r *models.Region) uint               { return r.ID }
+func cityIDOf(r *models.City) uint                   { return r.ID }
+func landmarkIDOf(r *models.Landmark) uint           { return r.ID }
+func reviewIDOf(r *models.Review) uint               { return r.ID }
+func landmarkPhotoIDOf(r *models.LandmarkPhoto) uint { return r.ID }
+func reviewPhotoIDOf(r *models.ReviewPhoto) uint     { return r.ID }
+func geoJSONIDOf(r *models.GeoJSON) uint             { return r.ID }
+func landmarkTypeIDOf(r *models.LandmarkType) uint   { return r.ID }
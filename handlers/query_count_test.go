@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"landmarksmodule/config"
+	"landmarksmodule/db"
+	"landmarksmodule/handlers"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/routes"
+
+	"github.com/jinzhu/gorm"
+)
+
+// queries counts the statements run through gorm by the databases of sqliteServer
+var queries atomic.Int64
+
+// listingEndpoints are the listings whose related rows must be loaded in batches, whatever the page size
+var listingEndpoints = []string{
+	"/landmarks?limit=500&reviews=1",
+	"/landmarks?limit=500&reviews=1&lang=de",
+	"/landmarks.geojson?limit=500",
+	"/countries?limit=500",
+	"/regions?limit=500",
+}
+
+// sqliteServer serves the API over an in-memory SQLite database seeded with size landmarks and
+// size countries, counting its queries in queries
+func sqliteServer(t testing.TB, size int) *server {
+	t.Helper()
+	if err := db.Init(config.DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+	count := func(*gorm.Scope) { queries.Add(1) }
+	db.DB.Callback().Query().After("gorm:query").Register("test:count_queries", count)
+	db.DB.Callback().RowQuery().After("gorm:row_query").Register("test:count_queries", count)
+
+	seed(t, size)
+	repos := repository.NewGorm(db.DB, db.SQL)
+	handlers.Init(repos, config.Default().Landmarks)
+	return &server{t: t, router: routes.NewRouter(), repos: repos}
+}
+
+// seed stores size countries of two regions with boundaries, and size landmarks with two photos
+// and two reviews of one photo each, all of them translated
+func seed(t testing.TB, size int) {
+	t.Helper()
+	create := func(value interface{}) {
+		if err := db.DB.Create(value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	translate := func(entity string, id uint, field string) {
+		create(&models.Translation{EntityType: entity, EntityID: id, Field: field, Locale: "de", Text: fmt.Sprintf("%s %d", entity, id)})
+	}
+
+	var cityID uint
+	for i := 0; i < size; i++ {
+		country := &models.Country{Name: fmt.Sprintf("Country %d", i)}
+		create(country)
+		translate(models.EntityCountry, country.ID, "name")
+		for j := 0; j < 2; j++ {
+			lng, lat := float64(i), float64(j)
+			region := &models.Region{Name: fmt.Sprintf("Region %d.%d", i, j), CountryID: country.ID}
+			create(region)
+			translate(models.EntityRegion, region.ID, "name")
+			create(&models.GeoJSON{RegionID: region.ID, GeoJSONData: fmt.Sprintf(
+				`{"type":"Polygon","coordinates":[[[%[1]g,%[2]g],[%[3]g,%[2]g],[%[3]g,%[4]g],[%[1]g,%[4]g],[%[1]g,%[2]g]]]}`,
+				lng, lat, lng+1, lat+1)})
+			if cityID == 0 {
+				city := &models.City{Name: "Prizren", RegionID: region.ID}
+				create(city)
+				cityID = city.ID
+			}
+		}
+	}
+
+	for i := 0; i < size; i++ {
+		landmark := &models.Landmark{Name: fmt.Sprintf("Landmark %d", i), CityID: cityID}
+		create(landmark)
+		translate(models.EntityLandmark, landmark.ID, "name")
+		for j := 0; j < 2; j++ {
+			create(&models.LandmarkPhoto{LandmarkID: landmark.ID, Name: "photo.jpg", Path: fmt.Sprintf("/files/landmarks/%d-%d.jpg", i, j)})
+			review := &models.Review{LandmarkID: landmark.ID, DeviceID: "device", Name: "Ana", Rating: j + 4}
+			create(review)
+			create(&models.ReviewPhoto{ReviewID: review.ID, Name: "photo.jpg", Path: fmt.Sprintf("/files/reviews/%d.jpg", review.ID)})
+		}
+	}
+}
+
+// countQueries requests the path and returns the number of queries it ran
+func countQueries(s *server, path string) int64 {
+	s.t.Helper()
+	before := queries.Load()
+	s.expect(http.StatusOK, http.MethodGet, path, nil, nil)
+	return queries.Load() - before
+}
+
+// TestListingQueriesDoNotGrowWithPageSize checks that the listings load the photos, reviews,
+// review statistics, regions, boundaries and translations of a page in a fixed number of queries
+func TestListingQueriesDoNotGrowWithPageSize(t *testing.T) {
+	const size = 5
+	counts := map[int]map[string]int64{}
+	for _, n := range []int{size, 10 * size} {
+		s := sqliteServer(t, n)
+		counts[n] = map[string]int64{}
+		for _, path := range listingEndpoints {
+			counts[n][path] = countQueries(s, path)
+		}
+
+		var landmarks page[models.Landmark]
+		s.expect(http.StatusOK, http.MethodGet, "/landmarks?limit=500&reviews=1", nil, &landmarks)
+		if len(landmarks.Data) != n || len(landmarks.Data[0].Reviews) != 2 || len(landmarks.Data[0].PhotoLinks) != 2 || len(landmarks.Data[0].Reviews[0].PhotoLinks) != 1 {
+			t.Fatalf("%d landmarks seeded, listing has %d, the first with %d reviews and %d photos", n, len(landmarks.Data), len(landmarks.Data[0].Reviews), len(landmarks.Data[0].PhotoLinks))
+		}
+		var countries page[models.Country]
+		s.expect(http.StatusOK, http.MethodGet, "/countries?limit=500", nil, &countries)
+		if len(countries.Data) != n || len(countries.Data[0].Regions) != 2 || countries.Data[0].Regions[0].GeoJSON == "" {
+			t.Fatalf("%d countries seeded, listing has %d, the first with %d regions", n, len(countries.Data), len(countries.Data[0].Regions))
+		}
+	}
+
+	for _, path := range listingEndpoints {
+		if counts[size][path] == 0 || counts[size][path] != counts[10*size][path] {
+			t.Errorf("GET %s ran %d queries for %d rows and %d for %d rows", path, counts[size][path], size, counts[10*size][path], 10*size)
+		}
+	}
+}
+
+func BenchmarkLandmarkListing(b *testing.B) {
+	s := sqliteServer(b, 100)
+	for b.Loop() {
+		s.expect(http.StatusOK, http.MethodGet, "/landmarks?limit=100&reviews=1", nil, nil)
+	}
+}
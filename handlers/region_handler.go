@@ -1,8 +1,12 @@
 package handlers
 
 import (
-	"landmarksmodule/db"
+	"errors"
+	"landmarksmodule/export"
+	"landmarksmodule/geo"
 	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/tiles"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,117 +20,217 @@ func CreateRegion(c *gin.Context) {
 		return
 	}
 
-	db.DB.Create(&region)
+	if err := repos.Regions.Create(&region); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create region"})
+		return
+	}
+	textSearch.invalidate()
 
 	c.JSON(http.StatusCreated, region)
 }
 
-// GetRegions returns all regions with associated GeoJSON if available
+// GetRegions returns a page of regions with associated GeoJSON if available, see
+// parseGeometryDetail for the query parameters choosing its detail
 func GetRegions(c *gin.Context) {
-	var regions []models.Region
-	db.DB.Find(&regions)
+	p, err := parseSortedPagination(c, repository.RegionSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+	detail, err := parseGeometryDetail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	regions, total, err := repos.Regions.List(p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions"})
+		return
+	}
+	regions, next := pageOf(p, regions, regionIDOf)
 
 	// Fetch GeoJSON for each region if available
-	for i := range regions {
-		geoJSON, err := db.GetGeoJSONByRegionID(regions[i].ID)
-		if err == nil && geoJSON != nil {
-			regions[i].GeoJSON = geoJSON.GeoJSONData
-		}
+	if err := repos.GeoJSON.LoadRegionGeoJSON(regions, detail); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve GeoJSON for regions"})
+		return
 	}
 
-	c.JSON(http.StatusOK, regions)
+	respondPage(c, regions, next, total)
 }
 
 // GetRegionByID returns a region by ID along with its GeoJSON data if available
 func GetRegionByID(c *gin.Context) {
-	var region models.Region
-	id := c.Param("id")
-	db.DB.First(&region, id)
+	detail, err := parseGeometryDetail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if region.ID == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+	region, ok := findRegion(c)
+	if !ok {
 		return
 	}
 
 	// Fetch GeoJSON associated with the region
-	geoJSON, err := db.GetGeoJSONByRegionID(region.ID)
-	if err == nil && geoJSON != nil {
-		region.GeoJSON = geoJSON.GeoJSONData
+	regions := []models.Region{*region}
+	if err := repos.GeoJSON.LoadRegionGeoJSON(regions, detail); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve GeoJSON for region"})
+		return
 	}
 
-	c.JSON(http.StatusOK, region)
+	if !localize(c, regions) {
+		return
+	}
+	c.JSON(http.StatusOK, regions[0])
 }
 
-// UpdateRegion updates a region by ID
-func UpdateRegion(c *gin.Context) {
-	var region models.Region
-	id := c.Param("id")
+// GetRegionBoundary exports the boundary of a region as GeoJSON, as a KML polygon or as GPX tracks,
+// see parseGeometryDetail for the query parameters choosing its detail
+func GetRegionBoundary(c *gin.Context) {
+	detail, err := parseGeometryDetail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	detail.Omit = false
+
+	region, ok := findRegion(c)
+	if !ok {
+		return
+	}
+
+	regions := []models.Region{*region}
+	if err := repos.GeoJSON.LoadRegionGeoJSON(regions, detail); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve GeoJSON for region"})
+		return
+	}
+	if regions[0].GeoJSON == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "GeoJSON not found"})
+		return
+	}
+
+	if c.GetString(listingFormatKey) == formatGeoJSON {
+		c.Data(http.StatusOK, "application/geo+json", []byte(regions[0].GeoJSON))
+		return
+	}
+	shape, err := geo.ParseShape([]byte(regions[0].GeoJSON))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse GeoJSON"})
+		return
+	}
+	if !localize(c, region) {
+		return
+	}
+	respondPlacemarks(c, region.Name, []export.Placemark{{Name: region.Name, Type: "region", Polygons: shape.Polygons}})
+}
 
-	if err := db.DB.First(&region, id).Error; err != nil {
+// findRegion loads the region named by the id path parameter, responding with an error if it fails
+func findRegion(c *gin.Context) (*models.Region, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+		return nil, false
+	}
+
+	region, err := repos.Regions.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve region"})
+		return nil, false
+	}
+	return region, true
+}
+
+// UpdateRegion updates a region by ID
+func UpdateRegion(c *gin.Context) {
+	region, ok := findRegion(c)
+	if !ok {
 		return
 	}
 
-	if err := c.BindJSON(&region); err != nil {
+	if err := c.BindJSON(region); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region data"})
 		return
 	}
 
-	db.DB.Save(&region)
+	if err := repos.Regions.Update(region); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update region"})
+		return
+	}
+	tiles.Invalidate()
+	textSearch.invalidate()
 
 	c.JSON(http.StatusOK, region)
 }
 
 // DeleteRegion deletes a region by ID
 func DeleteRegion(c *gin.Context) {
-	var region models.Region
-	id := c.Param("id")
-
-	if err := db.DB.First(&region, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+	region, ok := findRegion(c)
+	if !ok {
 		return
 	}
 
-	db.DB.Delete(&region)
+	if err := repos.Regions.Delete(region); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete region"})
+		return
+	}
+	locator.invalidate()
+	tiles.Invalidate()
+	textSearch.invalidate()
 
 	c.Status(http.StatusNoContent)
 }
 
 func SearchRegions(c *gin.Context) {
-	var regions []models.Region
-	name := c.Query("name")
-	query := db.DB.Where("name LIKE ?", "%"+name+"%")
-	query.Find(&regions)
-	c.JSON(http.StatusOK, regions)
+	p, err := parseSortedPagination(c, repository.RegionSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+
+	regions, total, err := repos.Regions.Search(c.Query("name"), p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search regions"})
+		return
+	}
+	regions, next := pageOf(p, regions, regionIDOf)
+	respondPage(c, regions, next, total)
 }
 
 func FilterRegions(c *gin.Context) {
-	var regions []models.Region
-
-	// Define the query parameters and their corresponding SQL clauses
-	params := map[string]string{
-		"min_population": "population >= ?",
-		"max_population": "population <= ?",
+	p, err := parseSortedPagination(c, repository.RegionSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
 	}
 
-	query := db.DB
-	for param, clause := range params {
-		if value := c.Query(param); value != "" {
-			query = query.Where(clause, value)
-		}
+	var filter repository.RegionFilter
+	if filter.MinPopulation, err = queryInt(c, "min_population"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.MaxPopulation, err = queryInt(c, "max_population"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := query.Find(&regions).Error; err != nil {
+	regions, total, err := repos.Regions.Filter(filter, p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve regions"})
 		return
 	}
 
-	if len(regions) == 0 {
+	if total == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No region found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, regions)
+	regions, next := pageOf(p, regions, regionIDOf)
+	respondPage(c, regions, next, total)
 }
 
 func AddRegionsToCountry(c *gin.Context) {
@@ -145,12 +249,12 @@ func AddRegionsToCountry(c *gin.Context) {
 		return
 	}
 
-	if err := db.DB.Model(&models.Region{}).
-		Where("id IN (?)", input.RegionIDs).
-		Update("country_id", input.CountryID).Error; err != nil {
+	if err := repos.Regions.AssignCountry(input.CountryID, input.RegionIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update regions"})
 		return
 	}
+	tiles.Invalidate()
+	textSearch.invalidate()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Regions updated successfully"})
 }
@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"landmarksmodule/export"
+	"landmarksmodule/models"
+)
+
+func TestRegionCRUD(t *testing.T) {
+	s := newServer(t)
+
+	var created models.Region
+	s.expect(http.StatusCreated, http.MethodPost, "/regions", map[string]interface{}{"name": "Prizren", "population": 177781}, &created)
+	if created.ID == 0 {
+		t.Fatalf("created %+v without an id", created)
+	}
+
+	path := fmt.Sprintf("/regions/%d", created.ID)
+	var fetched models.Region
+	s.expect(http.StatusOK, http.MethodGet, path, nil, &fetched)
+	if fetched.Name != "Prizren" || fetched.Population != 177781 {
+		t.Errorf("fetched %+v", fetched)
+	}
+
+	var updated models.Region
+	s.expect(http.StatusOK, http.MethodPut, path, map[string]interface{}{"name": "Prizreni"}, &updated)
+	if updated.Name != "Prizreni" || updated.Population != 177781 {
+		t.Errorf("updated %+v, want the new name and the same population", updated)
+	}
+
+	s.expect(http.StatusNoContent, http.MethodDelete, path, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodGet, path, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodDelete, path, nil, nil)
+}
+
+func TestRegionErrors(t *testing.T) {
+	s := newServer(t)
+	region := &models.Region{Name: "Prizren"}
+	if err := s.repos.Regions.Create(region); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/regions/%d", region.ID)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"unknown id", http.MethodGet, "/regions/999", nil, http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/regions/abc", nil, http.StatusNotFound},
+		{"update unknown id", http.MethodPut, "/regions/999", map[string]interface{}{"name": "x"}, http.StatusNotFound},
+		{"invalid json", http.MethodPost, "/regions", "{", http.StatusBadRequest},
+		{"update invalid json", http.MethodPut, path, "[", http.StatusBadRequest},
+		{"invalid zoom", http.MethodGet, path + "?zoom=99", nil, http.StatusBadRequest},
+		{"zoom and tolerance", http.MethodGet, path + "?zoom=3&tolerance=0.1", nil, http.StatusBadRequest},
+		{"invalid geometry", http.MethodGet, "/regions?geometry=maybe", nil, http.StatusBadRequest},
+		{"invalid page", http.MethodGet, "/regions?page=0", nil, http.StatusBadRequest},
+		{"lookup without coordinates", http.MethodGet, "/regions/lookup", nil, http.StatusBadRequest},
+		{"lookup latitude out of range", http.MethodGet, "/regions/lookup?latitude=500&longitude=20", nil, http.StatusBadRequest},
+		{"lookup longitude out of range", http.MethodGet, "/regions/lookup?latitude=42&longitude=-181", nil, http.StatusBadRequest},
+		{"lookup outside every region", http.MethodGet, "/regions/lookup?latitude=42&longitude=20", nil, http.StatusNotFound},
+		{"no regions to assign", http.MethodPost, "/regions/country", map[string]interface{}{"country_id": 1}, http.StatusBadRequest},
+		{"unknown translation locale", http.MethodPut, path + "/translations/xx", map[string]string{"name": "x"}, http.StatusNotFound},
+		{"untranslated field", http.MethodPut, path + "/translations/de", map[string]string{"area": "x"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := s.do(test.method, test.path, test.body)
+			if response.Code != test.status {
+				t.Errorf("status %d, want %d: %s", response.Code, test.status, response.Body)
+			}
+		})
+	}
+}
+
+func TestRegionPagination(t *testing.T) {
+	s := newServer(t)
+	for i := 0; i < 4; i++ {
+		if err := s.repos.Regions.Create(&models.Region{Name: fmt.Sprintf("Region %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, pages := collect(s, "/regions?limit=3", func(region models.Region) uint { return region.ID })
+	if len(ids) != 4 || pages != 2 || !distinct(ids) {
+		t.Errorf("cursors gave ids %v in %d pages, want the 4 regions in 2 pages", ids, pages)
+	}
+
+	var second page[models.Region]
+	s.expect(http.StatusOK, http.MethodGet, "/regions?limit=3&page=2", nil, &second)
+	if len(second.Data) != 1 || second.Total != 4 || second.NextCursor != nil {
+		t.Errorf("page 2 has %d regions of %d and cursor %v, want the last one", len(second.Data), second.Total, second.NextCursor)
+	}
+}
+
+func TestRegionBoundaryExport(t *testing.T) {
+	s := newServer(t)
+	region := &models.Region{Name: "Prizren & <Dukagjin>"}
+	if err := s.repos.Regions.Create(region); err != nil {
+		t.Fatal(err)
+	}
+	boundary := &models.GeoJSON{RegionID: region.ID, GeoJSONData: `{"type":"Polygon","coordinates":[[[20.5,42.1],[20.9,42.1],[20.9,42.3],[20.5,42.1]]]}`}
+	if err := s.repos.GeoJSON.Create(boundary); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/regions/%d/boundary", region.ID)
+
+	response := s.do(http.MethodGet, path+".kml", nil)
+	if response.Code != http.StatusOK || response.Header().Get("Content-Type") != export.KMLContentType {
+		t.Fatalf("KML export: status %d and content type %q: %s", response.Code, response.Header().Get("Content-Type"), response.Body)
+	}
+	var kml struct {
+		Name        string `xml:"Document>name"`
+		Coordinates string `xml:"Document>Placemark>Polygon>outerBoundaryIs>LinearRing>coordinates"`
+	}
+	if err := xml.Unmarshal(response.Body.Bytes(), &kml); err != nil {
+		t.Fatal(err)
+	}
+	if kml.Name != region.Name || kml.Coordinates != "20.5,42.1 20.9,42.1 20.9,42.3 20.5,42.1" {
+		t.Errorf("KML export of %q with coordinates %q", kml.Name, kml.Coordinates)
+	}
+
+	response = s.do(http.MethodGet, path+".gpx", nil)
+	if response.Code != http.StatusOK || response.Header().Get("Content-Type") != export.GPXContentType {
+		t.Fatalf("GPX export: status %d and content type %q: %s", response.Code, response.Header().Get("Content-Type"), response.Body)
+	}
+	var gpx struct {
+		Name   string `xml:"trk>name"`
+		Points []struct {
+			Latitude  string `xml:"lat,attr"`
+			Longitude string `xml:"lon,attr"`
+		} `xml:"trk>trkseg>trkpt"`
+	}
+	if err := xml.Unmarshal(response.Body.Bytes(), &gpx); err != nil {
+		t.Fatal(err)
+	}
+	if gpx.Name != region.Name || len(gpx.Points) != 4 || gpx.Points[1].Latitude != "42.1" || gpx.Points[1].Longitude != "20.9" {
+		t.Errorf("GPX export of %q with points %+v", gpx.Name, gpx.Points)
+	}
+
+	s.expect(http.StatusNotFound, http.MethodGet, "/regions/999/boundary.kml", nil, nil)
+}
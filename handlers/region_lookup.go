@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// regionLocator keeps a spatial index of the stored region boundaries.
+// The index is dropped whenever a boundary changes and rebuilt on the next lookup.
+type regionLocator struct {
+	mu    sync.Mutex
+	index *geo.Index
+}
+
+var locator regionLocator
+
+// invalidate drops the index after a change of the region boundaries
+func (l *regionLocator) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.index = nil
+}
+
+// current returns the index, building it first if needed
+func (l *regionLocator) current() (*geo.Index, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.index == nil {
+		index, err := buildRegionIndex()
+		if err != nil {
+			return nil, err
+		}
+		l.index = index
+	}
+	return l.index, nil
+}
+
+// lookup returns the ids of the regions whose boundary contains the point, the smallest region first
+func (l *regionLocator) lookup(latitude, longitude float64) ([]uint, error) {
+	index, err := l.current()
+	if err != nil {
+		return nil, err
+	}
+	return index.Lookup(geo.Point{Lng: longitude, Lat: latitude}), nil
+}
+
+// boundary returns the parsed boundary of the region, or nil if it has none
+func (l *regionLocator) boundary(regionID uint) (*geo.Shape, error) {
+	index, err := l.current()
+	if err != nil {
+		return nil, err
+	}
+	return index.Shape(regionID), nil
+}
+
+// buildRegionIndex indexes the boundary of every region, skipping the ones which cannot be parsed
+func buildRegionIndex() (*geo.Index, error) {
+	records, _, err := repos.GeoJSON.List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	index := geo.NewIndex()
+	indexed := map[uint]bool{}
+	for _, record := range records {
+		// Like GetByRegion, the first record of a region wins
+		if indexed[record.RegionID] {
+			continue
+		}
+		indexed[record.RegionID] = true
+
+		shape, err := geo.ParseShape([]byte(record.GeoJSONData))
+		if err != nil {
+			log.Printf("Skipping GeoJSON %d of region %d: %v", record.ID, record.RegionID, err)
+			continue
+		}
+		index.Add(record.RegionID, shape)
+	}
+	return index, nil
+}
+
+// locateRegion returns the smallest region whose boundary contains the point, or nil if there is none
+func locateRegion(latitude, longitude float64) (*models.Region, error) {
+	ids, err := locator.lookup(latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		region, err := repos.Regions.Get(id)
+		if errors.Is(err, repository.ErrNotFound) {
+			// The boundary of a deleted region
+			continue
+		}
+		return region, err
+	}
+	return nil, nil
+}
+
+// LookupRegion returns the region whose boundary contains the given point, along with its country
+func LookupRegion(c *gin.Context) {
+	latitude, err1 := strconv.ParseFloat(c.Query("latitude"), 64)
+	longitude, err2 := strconv.ParseFloat(c.Query("longitude"), 64)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid latitude or longitude"})
+		return
+	}
+	if !validCoordinates(c, latitude, longitude) {
+		return
+	}
+
+	region, err := locateRegion(latitude, longitude)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up region", "details": err.Error()})
+		return
+	}
+	if region == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No region contains the given point"})
+		return
+	}
+
+	var country *models.Country
+	if region.CountryID != 0 {
+		country, err = repos.Countries.Get(region.CountryID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve country", "details": err.Error()})
+			return
+		}
+	}
+
+	if !localize(c, region) {
+		return
+	}
+	if country != nil && !localize(c, country) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"region": region, "country": country})
+}
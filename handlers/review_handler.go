@@ -5,12 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/jinzhu/gorm"
-	"landmarksmodule/db"
 	"landmarksmodule/models"
+	"landmarksmodule/repository"
 	"net/http"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -38,7 +36,7 @@ func CreateReview(c *gin.Context) {
 	}
 
 	// Create the review
-	if err := db.DB.Create(&input).Error; err != nil {
+	if err := repos.Reviews.Create(&input); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
 		return
 	}
@@ -70,7 +68,7 @@ func CreateReviewWithPhotos(c *gin.Context) {
 	}
 
 	// Create the review
-	if err := db.DB.Create(&input).Error; err != nil {
+	if err := repos.Reviews.Create(&input); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
 		return
 	}
@@ -92,8 +90,8 @@ func CreateReviewWithPhotos(c *gin.Context) {
 				return
 			}
 
-			var landmark models.Landmark
-			if err := db.DB.First(&landmark, input.LandmarkID).Error; err != nil {
+			landmark, err := repos.Landmarks.Get(input.LandmarkID)
+			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark associated with the review not found"})
 				return
 			}
@@ -103,29 +101,27 @@ func CreateReviewWithPhotos(c *gin.Context) {
 			landmarkName := strings.ReplaceAll(landmark.Name, " ", "_")
 			fileName := fmt.Sprintf("%s_%s_%d%s", deviceId, landmarkName, time.Now().UnixNano(), filepath.Ext(file.Filename))
 
-			// Upload file to S3 with directory structure
+			// Upload file to the storage with directory structure
 			uploadPath := fmt.Sprintf("%s/%s/%s", deviceId, landmarkName, fileName)
-			uploadErr := uploadFileToS3(fileBytes, uploadPath)
+			photoURL, uploadErr := uploadPhoto(c.Request.Context(), fileBytes, uploadPath)
 			if uploadErr != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo to S3"})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
 				return
 			}
 			err = fileBytes.Close()
 			if err != nil {
 				return
 			}
-			// Construct the S3 URL for the uploaded file
-			s3URL := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", "golang-backend-photos", uploadPath)
 
 			// Create a new ReviewPhoto record
 			photo := models.ReviewPhoto{
 				ReviewID:  input.ID,
 				Name:      file.Filename,
-				Path:      s3URL,
+				Path:      photoURL,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
-			if err := db.DB.Create(&photo).Error; err != nil {
+			if err := repos.Photos.CreateReviewPhoto(&photo); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review photo"})
 				return
 			}
@@ -151,52 +147,50 @@ func validateReview(review *models.Review) error {
 
 // checkLandmarkExists checks if the landmark exists
 func checkLandmarkExists(landmarkID uint) error {
-	var landmark models.Landmark
-	if err := db.DB.First(&landmark, landmarkID).Error; err != nil {
+	if _, err := repos.Landmarks.Get(landmarkID); err != nil {
 		return fmt.Errorf("landmark ID does not exist")
 	}
 	return nil
 }
 
-func GetReviews(c *gin.Context) {
-	var reviews []models.Review
-
-	// Retrieve reviews from the database
-	if err := db.DB.Find(&reviews).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
+// respondWithReviews populates PhotoLinks of a page of reviews and writes it as the response
+func respondWithReviews(c *gin.Context, p *pagination, reviews []models.Review, total int64) {
+	reviews, next := pageOf(p, reviews, reviewIDOf)
+	if err := repos.Photos.LoadReviewPhotoLinks(reviews); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
 		return
 	}
 
-	// Populate PhotoLinks for each review
-	for i := range reviews {
-		var photos []models.ReviewPhoto
-		if err := db.DB.Where("review_id = ?", reviews[i].ID).Find(&photos).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
-			return
-		}
+	respondPage(c, reviews, next, total)
+}
 
-		var photoLinks []string
-		for _, photo := range photos {
-			photoLinks = append(photoLinks, photo.Path)
-		}
+func GetReviews(c *gin.Context) {
+	p, err := parseSortedPagination(c, repository.ReviewSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
 
-		reviews[i].Photos = nil // Clear Photos field
-		reviews[i].PhotoLinks = photoLinks
+	// Retrieve reviews from the database
+	reviews, total, err := repos.Reviews.List(p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
+		return
 	}
 
-	c.JSON(http.StatusOK, reviews)
+	respondWithReviews(c, p, reviews, total)
 }
 
 func GetReviewByID(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
 		return
 	}
 
-	var review models.Review
-	if err := db.DB.First(&review, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	review, err := repos.Reviews.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
 			return
 		}
@@ -204,110 +198,90 @@ func GetReviewByID(c *gin.Context) {
 		return
 	}
 
-	var photos []models.ReviewPhoto
-	if err := db.DB.Where("review_id = ?", review.ID).Find(&photos).Error; err != nil {
+	reviews := []models.Review{*review}
+	if err := repos.Photos.LoadReviewPhotoLinks(reviews); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
 		return
 	}
 
-	var photoLinks []string
-	for _, photo := range photos {
-		photoLinks = append(photoLinks, photo.Path)
-	}
+	c.JSON(http.StatusOK, reviews[0])
+}
 
-	review.Photos = nil // Clear Photos field
-	review.PhotoLinks = photoLinks
+// findReviewedLandmark loads the landmark named by the id path parameter for the review endpoints
+func findReviewedLandmark(c *gin.Context) (*models.Landmark, bool) {
+	landmarkID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid landmark ID"})
+		return nil, false
+	}
 
-	c.JSON(http.StatusOK, review)
+	landmark, err := repos.Landmarks.Get(landmarkID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark ID does not exist"})
+		return nil, false
+	}
+	return landmark, true
 }
 
 // GetReviewsByLandmarkID retrieves all reviews for a specific landmark based on its ID
 func GetReviewsByLandmarkID(c *gin.Context) {
-	landmarkID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	// Reviews of a landmark are listed newest first unless sorted, so cursors count rows
+	fields, err := parseSort(c, repository.ReviewSortFields)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid landmark ID"})
+		respondInvalidListing(c, err)
+		return
+	}
+	p, err := parsePagination(c, false)
+	if err != nil {
+		respondInvalidListing(c, err)
 		return
 	}
+	p.sort = fields
 
-	var landmark models.Landmark
-	if err := db.DB.First(&landmark, landmarkID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark ID does not exist"})
+	landmark, ok := findReviewedLandmark(c)
+	if !ok {
 		return
 	}
 
-	var reviews []models.Review
-	if err := db.DB.Where("landmark_id = ?", landmarkID).Find(&reviews).Error; err != nil {
+	reviews, total, err := repos.Reviews.ListByLandmark(landmark.ID, p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
 		return
 	}
 
-	for i := range reviews {
-		var photos []models.ReviewPhoto
-		if err := db.DB.Where("review_id = ?", reviews[i].ID).Find(&photos).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
-			return
-		}
-
-		var photoLinks []string
-		for _, photo := range photos {
-			photoLinks = append(photoLinks, photo.Path)
-		}
-
-		reviews[i].Photos = nil // Clear Photos field
-		reviews[i].PhotoLinks = photoLinks
-	}
-	c.JSON(http.StatusOK, reviews)
+	respondWithReviews(c, p, reviews, total)
 }
 
 // GetReviewCountByLandmarkID retrieves the count of reviews for a specific landmark based on its ID
 func GetReviewCountByLandmarkID(c *gin.Context) {
-	landmarkID, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid landmark ID"})
-		return
-	}
-
-	var landmark models.Landmark
-	if err := db.DB.First(&landmark, landmarkID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark ID does not exist"})
+	landmark, ok := findReviewedLandmark(c)
+	if !ok {
 		return
 	}
 
-	var count int64
-	if err := db.DB.Model(&models.Review{}).Where("landmark_id = ?", landmarkID).Count(&count).Error; err != nil {
+	stats, err := repos.Reviews.Stats(landmark.ID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count reviews"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"review_count": count})
+	c.JSON(http.StatusOK, gin.H{"review_count": stats.ReviewCount})
 }
 
 // GetAverageRatingByLandmarkID calculates the average rating for a specific landmark
 func GetAverageRatingByLandmarkID(c *gin.Context) {
-	landmarkID, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid landmark ID"})
-		return
-	}
-
-	var landmark models.Landmark
-	if err := db.DB.First(&landmark, landmarkID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark ID does not exist"})
+	landmark, ok := findReviewedLandmark(c)
+	if !ok {
 		return
 	}
 
-	var result struct {
-		AverageRating float64 `json:"average_rating"`
-	}
-	if err := db.DB.Model(&models.Review{}).
-		Select("AVG(rating) as average_rating").
-		Where("landmark_id = ?", landmarkID).
-		Scan(&result).Error; err != nil {
+	stats, err := repos.Reviews.Stats(landmark.ID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate average rating"})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, gin.H{"average_rating": stats.AverageRating})
 }
 
 // GetReviewsByDeviceID retrieves all reviews by a user based on their DeviceID
@@ -318,47 +292,42 @@ func GetReviewsByDeviceID(c *gin.Context) {
 		return
 	}
 
-	var reviews []models.Review
-	if err := db.DB.Where("device_id = ?", deviceID).Find(&reviews).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
+	p, err := parseSortedPagination(c, repository.ReviewSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
 		return
 	}
-	for i := range reviews {
-		var photos []models.ReviewPhoto
-		if err := db.DB.Where("review_id = ?", reviews[i].ID).Find(&photos).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for review"})
-			return
-		}
-
-		var photoLinks []string
-		for _, photo := range photos {
-			photoLinks = append(photoLinks, photo.Path)
-		}
 
-		reviews[i].Photos = nil // Clear Photos field
-		reviews[i].PhotoLinks = photoLinks
+	reviews, total, err := repos.Reviews.ListByDevice(deviceID, p.query())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
+		return
 	}
 
-	c.JSON(http.StatusOK, reviews)
+	respondWithReviews(c, p, reviews, total)
 }
 
 // UpdateReview updates a review by ID
 func UpdateReview(c *gin.Context) {
-	id := c.Param("id")
-	var review models.Review
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
 
-	if err := db.DB.First(&review, id).Error; err != nil {
+	review, err := repos.Reviews.Get(id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
 		return
 	}
 
-	if err := c.ShouldBindJSON(&review); err != nil {
+	if err := c.ShouldBindJSON(review); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON data"})
 		return
 	}
 
-	// Save updated landmark data
-	if err := db.DB.Save(&review).Error; err != nil {
+	// Save updated review data
+	if err := repos.Reviews.Update(review); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
 		return
 	}
@@ -368,15 +337,15 @@ func UpdateReview(c *gin.Context) {
 
 // DeleteReview deletes a review by ID
 func DeleteReview(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
 		return
 	}
 
-	var review models.Review
-	if err := db.DB.First(&review, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	review, err := repos.Reviews.Get(id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
 			return
 		}
@@ -384,16 +353,22 @@ func DeleteReview(c *gin.Context) {
 		return
 	}
 
-	// Delete associated review photos
-	if err := db.DB.Where("review_id = ?", review.ID).Delete(&models.ReviewPhoto{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review photos"})
+	// Delete the review together with its photo records
+	photos, err := repos.Photos.ListReviewPhotosByReview(review.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve review photos"})
 		return
 	}
-
-	if err := db.DB.Delete(&review).Error; err != nil {
+	if err := repos.Reviews.Delete(review); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
 		return
 	}
+	for _, photo := range photos {
+		if err := deletePhoto(c.Request.Context(), photo.Path); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete photo file"})
+			return
+		}
+	}
 
 	c.Status(http.StatusNoContent)
 }
@@ -406,40 +381,50 @@ func SearchReviews(c *gin.Context) {
 		return
 	}
 
-	var reviews []models.Review
-	if err := db.DB.Where("name LIKE ? OR comment LIKE ? OR CAST(rating AS CHAR) LIKE ?", "%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%").Find(&reviews).Error; err != nil {
+	p, err := parseSortedPagination(c, repository.ReviewSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+
+	reviews, total, err := repos.Reviews.Search(keyword, p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search reviews"})
 		return
 	}
 
-	c.JSON(http.StatusOK, reviews)
+	reviews, next := pageOf(p, reviews, reviewIDOf)
+	respondPage(c, reviews, next, total)
 }
 
 func FilterReviews(c *gin.Context) {
-	var reviews []models.Review
-
-	// Define the query parameters and their corresponding SQL clauses
-	params := map[string]string{
-		"min_rating": "rating >= ?",
-		"max_rating": "rating <= ?",
+	p, err := parseSortedPagination(c, repository.ReviewSortFields)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
 	}
 
-	query := db.DB
-	for param, clause := range params {
-		if value := c.Query(param); value != "" {
-			query = query.Where(clause, value)
-		}
+	var filter repository.ReviewFilter
+	if filter.MinRating, err = queryInt(c, "min_rating"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.MaxRating, err = queryInt(c, "max_rating"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := query.Find(&reviews).Error; err != nil {
+	reviews, total, err := repos.Reviews.Filter(filter, p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
 		return
 	}
 
-	if len(reviews) == 0 {
+	if total == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No review found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, reviews)
+	reviews, next := pageOf(p, reviews, reviewIDOf)
+	respondPage(c, reviews, next, total)
 }
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"landmarksmodule/models"
+)
+
+func TestReviewCRUD(t *testing.T) {
+	s := newServer(t)
+	landmark := s.landmark("Prizren Fortress", s.city().ID)
+
+	var created models.Review
+	s.expect(http.StatusCreated, http.MethodPost, "/reviewJSON", map[string]interface{}{
+		"landmark_id": landmark.ID, "device_id": "device-1", "name": "Ana", "rating": 5, "comment": "Great view",
+	}, &created)
+	if created.ID == 0 || created.LandmarkID != landmark.ID {
+		t.Fatalf("created %+v", created)
+	}
+
+	path := fmt.Sprintf("/reviews/%d", created.ID)
+	var fetched models.Review
+	s.expect(http.StatusOK, http.MethodGet, path, nil, &fetched)
+	if fetched.Comment != "Great view" || fetched.Rating != 5 {
+		t.Errorf("fetched %+v", fetched)
+	}
+
+	var updated models.Review
+	s.expect(http.StatusOK, http.MethodPut, path, map[string]interface{}{"rating": 4}, &updated)
+	if updated.Rating != 4 || updated.Comment != "Great view" {
+		t.Errorf("updated %+v, want rating 4 and the same comment", updated)
+	}
+
+	var count map[string]int64
+	s.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/landmarks/%d/review-count", landmark.ID), nil, &count)
+	if count["review_count"] != 1 {
+		t.Errorf("review count %v, want 1", count)
+	}
+
+	s.expect(http.StatusNoContent, http.MethodDelete, path, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodGet, path, nil, nil)
+	s.expect(http.StatusNotFound, http.MethodDelete, path, nil, nil)
+}
+
+func TestReviewErrors(t *testing.T) {
+	s := newServer(t)
+	landmark := s.landmark("Prizren Fortress", s.city().ID)
+	review := func(changes map[string]interface{}) map[string]interface{} {
+		body := map[string]interface{}{"landmark_id": landmark.ID, "device_id": "device-1", "name": "Ana", "rating": 5}
+		for key, value := range changes {
+			body[key] = value
+		}
+		return body
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"unknown id", http.MethodGet, "/reviews/999", nil, http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/reviews/abc", nil, http.StatusBadRequest},
+		{"update unknown id", http.MethodPut, "/reviews/999", map[string]interface{}{"rating": 3}, http.StatusNotFound},
+		{"delete invalid id", http.MethodDelete, "/reviews/abc", nil, http.StatusBadRequest},
+		{"invalid json", http.MethodPost, "/reviewJSON", "{", http.StatusBadRequest},
+		{"rating too high", http.MethodPost, "/reviewJSON", review(map[string]interface{}{"rating": 6}), http.StatusBadRequest},
+		{"no device", http.MethodPost, "/reviewJSON", review(map[string]interface{}{"device_id": ""}), http.StatusBadRequest},
+		{"unknown landmark", http.MethodPost, "/reviewJSON", review(map[string]interface{}{"landmark_id": 999}), http.StatusBadRequest},
+		{"reviews of unknown landmark", http.MethodGet, "/landmarks/999/reviews", nil, http.StatusBadRequest},
+		{"invalid limit", http.MethodGet, "/reviews?limit=abc", nil, http.StatusBadRequest},
+		{"invalid sort", http.MethodGet, "/reviews?sort=secret", nil, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := s.do(test.method, test.path, test.body)
+			if response.Code != test.status {
+				t.Errorf("status %d, want %d: %s", response.Code, test.status, response.Body)
+			}
+		})
+	}
+}
+
+func TestReviewPagination(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+	reviewed, other := s.landmark("Prizren Fortress", city.ID), s.landmark("Stone Bridge", city.ID)
+	for i := 0; i < 7; i++ {
+		review := &models.Review{LandmarkID: reviewed.ID, DeviceID: "device-1", Name: "Ana", Rating: i%5 + 1}
+		if i >= 5 {
+			review.LandmarkID, review.DeviceID = other.ID, "device-2"
+		}
+		if err := s.repos.Reviews.Create(review); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id := func(review models.Review) uint { return review.ID }
+
+	ids, pages := collect(s, "/reviews?limit=3", id)
+	if len(ids) != 7 || pages != 3 || !distinct(ids) {
+		t.Errorf("reviews: cursors gave ids %v in %d pages, want the 7 reviews in 3 pages", ids, pages)
+	}
+	ids, pages = collect(s, fmt.Sprintf("/landmarks/%d/reviews?limit=2", reviewed.ID), id)
+	if len(ids) != 5 || pages != 3 || !distinct(ids) {
+		t.Errorf("reviews of a landmark: cursors gave ids %v in %d pages, want its 5 reviews in 3 pages", ids, pages)
+	}
+	ids, _ = collect(s, "/reviews/user/device-2?limit=1", id)
+	if len(ids) != 2 || !distinct(ids) {
+		t.Errorf("reviews of a device: cursors gave ids %v, want its 2 reviews", ids)
+	}
+}
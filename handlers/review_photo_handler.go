@@ -3,11 +3,8 @@ package handlers
 import (
 	"errors"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"golang.org/x/net/context"
-	"landmarksmodule/db"
 	"landmarksmodule/models"
+	"landmarksmodule/storage"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
@@ -17,19 +14,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func init() {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+// CreateReviewPhoto handles the upload of review photos to the configured storage
+func CreateReviewPhoto(c *gin.Context) {
+	reviewID, err := parseID(c.PostForm("review_id"))
 	if err != nil {
-		panic(fmt.Sprintf("unable to load SDK config, %v", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Review with the specified review_id does not exist"})
+		return
 	}
-	s3Client = s3.NewFromConfig(cfg)
-}
-
-// CreateReviewPhoto handles the upload and storage of review photos to S3
-func CreateReviewPhoto(c *gin.Context) {
-	reviewID := c.PostForm("review_id")
-	var review models.Review
-	if err := db.DB.First(&review, reviewID).Error; err != nil {
+	review, err := repos.Reviews.Get(reviewID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Review with the specified review_id does not exist"})
 		return
 	}
@@ -52,8 +45,8 @@ func CreateReviewPhoto(c *gin.Context) {
 		}
 	}(fileBytes)
 
-	var landmark models.Landmark
-	if err := db.DB.First(&landmark, review.LandmarkID).Error; err != nil {
+	landmark, err := repos.Landmarks.Get(review.LandmarkID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Landmark associated with the review not found"})
 		return
 	}
@@ -62,26 +55,23 @@ func CreateReviewPhoto(c *gin.Context) {
 	landmarkName := strings.ReplaceAll(landmark.Name, " ", "_")
 	fileName := fmt.Sprintf("%s_%s_%d%s", deviceId, landmarkName, time.Now().UnixNano(), filepath.Ext(file.Filename))
 
-	// Upload file to S3 with directory structure
+	// Upload file to the storage with directory structure
 	uploadPath := fmt.Sprintf("%s/%s/%s", deviceId, landmarkName, fileName)
-	uploadErr := uploadFileToS3(fileBytes, uploadPath)
+	photoURL, uploadErr := uploadPhoto(c.Request.Context(), fileBytes, uploadPath)
 	if uploadErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo to S3"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
 		return
 	}
 
-	// Construct the S3 URL for the uploaded file
-	s3URL := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", "golang-backend-photos", uploadPath)
-
-	// Create a new LandmarkPhoto record
+	// Create a new ReviewPhoto record
 	photo := models.ReviewPhoto{
 		ReviewID:  review.ID,
 		Name:      file.Filename,
-		Path:      s3URL,
+		Path:      photoURL,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	if err := db.DB.Create(&photo).Error; err != nil {
+	if err := repos.Photos.CreateReviewPhoto(&photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review photo"})
 		return
 	}
@@ -90,20 +80,41 @@ func CreateReviewPhoto(c *gin.Context) {
 }
 
 func GetAllReviewPhotos(c *gin.Context) {
-	var photos []models.ReviewPhoto
-	if err := db.DB.Find(&photos).Error; err != nil {
+	p, err := parsePagination(c, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	photos, total, err := repos.Photos.ListReviewPhotos(p.query())
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve review photos"})
 		return
 	}
 
-	c.JSON(http.StatusOK, photos)
+	photos, next := pageOf(p, photos, reviewPhotoIDOf)
+	respondPage(c, photos, next, total)
 }
 
-func GetReviewPhotoByID(c *gin.Context) {
-	id := c.Param("id")
-	var photo models.ReviewPhoto
-	if err := db.DB.First(&photo, id).Error; err != nil {
+// findReviewPhoto loads the photo named by the id path parameter, responding with an error if it fails
+func findReviewPhoto(c *gin.Context) (*models.ReviewPhoto, bool) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Review photo not found"})
+		return nil, false
+	}
+
+	photo, err := repos.Photos.GetReviewPhoto(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review photo not found"})
+		return nil, false
+	}
+	return photo, true
+}
+
+func GetReviewPhotoByID(c *gin.Context) {
+	photo, ok := findReviewPhoto(c)
+	if !ok {
 		return
 	}
 
@@ -111,11 +122,8 @@ func GetReviewPhotoByID(c *gin.Context) {
 }
 
 func UpdateReviewPhoto(c *gin.Context) {
-	id := c.Param("id")
-	var photo models.ReviewPhoto
-
-	if err := db.DB.First(&photo, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Review photo not found"})
+	photo, ok := findReviewPhoto(c)
+	if !ok {
 		return
 	}
 
@@ -134,11 +142,14 @@ func UpdateReviewPhoto(c *gin.Context) {
 			return
 		}
 
-		// Upload the new file to S3 with the same path
-		uploadPath := strings.TrimPrefix(photo.Path, fmt.Sprintf("https://%s.s3.amazonaws.com/", "golang-backend-photos"))
-		uploadErr := uploadFileToS3(fileBytes, uploadPath)
+		// Upload the new file to the storage with the same path
+		uploadPath, ok := storage.KeyFromURL(storage.Store, photo.Path)
+		if !ok {
+			uploadPath = fmt.Sprintf("reviews/%d/%d%s", photo.ReviewID, time.Now().UnixNano(), filepath.Ext(file.Filename))
+		}
+		photoURL, uploadErr := uploadPhoto(c.Request.Context(), fileBytes, uploadPath)
 		if uploadErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo to S3"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload photo"})
 			return
 		}
 		err = fileBytes.Close()
@@ -146,10 +157,11 @@ func UpdateReviewPhoto(c *gin.Context) {
 			return
 		}
 
+		photo.Path = photoURL
 		photo.UpdatedAt = time.Now()
 	}
 
-	if err := db.DB.Save(&photo).Error; err != nil {
+	if err := repos.Photos.UpdateReviewPhoto(photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review photo"})
 		return
 	}
@@ -158,25 +170,32 @@ func UpdateReviewPhoto(c *gin.Context) {
 }
 
 func DeleteReviewPhoto(c *gin.Context) {
-	id := c.Param("id")
-	var photo models.ReviewPhoto
-
-	if err := db.DB.First(&photo, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Review photo not found"})
+	photo, ok := findReviewPhoto(c)
+	if !ok {
 		return
 	}
 
-	if err := db.DB.Delete(&photo).Error; err != nil {
+	if err := repos.Photos.DeleteReviewPhoto(photo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review photo"})
 		return
 	}
+
+	// Delete the file from the storage
+	if err := deletePhoto(c.Request.Context(), photo.Path); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete photo file"})
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
 func GetReviewPhotosByReviewID(c *gin.Context) {
-	reviewID := c.Param("id")
+	reviewID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No photos found for the specified review"})
+		return
+	}
 
-	var photos []models.ReviewPhoto
-	if err := db.DB.Where("review_id = ?", reviewID).Find(&photos).Error; err != nil {
+	photos, err := repos.Photos.ListReviewPhotosByReview(reviewID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve review photos"})
 		return
 	}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/search"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Defaults and bounds of the limit query parameter of Autocomplete
+const (
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+)
+
+// searchIndex keeps a full-text index of the landmarks, cities and regions.
+// The index is dropped whenever one of them changes and rebuilt on the next search.
+type searchIndex struct {
+	mu    sync.Mutex
+	index *search.Index
+}
+
+var textSearch searchIndex
+
+// invalidate drops the index after a change of the landmarks, cities or regions
+func (s *searchIndex) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = nil
+}
+
+// current returns the index, building it first if needed
+func (s *searchIndex) current() (*search.Index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil {
+		index, err := buildSearchIndex()
+		if err != nil {
+			return nil, err
+		}
+		s.index = index
+	}
+	return s.index, nil
+}
+
+// translatedWeights weighs the translated fields like the stored ones
+var translatedWeights = map[string]float64{"name": 3, "information": 1, "description": 1}
+
+// buildSearchIndex indexes the names of the landmarks, cities and regions, and the type,
+// description and information of the landmarks, along with their translations
+func buildSearchIndex() (*search.Index, error) {
+	regions, _, err := repos.Regions.List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	cities, _, err := repos.Cities.List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	landmarks, _, err := repos.Landmarks.List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	translations := map[string]map[uint][]models.Translation{}
+	for _, entity := range []string{models.EntityRegion, models.EntityCity, models.EntityLandmark} {
+		if translations[entity], err = translationsByEntity(entity); err != nil {
+			return nil, err
+		}
+	}
+
+	index := search.NewIndex()
+	for _, region := range regions {
+		index.Add(translatedDocument(search.Document{
+			Kind:     search.Region,
+			ID:       region.ID,
+			Name:     region.Name,
+			Fields:   []search.Field{{Name: "name", Text: region.Name, Weight: 3}},
+			RegionID: region.ID,
+		}, translations[models.EntityRegion][region.ID]))
+	}
+	cityRegions := map[uint]uint{}
+	for _, city := range cities {
+		cityRegions[city.ID] = city.RegionID
+		index.Add(translatedDocument(search.Document{
+			Kind:     search.City,
+			ID:       city.ID,
+			Name:     city.Name,
+			Fields:   []search.Field{{Name: "name", Text: city.Name, Weight: 3}},
+			RegionID: city.RegionID,
+		}, translations[models.EntityCity][city.ID]))
+	}
+	for _, landmark := range landmarks {
+		document := landmarkDocument(landmark, cityRegions[landmark.CityID])
+		index.Add(translatedDocument(document, translations[models.EntityLandmark][landmark.ID]))
+	}
+	return index, nil
+}
+
+// translationsByEntity returns the translations of every entity of a type, keyed by entity id
+func translationsByEntity(entity string) (map[uint][]models.Translation, error) {
+	translations, err := repos.Translations.ListByEntities(entity, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	byEntity := map[uint][]models.Translation{}
+	for _, translation := range translations {
+		byEntity[translation.EntityID] = append(byEntity[translation.EntityID], translation)
+	}
+	return byEntity, nil
+}
+
+// translatedDocument adds the translations of its entity to a document, as fields named after the
+// translated field and the locale, as in name_de, and the translated names as aliases
+func translatedDocument(document search.Document, translations []models.Translation) search.Document {
+	for _, translation := range translations {
+		document.Fields = append(document.Fields, search.Field{
+			Name:   translation.Field + "_" + translation.Locale,
+			Text:   translation.Text,
+			Weight: translatedWeights[translation.Field],
+		})
+		if translation.Field == "name" {
+			document.Aliases = append(document.Aliases, translation.Text)
+		}
+	}
+	return document
+}
+
+// landmarkDocument returns the indexed document of a landmark, its name weighing the most
+func landmarkDocument(landmark models.Landmark, regionID uint) search.Document {
+	return search.Document{
+		Kind: search.Landmark,
+		ID:   landmark.ID,
+		Name: landmark.Name,
+		Fields: []search.Field{
+			{Name: "name", Text: landmark.Name, Weight: 3},
+			{Name: "type", Text: landmark.Type, Weight: 2},
+			{Name: "description", Text: landmark.Description, Weight: 1},
+			{Name: "information", Text: landmark.Information, Weight: 1},
+		},
+		Type:     landmark.Type,
+		RegionID: regionID,
+	}
+}
+
+// parseKinds reads the kind query parameter, a comma separated list of landmark, city and region
+func parseKinds(c *gin.Context) ([]search.Kind, error) {
+	value := c.Query("kind")
+	if value == "" {
+		return nil, nil
+	}
+	var kinds []search.Kind
+	for _, kind := range strings.Split(value, ",") {
+		switch kind := search.Kind(strings.TrimSpace(kind)); kind {
+		case search.Landmark, search.City, search.Region:
+			kinds = append(kinds, kind)
+		default:
+			return nil, errors.New("kind must be landmark, city or region")
+		}
+	}
+	return kinds, nil
+}
+
+// Search ranks the landmarks, cities and regions matching every word of the q parameter, ignoring
+// case, diacritics and typos. The kind parameter restricts the results to a comma separated list of
+// landmark, city and region, type to the landmarks of a type and region_id to the entities of a region.
+func Search(c *gin.Context) {
+	query := search.Query{Text: strings.TrimSpace(c.Query("q"))}
+	if query.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
+		return
+	}
+
+	var err error
+	if query.Kinds, err = parseKinds(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kind query parameter", "details": err.Error()})
+		return
+	}
+	query.Type = c.Query("type")
+	if value := c.Query("region_id"); value != "" {
+		id, err := parseID(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid region_id query parameter"})
+			return
+		}
+		query.RegionID = id
+	}
+
+	p, err := parsePagination(c, false)
+	if err != nil {
+		respondInvalidListing(c, err)
+		return
+	}
+	query.Offset, query.Limit = p.offset, p.limit+1
+
+	index, err := textSearch.current()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build the search index"})
+		return
+	}
+	hits, total := index.Search(query)
+
+	hits, next := pageOf(p, hits, func(hit *search.Hit) uint { return hit.ID })
+	respondPage(c, hits, next, int64(total))
+}
+
+// Autocomplete suggests the landmarks, cities and regions whose name completes the q parameter,
+// restricted by the kind parameter like Search, up to limit of them
+func Autocomplete(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q query parameter is required"})
+		return
+	}
+	kinds, err := parseKinds(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kind query parameter", "details": err.Error()})
+		return
+	}
+	limit := defaultAutocompleteLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxAutocompleteLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxAutocompleteLimit)})
+			return
+		}
+	}
+
+	index, err := textSearch.current()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build the search index"})
+		return
+	}
+	suggestions := index.Suggest(text, kinds, limit)
+	if !localize(c, suggestions) {
+		return
+	}
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// matchingIDs returns the ids of the entities of a kind matching the text in the search index, for
+// the listings to fall back on when the text is not found as it is typed
+func matchingIDs(text string, kind search.Kind) ([]uint, error) {
+	index, err := textSearch.current()
+	if err != nil {
+		return nil, err
+	}
+	hits, _ := index.Search(search.Query{Text: text, Kinds: []search.Kind{kind}})
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
+
+// rankedPage orders rows like ids, the ranking of the search index, and returns the window of the
+// pagination with one row more than the limit, like pagination.query. The next cursor counts rows
+// since the ids are not in order, and a cursor after an id continues after its rank.
+func rankedPage[T any](p *pagination, rows []T, ids []uint, id func(row *T) uint) []T {
+	rank := make(map[uint]int, len(ids))
+	for i, matched := range ids {
+		rank[matched] = i
+	}
+	sort.Slice(rows, func(i, j int) bool { return rank[id(&rows[i])] < rank[id(&rows[j])] })
+
+	if p.afterID != 0 {
+		for i := range rows {
+			if id(&rows[i]) == p.afterID {
+				p.offset = i + 1
+			}
+		}
+	}
+	p.keyset = false
+	if p.offset >= len(rows) {
+		return nil
+	}
+	rows = rows[p.offset:]
+	if len(rows) > p.limit+1 {
+		rows = rows[:p.limit+1]
+	}
+	return rows
+}
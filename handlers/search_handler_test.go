@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"landmarksmodule/models"
+	"landmarksmodule/search"
+)
+
+func TestSearchLandmarksFallbackKeepsRanking(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+	// Created from the least to the most relevant to "Przren", which is too misspelled for the keyword
+	for _, landmark := range []models.Landmark{
+		{Name: "Ura e Gurit", Description: "Ottoman stone bridge over the Lumbardhi river in the old town of Prizren"},
+		{Name: "Hamam", Description: "Baths of Prizren"},
+		{Name: "Kalaja, the fortress of Prizren"},
+		{Name: "Prizren Fortress"},
+	} {
+		landmark.CityID = city.ID
+		if err := s.repos.Landmarks.Create(&landmark); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []uint{4, 3, 2, 1}
+
+	ids, pages := collect(s, "/landmarks/search?keyword=Przren&limit=1", func(l models.Landmark) uint { return l.ID })
+	if !reflect.DeepEqual(ids, want) || pages != 4 {
+		t.Errorf("landmarks %v in %d pages, want %v in 4 pages", ids, pages, want)
+	}
+	ids, _ = collect(s, "/landmarks/search?keyword=Przren&page=2&limit=3", func(l models.Landmark) uint { return l.ID })
+	if !reflect.DeepEqual(ids, want[3:]) {
+		t.Errorf("second page %v, want %v", ids, want[3:])
+	}
+	// A requested order is kept
+	ids, _ = collect(s, "/landmarks/search?keyword=Przren&sort=name", func(l models.Landmark) uint { return l.ID })
+	if !reflect.DeepEqual(ids, []uint{2, 3, 4, 1}) {
+		t.Errorf("landmarks sorted by name %v, want [2 3 4 1]", ids)
+	}
+}
+
+func TestAutocomplete(t *testing.T) {
+	s := newServer(t)
+	city := s.city()
+	for i := 1; i <= 60; i++ {
+		s.landmark(fmt.Sprintf("Prizren landmark %d", i), city.ID)
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"q=prizr", 10},
+		{"q=prizr&limit=1", 1},
+		{"q=prizr&limit=50", 50},
+		{"q=PRIZR%C3%8B&kind=city,region", 2},
+		{"q=prizr&kind=city", 1},
+		{"q=landmark%2042", 1},
+		{"q=nowhere", 0},
+	}
+	for _, test := range tests {
+		var suggestions []search.Suggestion
+		s.expect(http.StatusOK, http.MethodGet, "/autocomplete?"+test.query, nil, &suggestions)
+		if len(suggestions) != test.want {
+			t.Errorf("%s: %d suggestions, want %d", test.query, len(suggestions), test.want)
+		}
+	}
+
+	// The city and the region named Prizren are shorter than the landmarks
+	var suggestions []search.Suggestion
+	s.expect(http.StatusOK, http.MethodGet, "/autocomplete?q=prizr&limit=3", nil, &suggestions)
+	if len(suggestions) != 3 || suggestions[0].Kind == search.Landmark || suggestions[1].Kind == search.Landmark || suggestions[2].Kind != search.Landmark {
+		t.Errorf("suggestions %+v, want the city and the region first", suggestions)
+	}
+
+	for _, query := range []string{"", "q=%20", "q=prizr&limit=0", "q=prizr&limit=-1", "q=prizr&limit=51", "q=prizr&limit=ten", "q=prizr&kind=country"} {
+		s.expect(http.StatusBadRequest, http.MethodGet, "/autocomplete?"+query, nil, nil)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/repository"
+	"net/http"
+	"strings"
+)
+
+// sortError reports a field of the sort query parameter which the listing cannot be sorted on
+type sortError struct {
+	field   string
+	allowed []string
+}
+
+func (e *sortError) Error() string {
+	return fmt.Sprintf("cannot sort by %q", e.field)
+}
+
+// parseSort reads the sort query parameter, a comma separated list of fields each
+// prefixed with - for descending order, e.g. sort=-average_rating,name
+func parseSort(c *gin.Context, allowed []string) ([]repository.SortField, error) {
+	value := c.Query("sort")
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []repository.SortField
+	for _, name := range strings.Split(value, ",") {
+		field := repository.SortField{Field: strings.TrimSpace(name)}
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field = field.Field[1:]
+			field.Descending = true
+		}
+
+		known := false
+		for _, a := range allowed {
+			known = known || a == field.Field
+		}
+		if !known {
+			return nil, &sortError{field: field.Field, allowed: allowed}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseSortedPagination reads the pagination and sort query parameters of a listing ordered by id
+// by default. Sorted listings are paginated by offset since their order does not follow the ids.
+func parseSortedPagination(c *gin.Context, allowed []string) (*pagination, error) {
+	fields, err := parseSort(c, allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := parsePagination(c, len(fields) == 0)
+	if err != nil {
+		return nil, err
+	}
+	p.sort = fields
+	return p, nil
+}
+
+// respondInvalidListing writes the 400 response of an invalid pagination or sort query parameter
+func respondInvalidListing(c *gin.Context, err error) {
+	var sortErr *sortError
+	if errors.As(err, &sortErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "Invalid sort field",
+			"field":          sortErr.field,
+			"allowed_fields": sortErr.allowed,
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/storage"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// uploadPhoto stores the file under the given key and returns the URL it is reachable from. The upload
+// is abandoned when ctx, the context of the request, is cancelled.
+func uploadPhoto(ctx context.Context, file multipart.File, key string) (string, error) {
+	buffer := new(bytes.Buffer)
+	if _, err := buffer.ReadFrom(file); err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	fileBytes := buffer.Bytes()
+	fileType := http.DetectContentType(fileBytes)
+
+	if err := storage.Store.Put(ctx, key, bytes.NewReader(fileBytes), fileType); err != nil {
+		// Log the error for debugging purposes
+		log.Printf("Failed to upload file %s: %v", key, err)
+		return "", err
+	}
+
+	return storage.Store.URL(key), nil
+}
+
+// deletePhoto removes the stored file behind a photo URL.
+// URLs which do not belong to the configured backend are left untouched.
+func deletePhoto(ctx context.Context, url string) error {
+	key, ok := storage.KeyFromURL(storage.Store, url)
+	if !ok {
+		return nil
+	}
+	if err := storage.Store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Failed to delete file %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+// ServeStoredFile streams a stored photo back to the client
+func ServeStoredFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	file, err := storage.Store.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Defaults and bounds of the query parameters of GetSuggestedLandmarks
+const (
+	defaultSuggestionRadiusKm = 20.0
+	maxSuggestionRadiusKm     = 500.0
+	defaultSuggestionLimit    = 20
+	maxSuggestionLimit        = 100
+	defaultDistanceWeight     = 0.5
+	defaultRatingWeight       = 0.5
+	defaultPriorWeight        = 5.0
+	// neutralRating is the prior rating used while there are no reviews at all
+	neutralRating = 3.0
+)
+
+// suggestion is a suggested landmark with the details its score is computed from
+type suggestion struct {
+	repository.NearbyLandmark
+	Score float64 `json:"score"`
+}
+
+// scoreWeights tune suggestionScore
+type scoreWeights struct {
+	distance float64
+	rating   float64
+	// prior is the number of reviews of the prior rating blended into every landmark's rating
+	prior float64
+}
+
+// suggestionScore blends proximity with a Bayesian-smoothed rating, both scaled to [0, 1]:
+//
+//	proximity = 1 - distance / radius
+//	smoothed  = (prior * priorRating + reviewCount * averageRating) / (prior + reviewCount)
+//	rating    = (smoothed - 1) / 4
+//	score     = (weights.distance * proximity + weights.rating * rating) / (weights.distance + weights.rating)
+//
+// priorRating is the average rating of every review, so the rating of a landmark with few reviews
+// stays close to it and a single five star review cannot outrank a consistently well rated landmark.
+func suggestionScore(landmark repository.NearbyLandmark, radius, priorRating float64, weights scoreWeights) float64 {
+	proximity := 1 - landmark.Distance/radius
+	if proximity < 0 {
+		proximity = 0
+	}
+
+	smoothed := priorRating
+	reviews := float64(landmark.ReviewCount)
+	if weights.prior+reviews > 0 {
+		smoothed = (weights.prior*priorRating + reviews*landmark.AverageRating) / (weights.prior + reviews)
+	}
+	rating := (smoothed - 1) / 4
+
+	return (weights.distance*proximity + weights.rating*rating) / (weights.distance + weights.rating)
+}
+
+// queryNonNegative parses an optional non-negative decimal query parameter, returning fallback when it is absent
+func queryNonNegative(c *gin.Context, name string, fallback float64) (float64, error) {
+	value, err := queryFloat(c, name)
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return fallback, nil
+	}
+	if *value < 0 {
+		return 0, fmt.Errorf("%s query parameter must not be negative", name)
+	}
+	return *value, nil
+}
+
+// GetSuggestedLandmarks returns the best landmarks around the user, ranked by suggestionScore.
+// The radius, limit, type, distance_weight, rating_weight and prior_weight query parameters
+// tune the suggestions.
+func GetSuggestedLandmarks(c *gin.Context) {
+	// Parse user location from query parameters
+	latitudeStr := c.Query("latitude")
+	longitudeStr := c.Query("longitude")
+	if latitudeStr == "" || longitudeStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User location (latitude and longitude) must be provided"})
+		return
+	}
+
+	latitude, err := strconv.ParseFloat(latitudeStr, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid latitude value"})
+		return
+	}
+
+	longitude, err := strconv.ParseFloat(longitudeStr, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid longitude value"})
+		return
+	}
+	if !validCoordinates(c, latitude, longitude) {
+		return
+	}
+
+	query := repository.NearbyQuery{Latitude: latitude, Longitude: longitude, Type: c.Query("type")}
+	if query.RadiusKm, err = queryNonNegative(c, "radius", defaultSuggestionRadiusKm); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.RadiusKm == 0 || query.RadiusKm > maxSuggestionRadiusKm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius must be greater than 0 and at most %g km", maxSuggestionRadiusKm)})
+		return
+	}
+
+	limit := defaultSuggestionLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxSuggestionLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxSuggestionLimit)})
+			return
+		}
+	}
+
+	var weights scoreWeights
+	for _, param := range []struct {
+		name     string
+		fallback float64
+		value    *float64
+	}{
+		{"distance_weight", defaultDistanceWeight, &weights.distance},
+		{"rating_weight", defaultRatingWeight, &weights.rating},
+		{"prior_weight", defaultPriorWeight, &weights.prior},
+	} {
+		if *param.value, err = queryNonNegative(c, param.name, param.fallback); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if weights.distance+weights.rating == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "distance_weight and rating_weight cannot both be 0"})
+		return
+	}
+
+	// Retrieve the landmarks within the radius along with their review statistics
+	nearby, err := repos.Landmarks.Nearby(query)
+	if err != nil {
+		log.Println("Failed to retrieve suggested landmarks:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve suggested landmarks"})
+		return
+	}
+
+	overall, err := repos.Reviews.OverallStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve review statistics"})
+		return
+	}
+	priorRating := overall.AverageRating
+	if overall.ReviewCount == 0 {
+		priorRating = neutralRating
+	}
+
+	// Rank the landmarks, the closest first among equal scores
+	suggestions := make([]suggestion, len(nearby))
+	for i, landmark := range nearby {
+		suggestions[i] = suggestion{NearbyLandmark: landmark, Score: suggestionScore(landmark, query.RadiusKm, priorRating, weights)}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Score > suggestions[j].Score })
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	// Populate PhotoLinks for each landmark
+	landmarks := make([]models.Landmark, len(suggestions))
+	for i := range suggestions {
+		landmarks[i] = suggestions[i].Landmark
+	}
+	if err := repos.Photos.LoadLandmarkPhotoLinks(landmarks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos for landmark"})
+		return
+	}
+	if !localize(c, landmarks) {
+		return
+	}
+	for i := range suggestions {
+		suggestions[i].Landmark = landmarks[i]
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/geo"
+	"landmarksmodule/repository"
+	"landmarksmodule/tiles"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxTilePoints is the number of landmarks of a tile above which they are clustered
+const maxTilePoints = 2000
+
+// Names of the layers of the vector tiles
+const (
+	regionsLayer   = "regions"
+	landmarksLayer = "landmarks"
+)
+
+// GetTile serves the Mapbox Vector Tile z/x/y.mvt with a regions layer holding the region
+// boundaries, simplified for the zoom level, and a landmarks layer holding the landmarks as
+// points, or as clusters with a count when the tile is crowded
+func GetTile(c *gin.Context) {
+	y, ok := strings.CutSuffix(c.Param("y"), ".mvt")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tiles are only served in the .mvt format"})
+		return
+	}
+
+	z, err1 := strconv.Atoi(c.Param("z"))
+	x, err2 := strconv.Atoi(c.Param("x"))
+	row, err3 := strconv.Atoi(y)
+	if err1 != nil || err2 != nil || err3 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tile coordinates"})
+		return
+	}
+	tile, err := tiles.NewTile(z, x, row)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tile coordinates", "details": err.Error()})
+		return
+	}
+
+	// Tiles are rendered once for every list of locales their names are translated in
+	locales := requestLocales(c)
+	data, err := tiles.Render(tile, strings.Join(locales, ","), func() ([]byte, error) { return renderTile(tile, locales) })
+	if err != nil {
+		log.Printf("Failed to render tile %s: %v", tile, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render tile"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/vnd.mapbox-vector-tile", data)
+}
+
+// renderTile encodes the regions and landmarks covering the tile, their names in the given locales
+func renderTile(tile tiles.Tile, locales []string) ([]byte, error) {
+	regions, err := regionFeatures(tile, locales)
+	if err != nil {
+		return nil, err
+	}
+	landmarks, err := landmarkFeatures(tile, locales)
+	if err != nil {
+		return nil, err
+	}
+
+	return tiles.Encode([]tiles.Layer{
+		{Name: regionsLayer, Features: regions},
+		{Name: landmarksLayer, Features: landmarks},
+	}), nil
+}
+
+// regionFeatures returns the boundaries of the regions overlapping the tile
+func regionFeatures(tile tiles.Tile, locales []string) ([]tiles.Feature, error) {
+	index, err := locator.current()
+	if err != nil {
+		return nil, err
+	}
+	// Boundaries of deleted regions are skipped since their region is not found
+	regions, err := repos.Regions.ListByIDs(index.Intersecting(tile.BBox()))
+	if err != nil {
+		return nil, err
+	}
+	if err := localizeRegions(pointers(regions), locales); err != nil {
+		return nil, err
+	}
+
+	var features []tiles.Feature
+	for _, region := range regions {
+		polygons := tile.Polygons(index.Shape(region.ID))
+		if len(polygons) == 0 {
+			continue
+		}
+		features = append(features, tiles.Feature{
+			ID:       uint64(region.ID),
+			Type:     tiles.PolygonGeometry,
+			Polygons: polygons,
+			Properties: map[string]interface{}{
+				"name":       region.Name,
+				"country_id": region.CountryID,
+			},
+		})
+	}
+	return features, nil
+}
+
+// landmarkFeatures returns the landmarks of the tile, clustered like GetLandmarkMap does when there are too many
+func landmarkFeatures(tile tiles.Tile, locales []string) ([]tiles.Feature, error) {
+	query := repository.MapQuery{Box: tile.BBox()}
+	landmarks, total, err := repos.Landmarks.InBox(query, maxTilePoints)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []tiles.Feature
+	if total <= maxTilePoints {
+		if err := localizeLandmarks(pointers(landmarks), locales); err != nil {
+			return nil, err
+		}
+		for _, landmark := range landmarks {
+			position, ok := tile.Point(geo.Point{Lng: landmark.Longitude, Lat: landmark.Latitude})
+			if !ok {
+				continue
+			}
+			features = append(features, tiles.Feature{
+				ID:     uint64(landmark.ID),
+				Type:   tiles.PointGeometry,
+				Points: []tiles.Position{position},
+				Properties: map[string]interface{}{
+					"name": landmark.Name,
+					"type": landmark.Type,
+				},
+			})
+		}
+		return features, nil
+	}
+
+	clusters, err := repos.Landmarks.Clusters(query, clusterCellSize(tile.Z))
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		position, ok := tile.Point(geo.Point{Lng: cluster.Longitude, Lat: cluster.Latitude})
+		if !ok {
+			continue
+		}
+		properties := map[string]interface{}{"cluster": true, "count": cluster.Count}
+		if cluster.Count == 1 {
+			properties["landmark_id"] = cluster.LandmarkID
+		}
+		features = append(features, tiles.Feature{
+			Type:       tiles.PointGeometry,
+			Points:     []tiles.Position{position},
+			Properties: properties,
+		})
+	}
+	return features, nil
+}
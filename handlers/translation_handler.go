@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"landmarksmodule/locale"
+	"landmarksmodule/models"
+	"landmarksmodule/tiles"
+	"net/http"
+	"strings"
+)
+
+// translatedEntities finds the entity named by the id path parameter of the translation endpoints
+// and returns its id, responding with an error if it fails
+var translatedEntities = map[string]func(c *gin.Context) (uint, bool){
+	models.EntityLandmark: entityID(findLandmark, landmarkIDOf),
+	models.EntityCity:     entityID(findCity, cityIDOf),
+	models.EntityRegion:   entityID(findRegion, regionIDOf),
+	models.EntityCountry:  entityID(findCountry, countryIDOf),
+}
+
+// entityID adapts the find function of an entity to translatedEntities
+func entityID[T any](find func(c *gin.Context) (*T, bool), id func(row *T) uint) func(c *gin.Context) (uint, bool) {
+	return func(c *gin.Context) (uint, bool) {
+		row, ok := find(c)
+		if !ok {
+			return 0, false
+		}
+		return id(row), true
+	}
+}
+
+// findTranslatedLocale loads the entity of a translation endpoint and reads its locale path
+// parameter, responding with an error if either fails
+func findTranslatedLocale(c *gin.Context, entity string) (uint, string, bool) {
+	id, ok := translatedEntities[entity](c)
+	if !ok {
+		return 0, "", false
+	}
+	localeParam := c.Param("locale")
+	if !locale.Supports(localeParam) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unsupported locale", "details": fmt.Sprintf("locale must be one of %s", strings.Join(locale.Supported, ", "))})
+		return 0, "", false
+	}
+	return id, localeParam, true
+}
+
+// GetTranslations lists the translations of an entity as the texts of its fields keyed by locale,
+// as in {"de": {"name": "Prizren"}}
+func GetTranslations(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := translatedEntities[entity](c)
+		if !ok {
+			return
+		}
+		translations, err := repos.Translations.ListByEntity(entity, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve translations"})
+			return
+		}
+
+		locales := map[string]map[string]string{}
+		for _, translation := range translations {
+			if locales[translation.Locale] == nil {
+				locales[translation.Locale] = map[string]string{}
+			}
+			locales[translation.Locale][translation.Field] = translation.Text
+		}
+		c.JSON(http.StatusOK, locales)
+	}
+}
+
+// GetTranslation returns the texts of the fields of an entity in the locale path parameter, keyed by field
+func GetTranslation(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, localeParam, ok := findTranslatedLocale(c, entity)
+		if !ok {
+			return
+		}
+		translations, err := repos.Translations.ListByEntities(entity, []uint{id}, []string{localeParam})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve translations"})
+			return
+		}
+		if len(translations) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+			return
+		}
+
+		texts := map[string]string{}
+		for _, translation := range translations {
+			texts[translation.Field] = translation.Text
+		}
+		c.JSON(http.StatusOK, texts)
+	}
+}
+
+// SetTranslation replaces the translation of an entity in the locale path parameter by the texts
+// of the request body keyed by field, as in {"name": "Prizren"}. Only the fields listed in
+// models.TranslatableFields are accepted, and the empty ones are left out so that they fall back.
+func SetTranslation(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, localeParam, ok := findTranslatedLocale(c, entity)
+		if !ok {
+			return
+		}
+
+		var input map[string]string
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translation data", "details": "the body must map field names to texts"})
+			return
+		}
+		allowed := map[string]bool{}
+		for _, field := range models.TranslatableFields[entity] {
+			allowed[field] = true
+		}
+		texts := map[string]string{}
+		for field, text := range input {
+			if !allowed[field] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translation data", "details": fmt.Sprintf("%q is not a translated field, expected one of %s", field, strings.Join(models.TranslatableFields[entity], ", "))})
+				return
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				texts[field] = text
+			}
+		}
+		if len(texts) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translation data", "details": "at least one field must be translated"})
+			return
+		}
+
+		if err := repos.Translations.SetLocale(entity, id, localeParam, texts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save translation"})
+			return
+		}
+		tiles.Invalidate()
+		textSearch.invalidate()
+
+		c.JSON(http.StatusOK, texts)
+	}
+}
+
+// DeleteTranslation deletes the translation of an entity in the locale path parameter
+func DeleteTranslation(entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, localeParam, ok := findTranslatedLocale(c, entity)
+		if !ok {
+			return
+		}
+
+		deleted, err := repos.Translations.DeleteLocale(entity, id, localeParam)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete translation"})
+			return
+		}
+		if deleted == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Translation not found"})
+			return
+		}
+		tiles.Invalidate()
+		textSearch.invalidate()
+
+		c.Status(http.StatusNoContent)
+	}
+}
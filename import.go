@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"landmarksmodule/config"
+	"landmarksmodule/handlers"
+	"landmarksmodule/osm"
+	"landmarksmodule/repository"
+	"log"
+	"os"
+	"strings"
+)
+
+// importLandmarks runs the import-landmarks command, which imports a CSV file of landmarks like
+// POST /landmarks/import and prints the report as JSON. It returns the exit status of the command,
+// 1 when a row has an error and 2 when the command fails. The command runs in its own process, so a
+// running server keeps its cached tiles and search index until it is restarted.
+func importLandmarks(args []string) int {
+	flags := flag.NewFlagSet("import-landmarks", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "check the file without creating any landmark")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: landmarksmodule import-landmarks [-dry-run] FILE.csv")
+		fmt.Fprintln(flags.Output(), "Restart a running server afterwards to refresh its tiles and search index.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Printf("Failed to open CSV file: %v", err)
+		return 2
+	}
+	defer file.Close()
+
+	report, err := handlers.ImportLandmarksCSV(file, *dryRun)
+	if err != nil {
+		log.Printf("Failed to import landmarks: %v", err)
+		return 2
+	}
+
+	if !printReport(report) {
+		return 2
+	}
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// tagRules collects the repeated -rule flags of import-osm
+type tagRules []string
+
+func (r *tagRules) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *tagRules) Set(rule string) error {
+	*r = append(*r, rule)
+	return nil
+}
+
+// importOSM runs the import-osm command, which imports the features of an OpenStreetMap extract as
+// landmarks and prints the report as JSON. The features are selected by the -rule flags, or by the
+// tag rules of the configuration when none is given. It returns the exit status of the command.
+// Like import-landmarks, it leaves the caches of a running server as they are, which must be
+// restarted to draw the imported landmarks on its tiles and find them with its search.
+func importOSM(args []string, repos repository.Repositories, cfg config.OSMConfig) int {
+	flags := flag.NewFlagSet("import-osm", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "read the extract and place its features without storing anything")
+	maxDistance := flags.Float64("max-city-distance", cfg.MaxCityDistanceKm, "leave out the features farther in km from every city, 0 disables the limit")
+	createTypes := flags.Bool("create-types", false, "add the landmark types missing from the taxonomy instead of skipping their features")
+	var rules tagRules
+	flags.Var(&rules, "rule", "select the features with a tag, key=value or key=* for any value, may be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: landmarksmodule import-osm [-dry-run] [-create-types] [-rule key=value]... FILE.osm.pbf|FILE.osm")
+		fmt.Fprintln(flags.Output(), "Restart a running server afterwards to refresh its tiles and search index.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if len(rules) == 0 {
+		rules = cfg.TagRules
+	}
+
+	parsed, err := osm.ParseRules(rules)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	report, err := osm.Import(repos, flags.Arg(0), osm.Options{
+		Rules:             parsed,
+		MaxCityDistanceKm: *maxDistance,
+		DryRun:            *dryRun,
+		TypeMap:           cfg.TypeMap,
+		CreateTypes:       *createTypes,
+	})
+	if err != nil {
+		log.Printf("Failed to import OpenStreetMap extract: %v", err)
+		return 2
+	}
+
+	if !printReport(report) {
+		return 2
+	}
+	return 0
+}
+
+// printReport writes the report of a command to the standard output as indented JSON
+func printReport(report interface{}) bool {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("Failed to write the report: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+// Package locale negotiates the language of the responses among the locales of the translations
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported are the locales of the translations: Albanian, English, German and Serbian
+var Supported = []string{"sq", "en", "de", "sr"}
+
+// Supports reports whether the locale is one of Supported, as it is written there
+func Supports(locale string) bool {
+	for _, supported := range Supported {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the supported locale of a language tag, comparing its primary subtag without case,
+// so that de-AT and DE both match de. ok is false when the language is not supported.
+func Match(tag string) (string, bool) {
+	language, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	language = strings.ToLower(language)
+	for _, locale := range Supported {
+		if locale == language {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// weightedTag is a language of an Accept-Language header with its quality
+type weightedTag struct {
+	tag     string
+	quality float64
+}
+
+// Parse reads an Accept-Language header and returns the supported locales it names, the most
+// preferred first. The languages with a quality of 0, the * wildcard and the entries that cannot
+// be parsed are skipped.
+func Parse(header string) []string {
+	var tags []weightedTag
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		weighted := weightedTag{tag: strings.TrimSpace(tag), quality: 1}
+		if weighted.tag == "" || weighted.tag == "*" {
+			continue
+		}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			quality, err := strconv.ParseFloat(value, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+			weighted.quality = quality
+		}
+		if weighted.quality > 0 {
+			tags = append(tags, weighted)
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	var locales []string
+	for _, weighted := range tags {
+		if locale, ok := Match(weighted.tag); ok {
+			locales = appendLocale(locales, locale)
+		}
+	}
+	return locales
+}
+
+// Negotiate returns the locales of a request, the most preferred first: those of lang, a comma
+// separated list of language tags, then those of the Accept-Language header. The error names a
+// language of lang which is not supported.
+func Negotiate(lang, acceptLanguage string) ([]string, error) {
+	var locales []string
+	for _, tag := range strings.Split(lang, ",") {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		locale, ok := Match(tag)
+		if !ok {
+			return nil, fmt.Errorf("%q is not one of the supported locales %s", strings.TrimSpace(tag), strings.Join(Supported, ", "))
+		}
+		locales = appendLocale(locales, locale)
+	}
+	for _, locale := range Parse(acceptLanguage) {
+		locales = appendLocale(locales, locale)
+	}
+	return locales, nil
+}
+
+// appendLocale appends the locale unless it is already listed
+func appendLocale(locales []string, locale string) []string {
+	for _, listed := range locales {
+		if listed == locale {
+			return locales
+		}
+	}
+	return append(locales, locale)
+}
@@ -1,12 +1,47 @@
 package main
 
 import (
+	"landmarksmodule/config"
 	"landmarksmodule/db"
+	"landmarksmodule/handlers"
+	"landmarksmodule/repository"
 	"landmarksmodule/routes"
+	"landmarksmodule/storage"
+	"landmarksmodule/tiles"
+	"log"
+	"os"
 )
 
 func main() {
-	db.Init()
-	routes.SetupRoutes()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
+	if err := db.Init(cfg.Database); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	repos := repository.NewGorm(db.DB, db.SQL)
+	handlers.Init(repos, cfg.Landmarks)
+
+	// Commands run against the configured database instead of starting the server. They leave the
+	// tile cache alone, a disk cache being emptied when it is opened while a server may be using it.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-landmarks":
+			os.Exit(importLandmarks(os.Args[2:]))
+		case "import-osm":
+			os.Exit(importOSM(os.Args[2:], repos, cfg.OSM))
+		default:
+			log.Fatalf("Unknown command %q", os.Args[1])
+		}
+	}
+
+	if err := tiles.Init(cfg.Tiles); err != nil {
+		log.Fatalf("Failed to initialize tile cache: %v", err)
+	}
+	if err := storage.Init(cfg.Storage); err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	routes.SetupRoutes(cfg.Server)
 }
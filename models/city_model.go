@@ -11,8 +11,8 @@ type City struct {
 	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 	Name       string     `json:"name"`
 	Area       float64    `json:"area"`
-	Latitude   string     `json:"latitude"`
-	Longitude  string     `json:"longitude"`
+	Latitude   float64    `gorm:"index:idx_cities_coordinates" json:"latitude"`
+	Longitude  float64    `gorm:"index:idx_cities_coordinates" json:"longitude"`
 	Population int        `json:"population"`
 	RegionID   uint       `gorm:"foreignkey:RegionID" json:"region_id"`
 }
@@ -8,7 +8,7 @@ type Country struct {
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 	Name      string     `json:"name"`
-	Latitude  string     `json:"latitude"`
-	Longitude string     `json:"longitude"`
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
 	Regions   []Region   `gorm:"foreignkey:RegionID" json:"regions"`
 }
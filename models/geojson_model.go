@@ -3,12 +3,42 @@ package models
 import "time"
 
 type GeoJSON struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	RegionID    uint       `json:"region_id"`
-	GeoJSONData string     `gorm:"type:longtext" json:"geojson_data"` // Raw GeoJSON data as a string
-	MiddlePoint string     `json:"middle_point"`                      // Middle point as a string
-	Zoom        float64    `json:"zoom"`                              // Zoom field
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	RegionID    uint   `json:"region_id"`
+	GeoJSONData string `gorm:"type:longtext" json:"geojson_data"` // Raw GeoJSON data as a string
+	MapView
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// MapView tells a map client where to show a boundary
+type MapView struct {
+	MiddlePoint Coordinates `gorm:"embedded;embedded_prefix:middle_" json:"middle_point"`
+	BBox        BoundingBox `gorm:"embedded;embedded_prefix:bbox_" json:"bbox"`
+	Zoom        float64     `json:"zoom"`
+}
+
+// Coordinates is a position in degrees
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// BoundingBox is an area in degrees, MinLongitude is greater than MaxLongitude when it crosses the antimeridian
+type BoundingBox struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// GeoJSONSimplification is a boundary simplified so that it looks the same up to zoom level Zoom
+type GeoJSONSimplification struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	GeoJSONID   uint      `gorm:"index" json:"geojson_id"`
+	Zoom        int       `json:"zoom"`
+	GeoJSONData string    `gorm:"type:longtext" json:"geojson_data"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// Landmark is a place of interest in a city. Its latitude and longitude, like those of cities and
+// countries, are in degrees and encoded as JSON numbers; they were strings before the coordinate
+// columns became numeric.
 type Landmark struct {
 	ID          uint            `gorm:"primary_key" json:"id"`
 	CreatedAt   time.Time       `json:"created_at"`
@@ -13,10 +16,12 @@ type Landmark struct {
 	Type        string          `json:"type"`
 	Information string          `json:"information"`
 	Description string          `gorm:"size:mediumtext" json:"description"`
-	Latitude    string          `json:"latitude"`
-	Longitude   string          `json:"longitude"`
+	Latitude    float64         `gorm:"index:idx_landmarks_coordinates" json:"latitude"`
+	Longitude   float64         `gorm:"index:idx_landmarks_coordinates" json:"longitude"`
 	CityID      uint            `gorm:"foreignkey:CityID" json:"city_id"`
 	Photos      []LandmarkPhoto `gorm:"foreignkey:LandmarkID" json:"-"`
 	PhotoLinks  []string        `gorm:"-" json:"photo_links"`
 	Reviews     []Review        `gorm:"foreignkey:LandmarkID" json:"reviews,omitempty"`
+	// ExternalRef identifies an imported landmark in its source, such as osm:node/42
+	ExternalRef *string `gorm:"size:64;unique_index" json:"external_ref,omitempty"`
 }
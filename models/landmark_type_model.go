@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LandmarkType is an entry of the landmark taxonomy. Landmarks refer to their type by its Slug.
+type LandmarkType struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Slug is the value of Landmark.Type for the landmarks of this type, see LandmarkTypeSlug
+	Slug string `gorm:"size:64;unique_index;not null" json:"slug"`
+	Name string `gorm:"not null" json:"name"`
+	// ParentID is the broader category of the type, such as Historic for Castle, nil at the top
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+	Icon     string `json:"icon"`
+	// Labels maps language tags to the translated names, stored as LandmarkTypeLabel rows
+	Labels map[string]string `gorm:"-" json:"labels"`
+}
+
+// LandmarkTypeLabel is the name of a landmark type in a language
+type LandmarkTypeLabel struct {
+	ID             uint   `gorm:"primary_key" json:"id"`
+	LandmarkTypeID uint   `gorm:"unique_index:idx_landmark_type_labels_language" json:"landmark_type_id"`
+	Language       string `gorm:"size:16;unique_index:idx_landmark_type_labels_language" json:"language"`
+	Label          string `json:"label"`
+}
+
+// LandmarkTypeSlug returns the slug of the type of the given name: its lowercase words joined by
+// underscores, the last one in the singular, so that Museum, museum and Museums share the slug museum
+// and Historic Sites becomes historic_site
+func LandmarkTypeSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = singular(words[len(words)-1])
+	return strings.Join(words, "_")
+}
+
+// singular returns the singular of a regular English plural, and other words unchanged
+func singular(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case len(word) > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// LandmarkTypeName returns a name for a type known only by its slug, such as Historic site for historic_site
+func LandmarkTypeName(slug string) string {
+	name := strings.ReplaceAll(slug, "_", " ")
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
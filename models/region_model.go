@@ -14,4 +14,6 @@ type Region struct {
 	Area       float64    `json:"area"`
 	Population int        `json:"population"`
 	GeoJSON    string     `gorm:"type:longtext" json:"geojson,omitempty"`
+	// Map is the map view of the boundary, loaded along with GeoJSON
+	Map *MapView `gorm:"-" json:"map,omitempty"`
 }
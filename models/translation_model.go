@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Entities whose fields are translated, the values of Translation.EntityType
+const (
+	EntityLandmark = "landmark"
+	EntityCity     = "city"
+	EntityRegion   = "region"
+	EntityCountry  = "country"
+)
+
+// TranslatableFields lists the translated fields of every entity by their JSON name
+var TranslatableFields = map[string][]string{
+	EntityLandmark: {"name", "information", "description"},
+	EntityCity:     {"name"},
+	EntityRegion:   {"name"},
+	EntityCountry:  {"name"},
+}
+
+// Translation is the text of a field of a landmark, city, region or country in a locale. The stored
+// field keeps the text shown when no translation fits the locales of a request.
+type Translation struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	EntityType string    `gorm:"size:16;not null;unique_index:idx_translations_field" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;unique_index:idx_translations_field" json:"entity_id"`
+	Field      string    `gorm:"size:32;not null;unique_index:idx_translations_field" json:"field"`
+	Locale     string    `gorm:"size:8;not null;unique_index:idx_translations_field" json:"locale"`
+	Text       string    `gorm:"type:text" json:"text"`
+}
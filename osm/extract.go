@@ -0,0 +1,89 @@
+package osm
+
+import (
+	"strconv"
+
+	"landmarksmodule/geo"
+)
+
+// Feature is an element of an extract selected by the tag rules
+type Feature struct {
+	// Ref is the type and the id of the element, such as node/42
+	Ref         string
+	Name        string
+	Type        string
+	Description string
+	Point       geo.Point
+}
+
+// Extract reads the named features selected by the rules from an extract. Nodes are located by
+// their coordinates and ways by the mean of the distinct positions of their nodes, which takes a
+// second reading of the file when ways are selected. Relations are not imported.
+func Extract(path string, rules []Rule) ([]Feature, error) {
+	var features []Feature
+	feature := func(ref string, tags map[string]string) (Feature, bool) {
+		name := tags["name"]
+		if name == "" {
+			return Feature{}, false
+		}
+		landmarkType, ok := match(rules, tags)
+		return Feature{Ref: ref, Name: name, Type: landmarkType, Description: tags["description"]}, ok
+	}
+
+	// The nodes of the selected ways are only known once the ways, which follow the nodes, are read
+	type way struct {
+		feature Feature
+		refs    []int64
+	}
+	var ways []way
+	needed := map[int64]bool{}
+	err := scan(path, visitor{
+		node: func(id int64, lat, lon float64, tags map[string]string) {
+			if f, ok := feature("node/"+strconv.FormatInt(id, 10), tags); ok {
+				f.Point = geo.Point{Lng: lon, Lat: lat}
+				features = append(features, f)
+			}
+		},
+		way: func(id int64, refs []int64, tags map[string]string) {
+			if f, ok := feature("way/"+strconv.FormatInt(id, 10), tags); ok {
+				ways = append(ways, way{feature: f, refs: refs})
+				for _, ref := range refs {
+					needed[ref] = true
+				}
+			}
+		},
+	})
+	if err != nil || len(ways) == 0 {
+		return features, err
+	}
+
+	positions := make(map[int64]geo.Point, len(needed))
+	err = scan(path, visitor{
+		node: func(id int64, lat, lon float64, tags map[string]string) {
+			if needed[id] {
+				positions[id] = geo.Point{Lng: lon, Lat: lat}
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range ways {
+		// Closed ways repeat their first node, missing nodes lie outside of the extract
+		seen := map[int64]bool{}
+		var lng, lat float64
+		for _, ref := range w.refs {
+			if p, ok := positions[ref]; ok && !seen[ref] {
+				seen[ref] = true
+				lng, lat = lng+p.Lng, lat+p.Lat
+			}
+		}
+		if len(seen) == 0 {
+			continue
+		}
+		w.feature.Point = geo.Point{Lng: lng / float64(len(seen)), Lat: lat / float64(len(seen))}
+		features = append(features, w.feature)
+	}
+	return features, nil
+}
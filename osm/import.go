@@ -0,0 +1,177 @@
+package osm
+
+import (
+	"math"
+
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+// Options controls an import
+type Options struct {
+	Rules []Rule
+	// MaxCityDistanceKm leaves out the features farther from every city, 0 disables the limit
+	MaxCityDistanceKm float64
+	// DryRun reads the extract and places the features without storing anything
+	DryRun bool
+	// TypeMap maps the types of the features, the values of their tags, to the slugs of the
+	// taxonomy, as in castle: fortress. The types left out are slugified as they are.
+	TypeMap map[string]string
+	// CreateTypes adds the types missing from the taxonomy instead of skipping their features
+	CreateTypes bool
+}
+
+// Report tells the outcome of an import
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// Features counts the named features selected by the rules
+	Features int `json:"features"`
+	// Unplaced counts the features left out for lack of a city close enough
+	Unplaced int `json:"unplaced"`
+	// UnknownType counts the features skipped because their type is missing from the taxonomy
+	UnknownType int `json:"unknown_type"`
+	// TypesCreated counts the landmark types added to the taxonomy with Options.CreateTypes
+	TypesCreated int `json:"types_created"`
+	repository.UpsertCounts
+}
+
+// ExternalRefPrefix starts the ExternalRef of the landmarks imported from OpenStreetMap
+const ExternalRefPrefix = "osm:"
+
+// Import reads an extract and stores its features as landmarks in the city nearest to each of them.
+// A landmark is identified by the type and the id of its element, as in osm:node/42, so that
+// importing an extract again updates the landmarks instead of duplicating them. The features whose
+// type is missing from the taxonomy are skipped, unless Options.CreateTypes adds their types to it.
+func Import(repos repository.Repositories, path string, options Options) (*Report, error) {
+	features, err := Extract(path, options.Rules)
+	if err != nil {
+		return nil, err
+	}
+	cities, _, err := repos.Cities.List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	finder := newCityFinder(cities)
+	known, err := knownTypes(repos)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: options.DryRun, Features: len(features)}
+	landmarks := make([]models.Landmark, 0, len(features))
+	var slugs []string
+	for _, feature := range features {
+		slug := typeSlug(feature.Type, options.TypeMap)
+		if !known[slug] && !options.CreateTypes {
+			report.UnknownType++
+			continue
+		}
+		city, ok := finder.nearest(feature.Point, options.MaxCityDistanceKm)
+		if !ok {
+			report.Unplaced++
+			continue
+		}
+		if !known[slug] {
+			slugs = append(slugs, slug)
+		}
+		ref := ExternalRefPrefix + feature.Ref
+		landmarks = append(landmarks, models.Landmark{
+			Name:        feature.Name,
+			Type:        slug,
+			Description: feature.Description,
+			Latitude:    feature.Point.Lat,
+			Longitude:   feature.Point.Lng,
+			CityID:      city.ID,
+			ExternalRef: &ref,
+		})
+	}
+
+	if options.DryRun || len(landmarks) == 0 {
+		return report, nil
+	}
+	if len(slugs) > 0 {
+		if report.TypesCreated, err = repos.LandmarkTypes.EnsureSlugs(slugs); err != nil {
+			return nil, err
+		}
+	}
+	if report.UpsertCounts, err = repos.Landmarks.UpsertByExternalRef(landmarks); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// knownTypes returns the set of the slugs of the taxonomy
+func knownTypes(repos repository.Repositories) (map[string]bool, error) {
+	landmarkTypes, _, err := repos.LandmarkTypes.List(repository.Page{})
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(landmarkTypes))
+	for _, landmarkType := range landmarkTypes {
+		known[landmarkType.Slug] = true
+	}
+	return known, nil
+}
+
+// typeSlug returns the slug of the type of a feature, mapped by typeMap when it lists the type
+func typeSlug(featureType string, typeMap map[string]string) string {
+	if mapped, ok := typeMap[featureType]; ok {
+		return models.LandmarkTypeSlug(mapped)
+	}
+	return models.LandmarkTypeSlug(featureType)
+}
+
+// cityCell is a square of one degree on a side, keyed by the floor of its coordinates
+type cityCell struct {
+	lat, lng int
+}
+
+// cityFinder finds the nearest city of a point among the cities bucketed in cells
+type cityFinder struct {
+	cities []models.City
+	cells  map[cityCell][]int
+}
+
+func newCityFinder(cities []models.City) *cityFinder {
+	f := &cityFinder{cities: cities, cells: map[cityCell][]int{}}
+	for i, city := range cities {
+		cell := cityCell{lat: int(math.Floor(city.Latitude)), lng: int(math.Floor(city.Longitude))}
+		f.cells[cell] = append(f.cells[cell], i)
+	}
+	return f
+}
+
+// nearest returns the city closest to the point, within maxDistanceKm unless it is 0
+func (f *cityFinder) nearest(p geo.Point, maxDistanceKm float64) (*models.City, bool) {
+	candidates := func(visit func(i int)) {
+		for i := range f.cities {
+			visit(i)
+		}
+	}
+	if maxDistanceKm > 0 {
+		// Only the cells overlapping the circle of the given radius are searched
+		box := geo.Around(p.Lat, p.Lng, maxDistanceKm)
+		candidates = func(visit func(i int)) {
+			for lat := int(math.Floor(box.MinLat)); lat <= int(math.Floor(box.MaxLat)); lat++ {
+				for lng := int(math.Floor(box.MinLng)); lng <= int(math.Floor(box.MaxLng)); lng++ {
+					for _, i := range f.cells[cityCell{lat: lat, lng: lng}] {
+						visit(i)
+					}
+				}
+			}
+		}
+	}
+
+	nearest, best := -1, math.Inf(1)
+	candidates(func(i int) {
+		distance := geo.DistanceKm(p.Lat, p.Lng, f.cities[i].Latitude, f.cities[i].Longitude)
+		if distance < best && (maxDistanceKm == 0 || distance <= maxDistanceKm) {
+			nearest, best = i, distance
+		}
+	})
+	if nearest < 0 {
+		return nil, false
+	}
+	return &f.cities[nearest], true
+}
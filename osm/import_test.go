@@ -0,0 +1,116 @@
+package osm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+	"landmarksmodule/repository/memory"
+)
+
+const importExtract = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+  <node id="1" lat="42.2139" lon="20.7397">
+    <tag k="tourism" v="museum"/>
+    <tag k="name" v="Archaeological Museum"/>
+  </node>
+  <node id="2" lat="42.2125" lon="20.7436">
+    <tag k="historic" v="castle"/>
+    <tag k="name" v="Prizren Fortress"/>
+  </node>
+  <node id="3" lat="42.2150" lon="20.7450">
+    <tag k="tourism" v="viewpoint"/>
+    <tag k="name" v="Fortress Viewpoint"/>
+  </node>
+</osm>
+`
+
+// importRepositories returns memory repositories holding a city and the museum and fortress types
+func importRepositories(t *testing.T) repository.Repositories {
+	t.Helper()
+	repos := memory.New()
+	if err := repos.Cities.Create(&models.City{Name: "Prizren", Latitude: 42.2139, Longitude: 20.7397}); err != nil {
+		t.Fatal(err)
+	}
+	for _, slug := range []string{"museum", "fortress"} {
+		if err := repos.LandmarkTypes.Create(&models.LandmarkType{Slug: slug, Name: slug}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repos
+}
+
+func writeExtract(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "extract.osm")
+	if err := os.WriteFile(path, []byte(importExtract), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportSkipsUnknownTypes(t *testing.T) {
+	repos := importRepositories(t)
+	rules, _ := ParseRules([]string{"tourism=*", "historic=*"})
+
+	report, err := Import(repos, writeExtract(t), Options{Rules: rules, TypeMap: map[string]string{"castle": "fortress"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Features != 3 || report.UnknownType != 1 || report.Created != 2 || report.TypesCreated != 0 {
+		t.Errorf("report = %+v, want 3 features, 1 of unknown type, 2 created and no type created", report)
+	}
+
+	landmarkTypes, total, err := repos.LandmarkTypes.List(repository.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Errorf("taxonomy has %d types, want the 2 it had: %+v", total, landmarkTypes)
+	}
+	landmarks, _, err := repos.Landmarks.List(repository.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]string{}
+	for _, landmark := range landmarks {
+		types[landmark.Name] = landmark.Type
+	}
+	if types["Prizren Fortress"] != "fortress" || types["Archaeological Museum"] != "museum" {
+		t.Errorf("landmark types = %v, want the castle mapped to fortress", types)
+	}
+}
+
+func TestImportCreateTypes(t *testing.T) {
+	repos := importRepositories(t)
+	rules, _ := ParseRules([]string{"tourism=*", "historic=*"})
+
+	report, err := Import(repos, writeExtract(t), Options{Rules: rules, CreateTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.UnknownType != 0 || report.Created != 3 || report.TypesCreated != 2 {
+		t.Errorf("report = %+v, want 3 created and the castle and viewpoint types created", report)
+	}
+	if _, err := repos.LandmarkTypes.GetBySlug("viewpoint"); err != nil {
+		t.Errorf("viewpoint type: %v", err)
+	}
+}
+
+func TestImportDryRunCreatesNothing(t *testing.T) {
+	repos := importRepositories(t)
+	rules, _ := ParseRules([]string{"tourism=*", "historic=*"})
+
+	report, err := Import(repos, writeExtract(t), Options{Rules: rules, DryRun: true, CreateTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Features != 3 || report.TypesCreated != 0 || report.Created != 0 {
+		t.Errorf("report = %+v, want 3 features and nothing stored", report)
+	}
+	if _, total, _ := repos.LandmarkTypes.List(repository.Page{}); total != 2 {
+		t.Errorf("taxonomy has %d types after a dry run, want 2", total)
+	}
+}
@@ -0,0 +1,83 @@
+// Package osm reads OpenStreetMap extracts in the PBF or the XML format and imports the features
+// selected by tag rules as landmarks
+package osm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// visitor receives the elements of an extract, a nil callback skips the elements of its kind
+type visitor struct {
+	node func(id int64, lat, lon float64, tags map[string]string)
+	way  func(id int64, refs []int64, tags map[string]string)
+}
+
+// scan reads an extract, in the PBF format when its name ends with .pbf and in the XML format otherwise
+func scan(path string, v visitor) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(strings.ToLower(path), ".pbf") {
+		err = scanPBF(bufio.NewReader(file), v)
+	} else {
+		err = scanXML(bufio.NewReader(file), v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// Rule selects the elements having a tag, with any value when Value is *
+type Rule struct {
+	Key   string
+	Value string
+}
+
+func (r Rule) String() string {
+	return r.Key + "=" + r.Value
+}
+
+// ParseRule parses a rule written key=value or key=*
+func ParseRule(rule string) (Rule, error) {
+	key, value, ok := strings.Cut(rule, "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok || key == "" || value == "" {
+		return Rule{}, fmt.Errorf("invalid tag rule %q, use key=value or key=*", rule)
+	}
+	return Rule{Key: key, Value: value}, nil
+}
+
+// ParseRules parses a list of rules, see ParseRule
+func ParseRules(rules []string) ([]Rule, error) {
+	parsed := make([]Rule, len(rules))
+	for i, rule := range rules {
+		var err error
+		if parsed[i], err = ParseRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// match returns the landmark type of the first rule selecting the tags: the value of the tag,
+// or its key when the value is just yes, as in historic=yes
+func match(rules []Rule, tags map[string]string) (string, bool) {
+	for _, rule := range rules {
+		value, ok := tags[rule.Key]
+		if !ok || (rule.Value != "*" && rule.Value != value) {
+			continue
+		}
+		if value == "yes" {
+			return rule.Key, true
+		}
+		return value, true
+	}
+	return "", false
+}
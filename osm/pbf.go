@@ -0,0 +1,330 @@
+package osm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// maxBlobHeaderSize and maxBlobSize are the limits set by the PBF format
+	maxBlobHeaderSize = 64 * 1024
+	maxBlobSize       = 32 * 1024 * 1024
+)
+
+// supportedFeatures are the required features of a PBF file understood by scanPBF
+var supportedFeatures = map[string]bool{
+	"OsmSchema-V0.6": true,
+	"DenseNodes":     true,
+}
+
+// scanPBF reads an extract in the PBF format, a sequence of blobs each preceded by its header
+func scanPBF(r io.Reader, v visitor) error {
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		headerSize := binary.BigEndian.Uint32(size[:])
+		if headerSize > maxBlobHeaderSize {
+			return fmt.Errorf("blob header of %d bytes is too large", headerSize)
+		}
+		header := make([]byte, headerSize)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return err
+		}
+		blobType, blobSize, err := parseBlobHeader(header)
+		if err != nil {
+			return err
+		}
+		if blobSize > maxBlobSize {
+			return fmt.Errorf("blob of %d bytes is too large", blobSize)
+		}
+		blob := make([]byte, blobSize)
+		if _, err := io.ReadFull(r, blob); err != nil {
+			return err
+		}
+		data, err := blobData(blob)
+		if err != nil {
+			return err
+		}
+
+		switch blobType {
+		case "OSMHeader":
+			err = checkHeaderBlock(data)
+		case "OSMData":
+			err = readPrimitiveBlock(data, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseBlobHeader returns the type and the size of the blob following a BlobHeader
+func parseBlobHeader(data []byte) (string, uint64, error) {
+	var blobType string
+	var size uint64
+	m := message{data: data}
+	for m.next() {
+		switch m.field {
+		case 1:
+			blobType = string(m.bytes)
+		case 3:
+			size = m.varint
+		}
+	}
+	return blobType, size, m.err
+}
+
+// blobData returns the uncompressed content of a Blob
+func blobData(data []byte) ([]byte, error) {
+	var rawSize uint64
+	var raw, compressed []byte
+	m := message{data: data}
+	for m.next() {
+		switch m.field {
+		case 1:
+			raw = m.bytes
+		case 2:
+			rawSize = m.varint
+		case 3:
+			compressed = m.bytes
+		case 4, 5, 6, 7:
+			return nil, errors.New("only raw and zlib compressed blobs are supported")
+		}
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	if compressed == nil {
+		return raw, nil
+	}
+	if rawSize > maxBlobSize {
+		return nil, fmt.Errorf("blob of %d bytes is too large", rawSize)
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	out := bytes.NewBuffer(make([]byte, 0, rawSize))
+	if _, err := io.Copy(out, io.LimitReader(reader, maxBlobSize)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// checkHeaderBlock makes sure every feature required by the file is supported
+func checkHeaderBlock(data []byte) error {
+	m := message{data: data}
+	for m.next() {
+		if m.field == 4 && !supportedFeatures[string(m.bytes)] {
+			return fmt.Errorf("unsupported required feature %q", m.bytes)
+		}
+	}
+	return m.err
+}
+
+// block holds the string table and the coordinate encoding of a PrimitiveBlock
+type block struct {
+	strings     []string
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+func (b *block) lat(value int64) float64 {
+	return float64(b.latOffset+b.granularity*value) / 1e9
+}
+
+func (b *block) lon(value int64) float64 {
+	return float64(b.lonOffset+b.granularity*value) / 1e9
+}
+
+// tags pairs the keys and the values given as indexes in the string table
+func (b *block) tags(keys, values []uint64) (map[string]string, error) {
+	if len(keys) != len(values) {
+		return nil, errors.New("tags have more keys than values")
+	}
+	tags := make(map[string]string, len(keys))
+	for i := range keys {
+		if keys[i] >= uint64(len(b.strings)) || values[i] >= uint64(len(b.strings)) {
+			return nil, errors.New("tag refers to a missing string")
+		}
+		tags[b.strings[keys[i]]] = b.strings[values[i]]
+	}
+	return tags, nil
+}
+
+func readPrimitiveBlock(data []byte, v visitor) error {
+	b := block{granularity: 100}
+	var groups [][]byte
+	m := message{data: data}
+	for m.next() {
+		switch m.field {
+		case 1:
+			table := message{data: m.bytes}
+			for table.next() {
+				if table.field == 1 {
+					b.strings = append(b.strings, string(table.bytes))
+				}
+			}
+			if table.err != nil {
+				return table.err
+			}
+		case 2:
+			groups = append(groups, m.bytes)
+		case 17:
+			b.granularity = int64(m.varint)
+		case 19:
+			b.latOffset = int64(m.varint)
+		case 20:
+			b.lonOffset = int64(m.varint)
+		}
+	}
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, group := range groups {
+		g := message{data: group}
+		for g.next() {
+			var err error
+			switch {
+			case g.field == 1 && v.node != nil:
+				err = b.readNode(g.bytes, v)
+			case g.field == 2 && v.node != nil:
+				err = b.readDenseNodes(g.bytes, v)
+			case g.field == 3 && v.way != nil:
+				err = b.readWay(g.bytes, v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if g.err != nil {
+			return g.err
+		}
+	}
+	return nil
+}
+
+func (b *block) readNode(data []byte, v visitor) error {
+	var id, lat, lon int64
+	var keys, values []uint64
+	m := message{data: data}
+	for m.next() {
+		switch m.field {
+		case 1:
+			id = m.signed()
+		case 2:
+			keys = m.varints(keys)
+		case 3:
+			values = m.varints(values)
+		case 8:
+			lat = m.signed()
+		case 9:
+			lon = m.signed()
+		}
+	}
+	if m.err != nil {
+		return m.err
+	}
+	tags, err := b.tags(keys, values)
+	if err != nil {
+		return err
+	}
+	v.node(id, b.lat(lat), b.lon(lon), tags)
+	return nil
+}
+
+// readDenseNodes reads nodes stored column by column, with delta coded ids and coordinates and
+// their tags as a list of key and value indexes where 0 ends the tags of a node
+func (b *block) readDenseNodes(data []byte, v visitor) error {
+	var ids, lats, lons, keysValues []uint64
+	m := message{data: data}
+	for m.next() {
+		switch m.field {
+		case 1:
+			ids = m.varints(ids)
+		case 8:
+			lats = m.varints(lats)
+		case 9:
+			lons = m.varints(lons)
+		case 10:
+			keysValues = m.varints(keysValues)
+		}
+	}
+	if m.err != nil {
+		return m.err
+	}
+	if len(lats) != len(ids) || len(lons) != len(ids) {
+		return errors.New("dense nodes have fewer coordinates than ids")
+	}
+
+	var id, lat, lon int64
+	for i := range ids {
+		id += zigzag(ids[i])
+		lat += zigzag(lats[i])
+		lon += zigzag(lons[i])
+
+		var keys, values []uint64
+		for len(keysValues) > 0 && keysValues[0] != 0 {
+			if len(keysValues) < 2 {
+				return errors.New("dense node tag has no value")
+			}
+			keys, values = append(keys, keysValues[0]), append(values, keysValues[1])
+			keysValues = keysValues[2:]
+		}
+		if len(keysValues) > 0 {
+			keysValues = keysValues[1:]
+		}
+		tags, err := b.tags(keys, values)
+		if err != nil {
+			return err
+		}
+		v.node(id, b.lat(lat), b.lon(lon), tags)
+	}
+	return nil
+}
+
+func (b *block) readWay(data []byte, v visitor) error {
+	var id int64
+	var keys, values, deltas []uint64
+	m := message{data: data}
+	for m.next() {
+		switch m.field {
+		case 1:
+			id = int64(m.varint)
+		case 2:
+			keys = m.varints(keys)
+		case 3:
+			values = m.varints(values)
+		case 8:
+			deltas = m.varints(deltas)
+		}
+	}
+	if m.err != nil {
+		return m.err
+	}
+	tags, err := b.tags(keys, values)
+	if err != nil {
+		return err
+	}
+
+	refs := make([]int64, len(deltas))
+	var ref int64
+	for i, delta := range deltas {
+		ref += zigzag(delta)
+		refs[i] = ref
+	}
+	v.way(id, refs, tags)
+	return nil
+}
@@ -0,0 +1,253 @@
+package osm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"flag"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"landmarksmodule/geo"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/prizren.osm.pbf from testdata/prizren.osm")
+
+const (
+	xmlFixture = "testdata/prizren.osm"
+	pbfFixture = "testdata/prizren.osm.pbf"
+)
+
+func TestExtractPBFMatchesXML(t *testing.T) {
+	if *update {
+		writePBFFixture(t)
+	}
+	rules, err := ParseRules([]string{"tourism=*", "historic=*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromXML, err := Extract(xmlFixture, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPBF, err := Extract(pbfFixture, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byRef := func(features []Feature) map[string]Feature {
+		result := map[string]Feature{}
+		for _, feature := range features {
+			result[feature.Ref] = feature
+		}
+		return result
+	}
+	if !reflect.DeepEqual(byRef(fromXML), byRef(fromPBF)) {
+		t.Errorf("features differ\nXML: %+v\nPBF: %+v", fromXML, fromPBF)
+	}
+
+	features := byRef(fromPBF)
+	want := map[string]Feature{
+		"node/1":  {Ref: "node/1", Name: "Archaeological Museum", Type: "museum", Description: "Hammam of the sixteenth century", Point: geo.Point{Lng: 20.7397, Lat: 42.2139}},
+		"node/2":  {Ref: "node/2", Name: "Shadervan", Type: "historic", Point: geo.Point{Lng: 20.7411, Lat: 42.2093}},
+		"node/5":  {Ref: "node/5", Name: "Stone Bridge", Type: "attraction", Point: geo.Point{Lng: 20.7389, Lat: 42.2101}},
+		"way/100": {Ref: "way/100", Name: "Prizren Fortress", Type: "castle", Point: geo.Point{Lng: 20.743, Lat: 42.212}},
+		"way/102": {Ref: "way/102", Name: "Old Wall", Type: "city_walls", Point: geo.Point{Lng: 20.735, Lat: 42.208}},
+	}
+	if len(features) != len(want) {
+		t.Errorf("extracted %d features, want %d: %+v", len(features), len(want), fromPBF)
+	}
+	for ref, expected := range want {
+		feature, ok := features[ref]
+		if !ok {
+			t.Errorf("%s is missing", ref)
+			continue
+		}
+		point := feature.Point
+		feature.Point, expected.Point = geo.Point{}, geo.Point{}
+		if feature != expected {
+			t.Errorf("%s = %+v, want %+v", ref, feature, expected)
+		}
+		if want := want[ref].Point; math.Abs(point.Lng-want.Lng) > 1e-9 || math.Abs(point.Lat-want.Lat) > 1e-9 {
+			t.Errorf("%s is at %+v, want %+v", ref, point, want)
+		}
+	}
+}
+
+// pbfBuffer encodes protocol buffer messages
+type pbfBuffer []byte
+
+func (b *pbfBuffer) varint(field int, value uint64) {
+	*b = binary.AppendUvarint(binary.AppendUvarint(*b, uint64(field)<<3), value)
+}
+
+func (b *pbfBuffer) bytes(field int, data []byte) {
+	*b = binary.AppendUvarint(binary.AppendUvarint(*b, uint64(field)<<3|2), uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *pbfBuffer) packed(field int, values []uint64) {
+	var data []byte
+	for _, value := range values {
+		data = binary.AppendUvarint(data, value)
+	}
+	b.bytes(field, data)
+}
+
+func encodeZigzag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
+
+// deltas returns the zigzag coded differences between the successive values
+func deltas(values []int64) []uint64 {
+	result := make([]uint64, len(values))
+	var previous int64
+	for i, value := range values {
+		result[i], previous = encodeZigzag(value-previous), value
+	}
+	return result
+}
+
+// stringTable numbers the strings of a primitive block, 0 being the empty string
+type stringTable struct {
+	strings []string
+	index   map[string]uint64
+}
+
+func (s *stringTable) id(value string) uint64 {
+	if s.index == nil {
+		s.strings, s.index = []string{""}, map[string]uint64{"": 0}
+	}
+	id, ok := s.index[value]
+	if !ok {
+		id = uint64(len(s.strings))
+		s.strings = append(s.strings, value)
+		s.index[value] = id
+	}
+	return id
+}
+
+// tagIDs returns the indexes of the keys and the values of the tags, sorted by key
+func (s *stringTable) tagIDs(tags map[string]string) ([]uint64, []uint64) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var keyIDs, valueIDs []uint64
+	for _, key := range keys {
+		keyIDs, valueIDs = append(keyIDs, s.id(key)), append(valueIDs, s.id(tags[key]))
+	}
+	return keyIDs, valueIDs
+}
+
+// coordinate is a coordinate in units of the default granularity of 100 nanodegrees
+func coordinate(degrees float64) int64 {
+	return int64(math.Round(degrees * 1e7))
+}
+
+// writePBFFixture converts the XML fixture to a PBF file of zlib compressed blobs. Node 5 is
+// written as a plain node and the other nodes as dense nodes, so that both encodings are read.
+func writePBFFixture(t *testing.T) {
+	t.Helper()
+	file, err := os.Open(xmlFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var table stringTable
+	var denseIDs, denseLats, denseLons []int64
+	var denseTags []uint64
+	var nodes, ways []pbfBuffer
+	err = scanXML(file, visitor{
+		node: func(id int64, lat, lon float64, tags map[string]string) {
+			keys, values := table.tagIDs(tags)
+			if id == 5 {
+				var node pbfBuffer
+				node.varint(1, encodeZigzag(id))
+				node.packed(2, keys)
+				node.packed(3, values)
+				node.varint(8, encodeZigzag(coordinate(lat)))
+				node.varint(9, encodeZigzag(coordinate(lon)))
+				nodes = append(nodes, node)
+				return
+			}
+			denseIDs = append(denseIDs, id)
+			denseLats, denseLons = append(denseLats, coordinate(lat)), append(denseLons, coordinate(lon))
+			for i := range keys {
+				denseTags = append(denseTags, keys[i], values[i])
+			}
+			denseTags = append(denseTags, 0)
+		},
+		way: func(id int64, refs []int64, tags map[string]string) {
+			keys, values := table.tagIDs(tags)
+			var way pbfBuffer
+			way.varint(1, uint64(id))
+			way.packed(2, keys)
+			way.packed(3, values)
+			way.packed(8, deltas(refs))
+			ways = append(ways, way)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dense, denseGroup, nodeGroup, wayGroup pbfBuffer
+	dense.packed(1, deltas(denseIDs))
+	dense.packed(8, deltas(denseLats))
+	dense.packed(9, deltas(denseLons))
+	dense.packed(10, denseTags)
+	denseGroup.bytes(2, dense)
+	for _, node := range nodes {
+		nodeGroup.bytes(1, node)
+	}
+	for _, way := range ways {
+		wayGroup.bytes(3, way)
+	}
+
+	var strings, primitive, header pbfBuffer
+	for _, value := range table.strings {
+		strings.bytes(1, []byte(value))
+	}
+	primitive.bytes(1, strings)
+	primitive.bytes(2, denseGroup)
+	primitive.bytes(2, nodeGroup)
+	primitive.bytes(2, wayGroup)
+	header.bytes(4, []byte("OsmSchema-V0.6"))
+	header.bytes(4, []byte("DenseNodes"))
+
+	var out bytes.Buffer
+	writeBlob(t, &out, "OSMHeader", header)
+	writeBlob(t, &out, "OSMData", primitive)
+	if err := os.WriteFile(pbfFixture, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeBlob writes a zlib compressed blob preceded by the size of its header and its header
+func writeBlob(t *testing.T, out *bytes.Buffer, blobType string, data []byte) {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blob, header pbfBuffer
+	blob.varint(2, uint64(len(data)))
+	blob.bytes(3, compressed.Bytes())
+	header.bytes(1, []byte(blobType))
+	header.varint(3, uint64(len(blob)))
+
+	out.Write(binary.BigEndian.AppendUint32(nil, uint32(len(header))))
+	out.Write(header)
+	out.Write(blob)
+}
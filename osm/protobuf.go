@@ -0,0 +1,119 @@
+package osm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Protocol buffer wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protocol buffer message")
+
+// message reads the fields of an encoded protocol buffer message one after the other
+type message struct {
+	data []byte
+	err  error
+	// field and wire are the number and the wire type of the current field
+	field int
+	wire  int
+	// varint holds the value of the current field for wireVarint, bytes for wireBytes
+	varint uint64
+	bytes  []byte
+}
+
+// next moves to the next field, returning false at the end of the message or on an error
+func (m *message) next() bool {
+	if m.err != nil || len(m.data) == 0 {
+		return false
+	}
+	key, ok := m.readVarint()
+	if !ok {
+		return false
+	}
+	m.field, m.wire = int(key>>3), int(key&7)
+
+	switch m.wire {
+	case wireVarint:
+		m.varint, ok = m.readVarint()
+	case wireBytes:
+		var size uint64
+		if size, ok = m.readVarint(); ok {
+			if size > uint64(len(m.data)) {
+				m.err = errTruncated
+				return false
+			}
+			m.bytes, m.data = m.data[:size], m.data[size:]
+		}
+	case wireFixed64:
+		ok = m.skip(8)
+	case wireFixed32:
+		ok = m.skip(4)
+	default:
+		m.err = fmt.Errorf("unsupported protocol buffer wire type %d", m.wire)
+		return false
+	}
+	return ok
+}
+
+func (m *message) readVarint() (uint64, bool) {
+	value, n := decodeVarint(m.data)
+	if n == 0 {
+		m.err = errTruncated
+		return 0, false
+	}
+	m.data = m.data[n:]
+	return value, true
+}
+
+func (m *message) skip(n int) bool {
+	if len(m.data) < n {
+		m.err = errTruncated
+		return false
+	}
+	m.data = m.data[n:]
+	return true
+}
+
+// signed returns the current varint field as a zigzag encoded sint64
+func (m *message) signed() int64 {
+	return zigzag(m.varint)
+}
+
+// varints appends the values of the current field to values, whether it is packed or not
+func (m *message) varints(values []uint64) []uint64 {
+	if m.wire == wireVarint {
+		return append(values, m.varint)
+	}
+	for data := m.bytes; len(data) > 0; {
+		value, n := decodeVarint(data)
+		if n == 0 {
+			m.err = errTruncated
+			return values
+		}
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values
+}
+
+// decodeVarint returns the value of the varint at the start of data and its length, or a length of 0 when it is truncated
+func decodeVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		value |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+func zigzag(value uint64) int64 {
+	return int64(value>>1) ^ -int64(value&1)
+}
@@ -0,0 +1,85 @@
+package osm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// scanXML reads an extract in the OSM XML format, a list of node, way and relation elements
+func scanXML(r io.Reader, v visitor) error {
+	decoder := xml.NewDecoder(r)
+	var (
+		kind     string
+		id       int64
+		lat, lon float64
+		refs     []int64
+		tags     map[string]string
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "node", "way", "relation":
+				kind, refs, tags = element.Name.Local, nil, map[string]string{}
+				if id, err = strconv.ParseInt(attribute(element, "id"), 10, 64); err != nil {
+					return fmt.Errorf("%s has an invalid id: %w", kind, err)
+				}
+				if kind == "node" {
+					if lat, err = strconv.ParseFloat(attribute(element, "lat"), 64); err != nil {
+						return fmt.Errorf("node %d has an invalid lat: %w", id, err)
+					}
+					if lon, err = strconv.ParseFloat(attribute(element, "lon"), 64); err != nil {
+						return fmt.Errorf("node %d has an invalid lon: %w", id, err)
+					}
+				}
+			case "tag":
+				if kind != "" {
+					tags[attribute(element, "k")] = attribute(element, "v")
+				}
+			case "nd":
+				if kind == "way" {
+					ref, err := strconv.ParseInt(attribute(element, "ref"), 10, 64)
+					if err != nil {
+						return fmt.Errorf("way %d has an invalid node ref: %w", id, err)
+					}
+					refs = append(refs, ref)
+				}
+			}
+
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "node":
+				if v.node != nil {
+					v.node(id, lat, lon, tags)
+				}
+				kind = ""
+			case "way":
+				if v.way != nil {
+					v.way(id, refs, tags)
+				}
+				kind = ""
+			case "relation":
+				kind = ""
+			}
+		}
+	}
+}
+
+func attribute(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"landmarksmodule/db"
+
+	"github.com/jinzhu/gorm"
+)
+
+// NewGorm returns repositories backed by the given database and its SQL dialect
+func NewGorm(database *gorm.DB, dialect db.Dialect) Repositories {
+	return Repositories{
+		Countries: &gormCountryRepository{db: database, dialect: dialect},
+		Regions:   &gormRegionRepository{db: database},
+		Cities:    &gormCityRepository{db: database, dialect: dialect},
+		Landmarks: &gormLandmarkRepository{db: database, dialect: dialect},
+		Reviews:   &gormReviewRepository{db: database, dialect: dialect},
+		Photos:    &gormPhotoRepository{db: database},
+		GeoJSON:   &gormGeoJSONRepository{db: database},
+
+		LandmarkTypes: &gormLandmarkTypeRepository{db: database},
+		Translations:  &gormTranslationRepository{db: database},
+	}
+}
+
+// translateError maps gorm errors to the errors of this package
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// first loads the record with the given primary key into out
+func first(database *gorm.DB, out interface{}, id uint) error {
+	return translateError(database.First(out, id).Error)
+}
+
+// sortableFields lists the fields each table may be sorted on
+var sortableFields = map[string][]string{
+	"regions":   RegionSortFields,
+	"cities":    CitySortFields,
+	"landmarks": LandmarkSortFields,
+	"reviews":   ReviewSortFields,
+}
+
+// sortExpressions maps the computed sort fields of a table to the SQL computing them
+var sortExpressions = map[string]map[string]string{
+	"landmarks": {
+		"average_rating": "(SELECT COALESCE(AVG(reviews.rating), 0) FROM reviews WHERE reviews.landmark_id = landmarks.id AND reviews.deleted_at IS NULL)",
+		"review_count":   "(SELECT COUNT(*) FROM reviews WHERE reviews.landmark_id = landmarks.id AND reviews.deleted_at IS NULL)",
+	},
+}
+
+// sortOrder returns the ORDER BY clause of the sort fields on table, ending with its id column
+func sortOrder(table string, fields []SortField) (string, error) {
+	var terms []string
+	for _, field := range fields {
+		allowed := false
+		for _, name := range sortableFields[table] {
+			allowed = allowed || name == field.Field
+		}
+		if !allowed {
+			return "", fmt.Errorf("cannot sort %s by %q", table, field.Field)
+		}
+
+		expression, ok := sortExpressions[table][field.Field]
+		if !ok {
+			expression = table + "." + field.Field
+		}
+		if field.Descending {
+			expression += " desc"
+		}
+		terms = append(terms, expression)
+	}
+	return strings.Join(append(terms, table+".id"), ", "), nil
+}
+
+// paginate counts the rows matched by query and loads the requested page of them into out.
+// Rows are ordered by the sort fields of the page if any, otherwise by order, or by the id
+// column of table when order is empty.
+func paginate(query *gorm.DB, table string, order string, page Page, out interface{}) (int64, error) {
+	var total int64
+	if err := query.Model(out).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	if page.AfterID > 0 {
+		query = query.Where(table+".id > ?", page.AfterID)
+	}
+	if len(page.Sort) > 0 {
+		var err error
+		if order, err = sortOrder(table, page.Sort); err != nil {
+			return 0, err
+		}
+	}
+	if order == "" {
+		order = table + ".id"
+	}
+	query = query.Order(order)
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+	return total, query.Find(out).Error
+}
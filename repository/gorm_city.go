@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"landmarksmodule/db"
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormCityRepository struct {
+	db      *gorm.DB
+	dialect db.Dialect
+}
+
+func (r *gormCityRepository) List(page Page) ([]models.City, int64, error) {
+	var cities []models.City
+	total, err := paginate(r.db, "cities", "", page, &cities)
+	return cities, total, err
+}
+
+func (r *gormCityRepository) Get(id uint) (*models.City, error) {
+	var city models.City
+	if err := first(r.db, &city, id); err != nil {
+		return nil, err
+	}
+	return &city, nil
+}
+
+func (r *gormCityRepository) Nearest(latitude, longitude float64, regionID uint) (*models.City, error) {
+	distance, distanceArgs := r.dialect.Distance("latitude", "longitude", latitude, longitude)
+	where := "deleted_at IS NULL"
+	if regionID != 0 {
+		where += " AND region_id = ?"
+		distanceArgs = append(distanceArgs, regionID)
+	}
+	query := `
+		SELECT *,
+		       ` + distance + ` AS distance
+		FROM cities
+		WHERE ` + where + `
+		ORDER BY distance
+		LIMIT 1`
+
+	var cities []models.City
+	if err := r.db.Raw(query, distanceArgs...).Scan(&cities).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if len(cities) == 0 {
+		return nil, ErrNotFound
+	}
+	return &cities[0], nil
+}
+
+func (r *gormCityRepository) Search(name string, page Page) ([]models.City, int64, error) {
+	var cities []models.City
+	total, err := paginate(r.db.Where("name LIKE ?", "%"+name+"%"), "cities", "", page, &cities)
+	return cities, total, err
+}
+
+func (r *gormCityRepository) ListByName(name string) ([]models.City, error) {
+	var cities []models.City
+	err := r.db.Where("LOWER(name) = LOWER(?)", name).Order("id").Find(&cities).Error
+	return cities, err
+}
+
+func (r *gormCityRepository) Filter(filter CityFilter, page Page) ([]models.City, int64, error) {
+	query := r.db
+	if filter.MinPopulation != nil {
+		query = query.Where("population >= ?", *filter.MinPopulation)
+	}
+	if filter.MaxPopulation != nil {
+		query = query.Where("population <= ?", *filter.MaxPopulation)
+	}
+	if filter.MinArea != nil {
+		query = query.Where("area >= ?", *filter.MinArea)
+	}
+	if filter.MaxArea != nil {
+		query = query.Where("area <= ?", *filter.MaxArea)
+	}
+	if filter.MinLatitude != nil {
+		query = query.Where("latitude >= ?", *filter.MinLatitude)
+	}
+	if filter.MaxLatitude != nil {
+		query = query.Where("latitude <= ?", *filter.MaxLatitude)
+	}
+	if filter.MinLongitude != nil {
+		query = query.Where("longitude >= ?", *filter.MinLongitude)
+	}
+	if filter.MaxLongitude != nil {
+		query = query.Where("longitude <= ?", *filter.MaxLongitude)
+	}
+	if filter.IDs != nil {
+		query = query.Where("id IN (?)", filter.IDs)
+	}
+
+	var cities []models.City
+	total, err := paginate(query, "cities", "", page, &cities)
+	return cities, total, err
+}
+
+func (r *gormCityRepository) Create(city *models.City) error {
+	return r.db.Create(city).Error
+}
+
+func (r *gormCityRepository) Update(city *models.City) error {
+	return r.db.Save(city).Error
+}
+
+func (r *gormCityRepository) Delete(city *models.City) error {
+	return r.db.Delete(city).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"landmarksmodule/db"
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormCountryRepository struct {
+	db      *gorm.DB
+	dialect db.Dialect
+}
+
+func (r *gormCountryRepository) List(page Page) ([]models.Country, int64, error) {
+	var countries []models.Country
+	total, err := paginate(r.db, "countries", "", page, &countries)
+	return countries, total, err
+}
+
+func (r *gormCountryRepository) Get(id uint) (*models.Country, error) {
+	var country models.Country
+	if err := first(r.db, &country, id); err != nil {
+		return nil, err
+	}
+	return &country, nil
+}
+
+func (r *gormCountryRepository) Nearest(latitude, longitude float64) (*models.Country, error) {
+	distance, distanceArgs := r.dialect.Distance("latitude", "longitude", latitude, longitude)
+	query := `
+		SELECT *,
+		       ` + distance + ` AS distance
+		FROM countries
+		WHERE deleted_at IS NULL
+		ORDER BY distance
+		LIMIT 1`
+
+	var countries []models.Country
+	if err := r.db.Raw(query, distanceArgs...).Scan(&countries).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if len(countries) == 0 {
+		return nil, ErrNotFound
+	}
+	return &countries[0], nil
+}
+
+func (r *gormCountryRepository) Create(country *models.Country) error {
+	return r.db.Create(country).Error
+}
+
+func (r *gormCountryRepository) Update(country *models.Country) error {
+	return r.db.Save(country).Error
+}
+
+func (r *gormCountryRepository) Delete(country *models.Country) error {
+	return r.db.Delete(country).Error
+}
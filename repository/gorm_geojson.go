@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormGeoJSONRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormGeoJSONRepository) List(page Page) ([]models.GeoJSON, int64, error) {
+	var geoJSONRecords []models.GeoJSON
+	total, err := paginate(r.db, "geo_jsons", "", page, &geoJSONRecords)
+	return geoJSONRecords, total, err
+}
+
+func (r *gormGeoJSONRepository) GetByRegion(regionID uint) (*models.GeoJSON, error) {
+	var geoJSON models.GeoJSON
+	if err := r.db.Where("region_id = ?", regionID).First(&geoJSON).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &geoJSON, nil
+}
+
+func (r *gormGeoJSONRepository) Create(geoJSON *models.GeoJSON) error {
+	return r.db.Create(geoJSON).Error
+}
+
+func (r *gormGeoJSONRepository) Update(geoJSON *models.GeoJSON) error {
+	return r.db.Save(geoJSON).Error
+}
+
+func (r *gormGeoJSONRepository) ReplaceSimplifications(geoJSONID uint, simplifications []models.GeoJSONSimplification) error {
+	tx := r.db.Begin()
+	if err := tx.Where("geo_json_id = ?", geoJSONID).Delete(&models.GeoJSONSimplification{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range simplifications {
+		simplifications[i].GeoJSONID = geoJSONID
+		if err := tx.Create(&simplifications[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// mapViewColumns are the columns of geo_jsons loaded when the boundaries themselves are omitted
+const mapViewColumns = "id, region_id, middle_latitude, middle_longitude, bbox_min_latitude, bbox_min_longitude, " +
+	"bbox_max_latitude, bbox_max_longitude, zoom, created_at, updated_at"
+
+func (r *gormGeoJSONRepository) LoadRegionGeoJSON(regions []models.Region, detail GeometryDetail) error {
+	ids := make([]uint, len(regions))
+	for i := range regions {
+		ids[i] = regions[i].ID
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := r.db.Where("region_id IN (?)", ids).Order("id")
+	if detail.Omit {
+		query = query.Select(mapViewColumns)
+	}
+	var geoJSONRecords []models.GeoJSON
+	if err := query.Find(&geoJSONRecords).Error; err != nil {
+		return err
+	}
+
+	// Like GetByRegion, the first record of a region wins
+	byRegion := map[uint]*models.GeoJSON{}
+	for i, geoJSON := range geoJSONRecords {
+		if _, ok := byRegion[geoJSON.RegionID]; !ok {
+			byRegion[geoJSON.RegionID] = &geoJSONRecords[i]
+		}
+	}
+
+	if !detail.Omit && detail.Zoom != nil && len(byRegion) > 0 {
+		recordIDs := make([]uint, 0, len(byRegion))
+		for _, geoJSON := range byRegion {
+			recordIDs = append(recordIDs, geoJSON.ID)
+		}
+		var simplifications []models.GeoJSONSimplification
+		if err := r.db.Where("geo_json_id IN (?) AND zoom = ?", recordIDs, *detail.Zoom).Find(&simplifications).Error; err != nil {
+			return err
+		}
+		simplified := map[uint]string{}
+		for _, simplification := range simplifications {
+			simplified[simplification.GeoJSONID] = simplification.GeoJSONData
+		}
+		for _, geoJSON := range byRegion {
+			if data, ok := simplified[geoJSON.ID]; ok {
+				geoJSON.GeoJSONData = data
+			}
+		}
+	}
+
+	for i := range regions {
+		if geoJSON, ok := byRegion[regions[i].ID]; ok {
+			regions[i].GeoJSON = geoJSON.GeoJSONData
+			regions[i].Map = &geoJSON.MapView
+		}
+	}
+	return nil
+}
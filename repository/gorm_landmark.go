@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"landmarksmodule/db"
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormLandmarkRepository struct {
+	db      *gorm.DB
+	dialect db.Dialect
+}
+
+func (r *gormLandmarkRepository) List(page Page) ([]models.Landmark, int64, error) {
+	var landmarks []models.Landmark
+	total, err := paginate(r.db, "landmarks", "", page, &landmarks)
+	return landmarks, total, err
+}
+
+func (r *gormLandmarkRepository) Get(id uint) (*models.Landmark, error) {
+	var landmark models.Landmark
+	if err := first(r.db, &landmark, id); err != nil {
+		return nil, err
+	}
+	return &landmark, nil
+}
+
+func (r *gormLandmarkRepository) Search(keyword string, page Page) ([]models.Landmark, int64, error) {
+	query := r.db.Where("name LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+
+	var landmarks []models.Landmark
+	total, err := paginate(query, "landmarks", "", page, &landmarks)
+	return landmarks, total, err
+}
+
+// landmarkRating is the average rating of a row of landmarks, NULL when it has no review
+const landmarkRating = "(SELECT AVG(reviews.rating) FROM reviews WHERE reviews.landmark_id = landmarks.id AND reviews.deleted_at IS NULL)"
+
+// landmarkHasPhotos tells whether a row of landmarks has photos
+const landmarkHasPhotos = "EXISTS (SELECT 1 FROM landmark_photos WHERE landmark_photos.landmark_id = landmarks.id AND landmark_photos.deleted_at IS NULL)"
+
+// ratingBucketConditions selects the landmarks of each rating bucket, see RatingBucketOf
+var ratingBucketConditions = map[RatingBucket]string{
+	Unrated:    landmarkRating + " IS NULL",
+	Rating1To2: landmarkRating + " < 2",
+	Rating2To3: landmarkRating + " >= 2 AND " + landmarkRating + " < 3",
+	Rating3To4: landmarkRating + " >= 3 AND " + landmarkRating + " < 4",
+	Rating4To5: landmarkRating + " >= 4",
+}
+
+// filterLandmarks restricts query to the landmarks matching the filter, ignoring the filter on the
+// given facet. Columns are qualified so that the facets may join other tables.
+func filterLandmarks(query *gorm.DB, filter LandmarkFilter, ignored string) *gorm.DB {
+	if filter.Keyword != "" {
+		query = query.Where("landmarks.name LIKE ? OR landmarks.description LIKE ?", "%"+filter.Keyword+"%", "%"+filter.Keyword+"%")
+	}
+	if len(filter.CityIDs) > 0 && ignored != FacetCity {
+		query = query.Where("landmarks.city_id IN (?)", filter.CityIDs)
+	}
+	if len(filter.RegionIDs) > 0 && ignored != FacetRegion {
+		query = query.Where("landmarks.city_id IN (SELECT cities.id FROM cities WHERE cities.region_id IN (?))", filter.RegionIDs)
+	}
+	if len(filter.Types) > 0 && ignored != FacetType {
+		query = query.Where("landmarks.type IN (?)", filter.Types)
+	}
+	if len(filter.RatingBuckets) > 0 && ignored != FacetRating {
+		conditions := make([]string, len(filter.RatingBuckets))
+		for i, bucket := range filter.RatingBuckets {
+			conditions[i] = ratingBucketConditions[bucket]
+		}
+		query = query.Where(strings.Join(conditions, " OR "))
+	}
+	if filter.HasPhotos != nil && ignored != FacetHasPhotos {
+		if *filter.HasPhotos {
+			query = query.Where(landmarkHasPhotos)
+		} else {
+			query = query.Where("NOT " + landmarkHasPhotos)
+		}
+	}
+	if filter.MinLatitude != nil {
+		query = query.Where("landmarks.latitude >= ?", *filter.MinLatitude)
+	}
+	if filter.MaxLatitude != nil {
+		query = query.Where("landmarks.latitude <= ?", *filter.MaxLatitude)
+	}
+	if filter.MinLongitude != nil {
+		query = query.Where("landmarks.longitude >= ?", *filter.MinLongitude)
+	}
+	if filter.MaxLongitude != nil {
+		query = query.Where("landmarks.longitude <= ?", *filter.MaxLongitude)
+	}
+	if filter.IDs != nil {
+		query = query.Where("landmarks.id IN (?)", filter.IDs)
+	}
+	return query
+}
+
+func (r *gormLandmarkRepository) Filter(filter LandmarkFilter, page Page) ([]models.Landmark, int64, error) {
+	var landmarks []models.Landmark
+	total, err := paginate(filterLandmarks(r.db, filter, ""), "landmarks", "", page, &landmarks)
+	return landmarks, total, err
+}
+
+func (r *gormLandmarkRepository) Facets(filter LandmarkFilter) (*LandmarkFacets, error) {
+	// count groups the landmarks by the value expression and the optional label expression,
+	// the most frequent value first
+	count := func(facet, value, label string, joins ...string) ([]FacetCount, error) {
+		query := filterLandmarks(r.db.Model(&models.Landmark{}), filter, facet)
+		for _, join := range joins {
+			query = query.Joins(join)
+		}
+		group := value
+		if label != "" {
+			group += ", " + label
+		} else {
+			label = "''"
+		}
+		var counts []FacetCount
+		err := query.Select(value + " as value, " + label + " as label, COUNT(*) as count").
+			Group(group).
+			Order("count desc, value").
+			Scan(&counts).Error
+		return counts, err
+	}
+
+	rating := "CASE"
+	for _, bucket := range RatingBuckets {
+		rating += " WHEN " + ratingBucketConditions[bucket] + " THEN '" + string(bucket) + "'"
+	}
+	rating += " END"
+
+	facets := &LandmarkFacets{}
+	var err error
+	if facets.Types, err = count(FacetType, "landmarks.type", ""); err != nil {
+		return nil, err
+	}
+	if facets.Cities, err = count(FacetCity, "landmarks.city_id", "COALESCE(cities.name, '')",
+		"LEFT JOIN cities ON cities.id = landmarks.city_id"); err != nil {
+		return nil, err
+	}
+	if facets.Regions, err = count(FacetRegion, "cities.region_id", "COALESCE(regions.name, '')",
+		"JOIN cities ON cities.id = landmarks.city_id", "LEFT JOIN regions ON regions.id = cities.region_id"); err != nil {
+		return nil, err
+	}
+
+	ratings, err := count(FacetRating, rating, "")
+	if err != nil {
+		return nil, err
+	}
+	ratingCounts := map[RatingBucket]int64{}
+	for _, row := range ratings {
+		ratingCounts[RatingBucket(row.Value)] = row.Count
+	}
+	facets.Ratings = RatingFacet(ratingCounts)
+
+	// The CASE turns the condition into 0 or 1 whichever boolean type the database has
+	photos, err := count(FacetHasPhotos, "CASE WHEN "+landmarkHasPhotos+" THEN 1 ELSE 0 END", "")
+	if err != nil {
+		return nil, err
+	}
+	var with, without int64
+	for _, row := range photos {
+		if row.Value == "1" {
+			with = row.Count
+		} else {
+			without = row.Count
+		}
+	}
+	facets.HasPhotos = HasPhotosFacet(with, without)
+	return facets, nil
+}
+
+func (r *gormLandmarkRepository) ListByCity(cityID uint, page Page) ([]models.Landmark, int64, error) {
+	var landmarks []models.Landmark
+	total, err := paginate(r.db.Where("city_id = ?", cityID), "landmarks", "", page, &landmarks)
+	return landmarks, total, err
+}
+
+func (r *gormLandmarkRepository) ListByRegion(regionID uint, page Page) ([]models.Landmark, int64, error) {
+	query := r.db.Joins("JOIN cities ON landmarks.city_id = cities.id").
+		Where("cities.region_id = ?", regionID)
+
+	var landmarks []models.Landmark
+	total, err := paginate(query, "landmarks", "", page, &landmarks)
+	return landmarks, total, err
+}
+
+func (r *gormLandmarkRepository) Nearby(query NearbyQuery) ([]NearbyLandmark, error) {
+	distance, args := r.dialect.Distance("l.latitude", "l.longitude", query.Latitude, query.Longitude)
+	// The bounding box of the search circle lets the coordinates index discard far landmarks
+	// before distances are computed
+	box := geo.Around(query.Latitude, query.Longitude, query.RadiusKm)
+	args = append(args, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng)
+
+	where := ""
+	if query.Type != "" {
+		where = "AND l.type = ?"
+		args = append(args, query.Type)
+	}
+	args = append(args, query.RadiusKm)
+
+	var landmarks []NearbyLandmark
+	err := r.db.Raw(`
+		SELECT 
+			l.*, 
+			COALESCE(AVG(r.rating), 0) as average_rating, 
+			COUNT(r.id) as review_count,
+			`+distance+` as distance
+		FROM 
+			landmarks l
+		LEFT JOIN 
+			reviews r ON l.id = r.landmark_id AND r.deleted_at IS NULL
+		WHERE 
+			l.deleted_at IS NULL
+			AND l.latitude BETWEEN ? AND ?
+			AND l.longitude BETWEEN ? AND ?
+			`+where+`
+		GROUP BY 
+			l.id
+		HAVING 
+			distance <= ?
+		ORDER BY 
+			distance ASC, l.id
+	`, args...).Scan(&landmarks).Error
+	return landmarks, err
+}
+
+// inBox restricts query to the landmarks of the viewport
+func inBox(query *gorm.DB, mapQuery MapQuery) *gorm.DB {
+	box := mapQuery.Box
+	query = query.Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+	if box.CrossesAntimeridian() {
+		query = query.Where("(longitude >= ? OR longitude <= ?)", box.MinLng, box.MaxLng)
+	} else {
+		query = query.Where("longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+	}
+	if mapQuery.Type != "" {
+		query = query.Where("type = ?", mapQuery.Type)
+	}
+	return query
+}
+
+func (r *gormLandmarkRepository) InBox(query MapQuery, limit int) ([]models.Landmark, int64, error) {
+	var total int64
+	if err := inBox(r.db.Model(&models.Landmark{}), query).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total > int64(limit) {
+		return nil, total, nil
+	}
+
+	var landmarks []models.Landmark
+	err := inBox(r.db, query).Order("id").Find(&landmarks).Error
+	return landmarks, total, err
+}
+
+func (r *gormLandmarkRepository) Clusters(query MapQuery, cellSize float64) ([]Cluster, error) {
+	size := strconv.FormatFloat(cellSize, 'f', -1, 64)
+	row := r.dialect.Floor("(latitude + 90) / " + size)
+	column := r.dialect.Floor("(longitude + 180) / " + size)
+
+	var clusters []Cluster
+	err := inBox(r.db.Model(&models.Landmark{}), query).
+		Select(`COUNT(*) as count, AVG(latitude) as latitude, AVG(longitude) as longitude,
+			MIN(latitude) as min_latitude, MIN(longitude) as min_longitude,
+			MAX(latitude) as max_latitude, MAX(longitude) as max_longitude,
+			MIN(id) as landmark_id`).
+		Group(row + ", " + column).
+		Order("landmark_id").
+		Scan(&clusters).Error
+	return clusters, err
+}
+
+func (r *gormLandmarkRepository) Create(landmark *models.Landmark) error {
+	return r.db.Create(landmark).Error
+}
+
+func (r *gormLandmarkRepository) CreateBatch(landmarks []models.Landmark) error {
+	tx := r.db.Begin()
+	for i := range landmarks {
+		if err := tx.Create(&landmarks[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormLandmarkRepository) UpsertByExternalRef(landmarks []models.Landmark) (UpsertCounts, error) {
+	var counts UpsertCounts
+	tx := r.db.Begin()
+	for i := range landmarks {
+		var stored models.Landmark
+		err := gorm.ErrRecordNotFound
+		if landmarks[i].ExternalRef != nil {
+			err = tx.Unscoped().Where("external_ref = ?", *landmarks[i].ExternalRef).First(&stored).Error
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = tx.Create(&landmarks[i]).Error
+			counts.Created++
+		case err != nil:
+		case stored.DeletedAt != nil:
+			counts.Deleted++
+		case ApplyImport(&stored, landmarks[i]):
+			err = tx.Save(&stored).Error
+			counts.Updated++
+		default:
+			counts.Unchanged++
+		}
+		if err != nil {
+			tx.Rollback()
+			return UpsertCounts{}, err
+		}
+	}
+	return counts, tx.Commit().Error
+}
+
+func (r *gormLandmarkRepository) Update(landmark *models.Landmark) error {
+	return r.db.Save(landmark).Error
+}
+
+func (r *gormLandmarkRepository) Delete(landmark *models.Landmark) error {
+	return r.db.Delete(landmark).Error
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormLandmarkTypeRepository struct {
+	db *gorm.DB
+}
+
+// loadLabels fills the labels of the types
+func (r *gormLandmarkTypeRepository) loadLabels(landmarkTypes []models.LandmarkType) error {
+	ids := make([]uint, len(landmarkTypes))
+	for i := range landmarkTypes {
+		ids[i] = landmarkTypes[i].ID
+		landmarkTypes[i].Labels = map[string]string{}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var labels []models.LandmarkTypeLabel
+	if err := r.db.Where("landmark_type_id IN (?)", ids).Find(&labels).Error; err != nil {
+		return err
+	}
+	byType := map[uint]map[string]string{}
+	for i := range landmarkTypes {
+		byType[landmarkTypes[i].ID] = landmarkTypes[i].Labels
+	}
+	for _, label := range labels {
+		byType[label.LandmarkTypeID][label.Language] = label.Label
+	}
+	return nil
+}
+
+// saveLabels replaces the stored labels of the type with its Labels
+func saveLabels(tx *gorm.DB, landmarkType *models.LandmarkType) error {
+	if err := tx.Where("landmark_type_id = ?", landmarkType.ID).Delete(&models.LandmarkTypeLabel{}).Error; err != nil {
+		return err
+	}
+	for language, label := range landmarkType.Labels {
+		row := models.LandmarkTypeLabel{LandmarkTypeID: landmarkType.ID, Language: language, Label: label}
+		if err := tx.Create(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *gormLandmarkTypeRepository) List(page Page) ([]models.LandmarkType, int64, error) {
+	var landmarkTypes []models.LandmarkType
+	total, err := paginate(r.db, "landmark_types", "", page, &landmarkTypes)
+	if err != nil {
+		return nil, 0, err
+	}
+	return landmarkTypes, total, r.loadLabels(landmarkTypes)
+}
+
+func (r *gormLandmarkTypeRepository) Get(id uint) (*models.LandmarkType, error) {
+	landmarkTypes := make([]models.LandmarkType, 1)
+	if err := first(r.db, &landmarkTypes[0], id); err != nil {
+		return nil, err
+	}
+	return &landmarkTypes[0], r.loadLabels(landmarkTypes)
+}
+
+func (r *gormLandmarkTypeRepository) GetBySlug(slug string) (*models.LandmarkType, error) {
+	landmarkTypes := make([]models.LandmarkType, 1)
+	if err := translateError(r.db.Where("slug = ?", slug).First(&landmarkTypes[0]).Error); err != nil {
+		return nil, err
+	}
+	return &landmarkTypes[0], r.loadLabels(landmarkTypes)
+}
+
+func (r *gormLandmarkTypeRepository) Create(landmarkType *models.LandmarkType) error {
+	tx := r.db.Begin()
+	if err := tx.Create(landmarkType).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveLabels(tx, landmarkType); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormLandmarkTypeRepository) Update(landmarkType *models.LandmarkType) error {
+	var stored models.LandmarkType
+	if err := first(r.db, &stored, landmarkType.ID); err != nil {
+		return err
+	}
+
+	tx := r.db.Begin()
+	if err := tx.Save(landmarkType).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := saveLabels(tx, landmarkType); err != nil {
+		tx.Rollback()
+		return err
+	}
+	// Deleted landmarks are renamed as well, in case they are restored
+	if stored.Slug != landmarkType.Slug {
+		err := tx.Unscoped().Model(&models.Landmark{}).Where("type = ?", stored.Slug).UpdateColumn("type", landmarkType.Slug).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormLandmarkTypeRepository) Delete(landmarkType *models.LandmarkType) error {
+	tx := r.db.Begin()
+	if err := tx.Where("landmark_type_id = ?", landmarkType.ID).Delete(&models.LandmarkTypeLabel{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(landmarkType).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormLandmarkTypeRepository) EnsureSlugs(slugs []string) (int, error) {
+	var existing []string
+	if err := r.db.Model(&models.LandmarkType{}).Pluck("slug", &existing).Error; err != nil {
+		return 0, err
+	}
+	known := map[string]bool{}
+	for _, slug := range existing {
+		known[slug] = true
+	}
+
+	created := 0
+	tx := r.db.Begin()
+	for _, slug := range slugs {
+		if slug == "" || known[slug] {
+			continue
+		}
+		known[slug] = true
+		if err := tx.Create(&models.LandmarkType{Slug: slug, Name: models.LandmarkTypeName(slug)}).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		created++
+	}
+	return created, tx.Commit().Error
+}
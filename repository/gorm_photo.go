@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormPhotoRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormPhotoRepository) ListLandmarkPhotos(page Page) ([]models.LandmarkPhoto, int64, error) {
+	var photos []models.LandmarkPhoto
+	total, err := paginate(r.db, "landmark_photos", "", page, &photos)
+	return photos, total, err
+}
+
+func (r *gormPhotoRepository) GetLandmarkPhoto(id uint) (*models.LandmarkPhoto, error) {
+	var photo models.LandmarkPhoto
+	if err := first(r.db, &photo, id); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+func (r *gormPhotoRepository) ListLandmarkPhotosByLandmark(landmarkID uint) ([]models.LandmarkPhoto, error) {
+	var photos []models.LandmarkPhoto
+	err := r.db.Where("landmark_id = ?", landmarkID).Find(&photos).Error
+	return photos, err
+}
+
+func (r *gormPhotoRepository) CreateLandmarkPhoto(photo *models.LandmarkPhoto) error {
+	return r.db.Create(photo).Error
+}
+
+func (r *gormPhotoRepository) UpdateLandmarkPhoto(photo *models.LandmarkPhoto) error {
+	return r.db.Save(photo).Error
+}
+
+func (r *gormPhotoRepository) DeleteLandmarkPhoto(photo *models.LandmarkPhoto) error {
+	return r.db.Delete(photo).Error
+}
+
+func (r *gormPhotoRepository) ListReviewPhotos(page Page) ([]models.ReviewPhoto, int64, error) {
+	var photos []models.ReviewPhoto
+	total, err := paginate(r.db, "review_photos", "", page, &photos)
+	return photos, total, err
+}
+
+func (r *gormPhotoRepository) GetReviewPhoto(id uint) (*models.ReviewPhoto, error) {
+	var photo models.ReviewPhoto
+	if err := first(r.db, &photo, id); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+func (r *gormPhotoRepository) ListReviewPhotosByReview(reviewID uint) ([]models.ReviewPhoto, error) {
+	var photos []models.ReviewPhoto
+	err := r.db.Where("review_id = ?", reviewID).Find(&photos).Error
+	return photos, err
+}
+
+func (r *gormPhotoRepository) CreateReviewPhoto(photo *models.ReviewPhoto) error {
+	return r.db.Create(photo).Error
+}
+
+func (r *gormPhotoRepository) UpdateReviewPhoto(photo *models.ReviewPhoto) error {
+	return r.db.Save(photo).Error
+}
+
+func (r *gormPhotoRepository) DeleteReviewPhoto(photo *models.ReviewPhoto) error {
+	return r.db.Delete(photo).Error
+}
+
+func (r *gormPhotoRepository) LoadLandmarkPhotoLinks(landmarks []models.Landmark) error {
+	ids := make([]uint, len(landmarks))
+	for i := range landmarks {
+		ids[i] = landmarks[i].ID
+	}
+
+	var photos []models.LandmarkPhoto
+	if len(ids) > 0 {
+		if err := r.db.Where("landmark_id IN (?)", ids).Order("id").Find(&photos).Error; err != nil {
+			return err
+		}
+	}
+
+	photoLinks := map[uint][]string{}
+	for _, photo := range photos {
+		photoLinks[photo.LandmarkID] = append(photoLinks[photo.LandmarkID], photo.Path)
+	}
+	for i := range landmarks {
+		landmarks[i].Photos = nil // Clear Photos field
+		landmarks[i].PhotoLinks = photoLinks[landmarks[i].ID]
+	}
+	return nil
+}
+
+func (r *gormPhotoRepository) LoadReviewPhotoLinks(reviews []models.Review) error {
+	ids := make([]uint, len(reviews))
+	for i := range reviews {
+		ids[i] = reviews[i].ID
+	}
+
+	var photos []models.ReviewPhoto
+	if len(ids) > 0 {
+		if err := r.db.Where("review_id IN (?)", ids).Order("id").Find(&photos).Error; err != nil {
+			return err
+		}
+	}
+
+	photoLinks := map[uint][]string{}
+	for _, photo := range photos {
+		photoLinks[photo.ReviewID] = append(photoLinks[photo.ReviewID], photo.Path)
+	}
+	for i := range reviews {
+		reviews[i].Photos = nil // Clear Photos field
+		reviews[i].PhotoLinks = photoLinks[reviews[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormRegionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRegionRepository) List(page Page) ([]models.Region, int64, error) {
+	var regions []models.Region
+	total, err := paginate(r.db, "regions", "", page, &regions)
+	return regions, total, err
+}
+
+func (r *gormRegionRepository) ListByCountry(countryID uint) ([]models.Region, error) {
+	var regions []models.Region
+	err := r.db.Where("country_id = ?", countryID).Find(&regions).Error
+	return regions, err
+}
+
+func (r *gormRegionRepository) ListByCountries(countryIDs []uint) ([]models.Region, error) {
+	var regions []models.Region
+	if len(countryIDs) == 0 {
+		return regions, nil
+	}
+	err := r.db.Where("country_id IN (?)", countryIDs).Order("id").Find(&regions).Error
+	return regions, err
+}
+
+func (r *gormRegionRepository) ListByIDs(ids []uint) ([]models.Region, error) {
+	var regions []models.Region
+	if len(ids) == 0 {
+		return regions, nil
+	}
+	err := r.db.Where("id IN (?)", ids).Order("id").Find(&regions).Error
+	return regions, err
+}
+
+func (r *gormRegionRepository) Get(id uint) (*models.Region, error) {
+	var region models.Region
+	if err := first(r.db, &region, id); err != nil {
+		return nil, err
+	}
+	return &region, nil
+}
+
+func (r *gormRegionRepository) Search(name string, page Page) ([]models.Region, int64, error) {
+	var regions []models.Region
+	total, err := paginate(r.db.Where("name LIKE ?", "%"+name+"%"), "regions", "", page, &regions)
+	return regions, total, err
+}
+
+func (r *gormRegionRepository) Filter(filter RegionFilter, page Page) ([]models.Region, int64, error) {
+	query := r.db
+	if filter.MinPopulation != nil {
+		query = query.Where("population >= ?", *filter.MinPopulation)
+	}
+	if filter.MaxPopulation != nil {
+		query = query.Where("population <= ?", *filter.MaxPopulation)
+	}
+
+	var regions []models.Region
+	total, err := paginate(query, "regions", "", page, &regions)
+	return regions, total, err
+}
+
+func (r *gormRegionRepository) Create(region *models.Region) error {
+	return r.db.Create(region).Error
+}
+
+func (r *gormRegionRepository) Update(region *models.Region) error {
+	return r.db.Save(region).Error
+}
+
+func (r *gormRegionRepository) Delete(region *models.Region) error {
+	return r.db.Delete(region).Error
+}
+
+func (r *gormRegionRepository) AssignCountry(countryID uint, regionIDs []uint) error {
+	return r.db.Model(&models.Region{}).
+		Where("id IN (?)", regionIDs).
+		Update("country_id", countryID).Error
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"landmarksmodule/db"
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormReviewRepository struct {
+	db      *gorm.DB
+	dialect db.Dialect
+}
+
+func (r *gormReviewRepository) List(page Page) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	total, err := paginate(r.db, "reviews", "", page, &reviews)
+	return reviews, total, err
+}
+
+func (r *gormReviewRepository) Get(id uint) (*models.Review, error) {
+	var review models.Review
+	if err := first(r.db, &review, id); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *gormReviewRepository) ListByLandmark(landmarkID uint, page Page) ([]models.Review, int64, error) {
+	page.AfterID = 0
+
+	var reviews []models.Review
+	total, err := paginate(r.db.Where("landmark_id = ?", landmarkID), "reviews", "created_at desc, id desc", page, &reviews)
+	return reviews, total, err
+}
+
+func (r *gormReviewRepository) ListLatestByLandmarks(landmarkIDs []uint, limit int) ([]models.Review, error) {
+	var reviews []models.Review
+	if len(landmarkIDs) == 0 {
+		return reviews, nil
+	}
+
+	// Number the reviews of each landmark newest first and keep the first ones
+	err := r.db.Raw(`
+		SELECT *
+		FROM (
+			SELECT 
+				reviews.*, 
+				ROW_NUMBER() OVER (PARTITION BY landmark_id ORDER BY created_at DESC, id DESC) AS position
+			FROM 
+				reviews
+			WHERE 
+				landmark_id IN (?) AND deleted_at IS NULL
+		) ranked
+		WHERE 
+			position <= ?
+		ORDER BY 
+			landmark_id, position
+	`, landmarkIDs, limit).Scan(&reviews).Error
+	return reviews, err
+}
+
+func (r *gormReviewRepository) ListByDevice(deviceID string, page Page) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	total, err := paginate(r.db.Where("device_id = ?", deviceID), "reviews", "", page, &reviews)
+	return reviews, total, err
+}
+
+func (r *gormReviewRepository) ListByLandmarkAndDevice(landmarkID uint, deviceID string) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.Where("landmark_id = ? AND device_id = ?", landmarkID, deviceID).Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *gormReviewRepository) Search(keyword string, page Page) ([]models.Review, int64, error) {
+	pattern := "%" + keyword + "%"
+	query := r.db.Where("name LIKE ? OR comment LIKE ? OR "+r.dialect.CastText("rating")+" LIKE ?", pattern, pattern, pattern)
+
+	var reviews []models.Review
+	total, err := paginate(query, "reviews", "", page, &reviews)
+	return reviews, total, err
+}
+
+func (r *gormReviewRepository) Filter(filter ReviewFilter, page Page) ([]models.Review, int64, error) {
+	query := r.db
+	if filter.MinRating != nil {
+		query = query.Where("rating >= ?", *filter.MinRating)
+	}
+	if filter.MaxRating != nil {
+		query = query.Where("rating <= ?", *filter.MaxRating)
+	}
+
+	var reviews []models.Review
+	total, err := paginate(query, "reviews", "", page, &reviews)
+	return reviews, total, err
+}
+
+func (r *gormReviewRepository) Stats(landmarkID uint) (ReviewStats, error) {
+	var stats ReviewStats
+	err := r.db.Model(&models.Review{}).
+		Select("COUNT(*) as review_count, COALESCE(AVG(rating), 0) as average_rating").
+		Where("landmark_id = ?", landmarkID).
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *gormReviewRepository) StatsByLandmarks(landmarkIDs []uint) (map[uint]ReviewStats, error) {
+	stats := map[uint]ReviewStats{}
+	if len(landmarkIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		LandmarkID    uint
+		ReviewCount   int64
+		AverageRating float64
+	}
+	err := r.db.Model(&models.Review{}).
+		Select("landmark_id, COUNT(*) as review_count, COALESCE(AVG(rating), 0) as average_rating").
+		Where("landmark_id IN (?)", landmarkIDs).
+		Group("landmark_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		stats[row.LandmarkID] = ReviewStats{ReviewCount: row.ReviewCount, AverageRating: row.AverageRating}
+	}
+	return stats, err
+}
+
+func (r *gormReviewRepository) OverallStats() (ReviewStats, error) {
+	var stats ReviewStats
+	err := r.db.Model(&models.Review{}).
+		Select("COUNT(*) as review_count, COALESCE(AVG(rating), 0) as average_rating").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *gormReviewRepository) Create(review *models.Review) error {
+	return r.db.Create(review).Error
+}
+
+func (r *gormReviewRepository) Update(review *models.Review) error {
+	return r.db.Save(review).Error
+}
+
+func (r *gormReviewRepository) Delete(review *models.Review) error {
+	tx := r.db.Begin()
+	if err := tx.Where("review_id = ?", review.ID).Delete(&models.ReviewPhoto{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(review).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"sort"
+
+	"landmarksmodule/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+type gormTranslationRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormTranslationRepository) ListByEntity(entityType string, entityID uint) ([]models.Translation, error) {
+	var translations []models.Translation
+	err := r.db.Where("entity_type = ? AND entity_id = ?", entityType, entityID).Order("locale, field").Find(&translations).Error
+	return translations, err
+}
+
+func (r *gormTranslationRepository) ListByEntities(entityType string, entityIDs []uint, locales []string) ([]models.Translation, error) {
+	query := r.db.Where("entity_type = ?", entityType)
+	if entityIDs != nil {
+		query = query.Where("entity_id IN (?)", entityIDs)
+	}
+	if locales != nil {
+		query = query.Where("locale IN (?)", locales)
+	}
+	var translations []models.Translation
+	err := query.Order("entity_id, locale, field").Find(&translations).Error
+	return translations, err
+}
+
+func (r *gormTranslationRepository) SetLocale(entityType string, entityID uint, locale string, texts map[string]string) error {
+	fields := make([]string, 0, len(texts))
+	for field := range texts {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	tx := r.db.Begin()
+	err := tx.Where("entity_type = ? AND entity_id = ? AND locale = ?", entityType, entityID, locale).Delete(&models.Translation{}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, field := range fields {
+		translation := models.Translation{EntityType: entityType, EntityID: entityID, Field: field, Locale: locale, Text: texts[field]}
+		if err := tx.Create(&translation).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *gormTranslationRepository) DeleteLocale(entityType string, entityID uint, locale string) (int64, error) {
+	result := r.db.Where("entity_type = ? AND entity_id = ? AND locale = ?", entityType, entityID, locale).Delete(&models.Translation{})
+	return result.RowsAffected, result.Error
+}
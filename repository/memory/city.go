@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"math"
+	"strings"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type cityRepository struct {
+	s *store
+}
+
+func (r *cityRepository) List(page repository.Page) ([]models.City, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	cities, total := paginateSorted(r.s.cities.list(nil), page, cityID, compareCities)
+	return cities, total, nil
+}
+
+func (r *cityRepository) Get(id uint) (*models.City, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.cities.get(id)
+}
+
+func (r *cityRepository) Nearest(latitude, longitude float64, regionID uint) (*models.City, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var nearest *models.City
+	best := math.Inf(1)
+	for _, city := range r.s.cities.list(nil) {
+		if regionID != 0 && city.RegionID != regionID {
+			continue
+		}
+		distance := distanceKm(latitude, longitude, city.Latitude, city.Longitude)
+		if distance < best {
+			city := city
+			nearest, best = &city, distance
+		}
+	}
+	if nearest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return nearest, nil
+}
+
+func (r *cityRepository) Search(name string, page repository.Page) ([]models.City, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	cities, total := paginateSorted(r.s.cities.list(func(city *models.City) bool {
+		return contains(city.Name, name)
+	}), page, cityID, compareCities)
+	return cities, total, nil
+}
+
+func (r *cityRepository) ListByName(name string) ([]models.City, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.cities.list(func(city *models.City) bool {
+		return strings.EqualFold(city.Name, name)
+	}), nil
+}
+
+func (r *cityRepository) Filter(filter repository.CityFilter, page repository.Page) ([]models.City, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	ids := idSet(filter.IDs)
+	cities, total := paginateSorted(r.s.cities.list(func(city *models.City) bool {
+		return (ids == nil || ids[city.ID]) &&
+			inIntRange(city.Population, filter.MinPopulation, filter.MaxPopulation) &&
+			inRange(city.Area, filter.MinArea, filter.MaxArea) &&
+			inRange(city.Latitude, filter.MinLatitude, filter.MaxLatitude) &&
+			inRange(city.Longitude, filter.MinLongitude, filter.MaxLongitude)
+	}), page, cityID, compareCities)
+	return cities, total, nil
+}
+
+func (r *cityRepository) Create(city *models.City) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.cities.create(city)
+	return nil
+}
+
+func (r *cityRepository) Update(city *models.City) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.cities.save(city)
+	return nil
+}
+
+func (r *cityRepository) Delete(city *models.City) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.cities.delete(city.ID)
+	return nil
+}
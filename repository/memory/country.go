@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"math"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type countryRepository struct {
+	s *store
+}
+
+func (r *countryRepository) List(page repository.Page) ([]models.Country, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	countries, total := paginate(r.s.countries.list(nil), page, countryID)
+	return countries, total, nil
+}
+
+func (r *countryRepository) Get(id uint) (*models.Country, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.countries.get(id)
+}
+
+func (r *countryRepository) Nearest(latitude, longitude float64) (*models.Country, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var nearest *models.Country
+	best := math.Inf(1)
+	for _, country := range r.s.countries.list(nil) {
+		distance := distanceKm(latitude, longitude, country.Latitude, country.Longitude)
+		if distance < best {
+			country := country
+			nearest, best = &country, distance
+		}
+	}
+	if nearest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return nearest, nil
+}
+
+func (r *countryRepository) Create(country *models.Country) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.countries.create(country)
+	return nil
+}
+
+func (r *countryRepository) Update(country *models.Country) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.countries.save(country)
+	return nil
+}
+
+func (r *countryRepository) Delete(country *models.Country) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.countries.delete(country.ID)
+	return nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"sort"
+	"strconv"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+// landmarkFacts holds what the filters of a landmark depend on besides its own fields
+type landmarkFacts struct {
+	regionID  uint
+	located   bool
+	rating    repository.RatingBucket
+	hasPhotos bool
+}
+
+// landmarkMatcher matches landmarks against a filter
+type landmarkMatcher struct {
+	filter  repository.LandmarkFilter
+	ids     map[uint]bool
+	cities  map[uint]bool
+	regions map[uint]bool
+	types   map[string]bool
+	ratings map[repository.RatingBucket]bool
+	facts   map[uint]landmarkFacts
+}
+
+// landmarkMatcher gathers the facts of every landmark for the filter, the caller must hold the lock
+func (s *store) landmarkMatcher(filter repository.LandmarkFilter) *landmarkMatcher {
+	m := &landmarkMatcher{
+		filter:  filter,
+		ids:     idSet(filter.IDs),
+		cities:  valueSet(filter.CityIDs),
+		regions: valueSet(filter.RegionIDs),
+		types:   valueSet(filter.Types),
+		ratings: valueSet(filter.RatingBuckets),
+		facts:   map[uint]landmarkFacts{},
+	}
+
+	counts, sums := map[uint]int64{}, map[uint]int{}
+	for _, review := range s.reviews.rows {
+		counts[review.LandmarkID]++
+		sums[review.LandmarkID] += review.Rating
+	}
+	photos := map[uint]bool{}
+	for _, photo := range s.landmarkPhotos.rows {
+		photos[photo.LandmarkID] = true
+	}
+	for id, landmark := range s.landmarks.rows {
+		stats := repository.ReviewStats{ReviewCount: counts[id]}
+		if stats.ReviewCount > 0 {
+			stats.AverageRating = float64(sums[id]) / float64(stats.ReviewCount)
+		}
+		city, located := s.cities.rows[landmark.CityID]
+		m.facts[id] = landmarkFacts{
+			regionID:  city.RegionID,
+			located:   located,
+			rating:    repository.RatingBucketOf(stats),
+			hasPhotos: photos[id],
+		}
+	}
+	return m
+}
+
+// valueSet returns the set of the values of a filter, nil when the filter does not restrict them
+func valueSet[T comparable](values []T) map[T]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[T]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// match reports whether a landmark matches the filter, ignoring the filter on the given facet
+func (m *landmarkMatcher) match(landmark *models.Landmark, ignored string) bool {
+	facts := m.facts[landmark.ID]
+	filter := m.filter
+	return (filter.Keyword == "" || contains(landmark.Name, filter.Keyword) || contains(landmark.Description, filter.Keyword)) &&
+		(m.cities == nil || ignored == repository.FacetCity || m.cities[landmark.CityID]) &&
+		(m.regions == nil || ignored == repository.FacetRegion || facts.located && m.regions[facts.regionID]) &&
+		(m.types == nil || ignored == repository.FacetType || m.types[landmark.Type]) &&
+		(m.ratings == nil || ignored == repository.FacetRating || m.ratings[facts.rating]) &&
+		(filter.HasPhotos == nil || ignored == repository.FacetHasPhotos || facts.hasPhotos == *filter.HasPhotos) &&
+		inRange(landmark.Latitude, filter.MinLatitude, filter.MaxLatitude) &&
+		inRange(landmark.Longitude, filter.MinLongitude, filter.MaxLongitude) &&
+		(m.ids == nil || m.ids[landmark.ID])
+}
+
+func (r *landmarkRepository) Facets(filter repository.LandmarkFilter) (*repository.LandmarkFacets, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	matcher := r.s.landmarkMatcher(filter)
+
+	// count counts the landmarks by the value returned by key, skipping the ones without one
+	count := func(facet string, key func(landmark *models.Landmark) (string, bool)) map[string]int64 {
+		counts := map[string]int64{}
+		for _, landmark := range r.s.landmarks.rows {
+			if !matcher.match(&landmark, facet) {
+				continue
+			}
+			if value, ok := key(&landmark); ok {
+				counts[value]++
+			}
+		}
+		return counts
+	}
+
+	facets := &repository.LandmarkFacets{}
+	facets.Types = sortedFacet(count(repository.FacetType, func(landmark *models.Landmark) (string, bool) {
+		return landmark.Type, true
+	}), nil)
+	facets.Cities = sortedFacet(count(repository.FacetCity, func(landmark *models.Landmark) (string, bool) {
+		return strconv.FormatUint(uint64(landmark.CityID), 10), true
+	}), func(value string) string {
+		id, _ := strconv.ParseUint(value, 10, 64)
+		return r.s.cities.rows[uint(id)].Name
+	})
+	facets.Regions = sortedFacet(count(repository.FacetRegion, func(landmark *models.Landmark) (string, bool) {
+		facts := matcher.facts[landmark.ID]
+		return strconv.FormatUint(uint64(facts.regionID), 10), facts.located
+	}), func(value string) string {
+		id, _ := strconv.ParseUint(value, 10, 64)
+		return r.s.regions.rows[uint(id)].Name
+	})
+
+	ratings := map[repository.RatingBucket]int64{}
+	for value, n := range count(repository.FacetRating, func(landmark *models.Landmark) (string, bool) {
+		return string(matcher.facts[landmark.ID].rating), true
+	}) {
+		ratings[repository.RatingBucket(value)] = n
+	}
+	facets.Ratings = repository.RatingFacet(ratings)
+
+	photos := count(repository.FacetHasPhotos, func(landmark *models.Landmark) (string, bool) {
+		return strconv.FormatBool(matcher.facts[landmark.ID].hasPhotos), true
+	})
+	facets.HasPhotos = repository.HasPhotosFacet(photos["true"], photos["false"])
+	return facets, nil
+}
+
+// sortedFacet lists the counts of a facet by decreasing count then value, labelled by the optional label function
+func sortedFacet(counts map[string]int64, label func(value string) string) []repository.FacetCount {
+	facet := make([]repository.FacetCount, 0, len(counts))
+	for value, n := range counts {
+		count := repository.FacetCount{Value: value, Count: n}
+		if label != nil {
+			count.Label = label(value)
+		}
+		facet = append(facet, count)
+	}
+	sort.Slice(facet, func(i, j int) bool {
+		if facet[i].Count != facet[j].Count {
+			return facet[i].Count > facet[j].Count
+		}
+		return facet[i].Value < facet[j].Value
+	})
+	return facet
+}
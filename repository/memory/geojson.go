@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type geoJSONRepository struct {
+	s *store
+}
+
+func (r *geoJSONRepository) List(page repository.Page) ([]models.GeoJSON, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	records, total := paginate(r.s.geoJSON.list(nil), page, geoJSONID)
+	return records, total, nil
+}
+
+// geoJSONByRegion returns the boundary of a region, the caller must hold the lock
+func (s *store) geoJSONByRegion(regionID uint) (*models.GeoJSON, error) {
+	records := s.geoJSON.list(func(geoJSON *models.GeoJSON) bool {
+		return geoJSON.RegionID == regionID
+	})
+	if len(records) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &records[0], nil
+}
+
+func (r *geoJSONRepository) GetByRegion(regionID uint) (*models.GeoJSON, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.geoJSONByRegion(regionID)
+}
+
+func (r *geoJSONRepository) Create(geoJSON *models.GeoJSON) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.geoJSON.create(geoJSON)
+	return nil
+}
+
+func (r *geoJSONRepository) Update(geoJSON *models.GeoJSON) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.geoJSON.save(geoJSON)
+	return nil
+}
+
+func (r *geoJSONRepository) ReplaceSimplifications(geoJSONID uint, simplifications []models.GeoJSONSimplification) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, simplification := range r.s.simplifications.list(func(s *models.GeoJSONSimplification) bool {
+		return s.GeoJSONID == geoJSONID
+	}) {
+		r.s.simplifications.delete(simplification.ID)
+	}
+	for i := range simplifications {
+		simplifications[i].GeoJSONID = geoJSONID
+		r.s.simplifications.create(&simplifications[i])
+	}
+	return nil
+}
+
+func (r *geoJSONRepository) LoadRegionGeoJSON(regions []models.Region, detail repository.GeometryDetail) error {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for i := range regions {
+		geoJSON, err := r.s.geoJSONByRegion(regions[i].ID)
+		if err != nil {
+			continue
+		}
+		switch {
+		case detail.Omit:
+			geoJSON.GeoJSONData = ""
+		case detail.Zoom != nil:
+			for _, simplification := range r.s.simplifications.list(func(s *models.GeoJSONSimplification) bool {
+				return s.GeoJSONID == geoJSON.ID && s.Zoom == *detail.Zoom
+			}) {
+				geoJSON.GeoJSONData = simplification.GeoJSONData
+			}
+		}
+		regions[i].GeoJSON = geoJSON.GeoJSONData
+		regions[i].Map = &geoJSON.MapView
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"math"
+	"strings"
+)
+
+// contains reports whether s contains substr ignoring case, like LIKE with the default collation
+func contains(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// idSet returns the set of the ids a filter is restricted to, nil when it is not restricted
+func idSet(ids []uint) map[uint]bool {
+	if ids == nil {
+		return nil
+	}
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// inRange reports whether value lies within the optional bounds
+func inRange(value float64, min, max *float64) bool {
+	if min != nil && !(value >= *min) {
+		return false
+	}
+	if max != nil && !(value <= *max) {
+		return false
+	}
+	return true
+}
+
+// inIntRange reports whether value lies within the optional bounds
+func inIntRange(value int, min, max *int) bool {
+	if min != nil && value < *min {
+		return false
+	}
+	if max != nil && value > *max {
+		return false
+	}
+	return true
+}
+
+// distanceKm returns the great-circle distance in km between two points given in degrees
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * 6371.0 * math.Asin(math.Min(1, math.Sqrt(a)))
+}
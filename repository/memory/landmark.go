@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"math"
+	"sort"
+
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type landmarkRepository struct {
+	s *store
+}
+
+func (r *landmarkRepository) List(page repository.Page) ([]models.Landmark, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	landmarks, total := paginateSorted(r.s.landmarks.list(nil), page, landmarkID, r.s.compareLandmarks)
+	return landmarks, total, nil
+}
+
+func (r *landmarkRepository) Get(id uint) (*models.Landmark, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.landmarks.get(id)
+}
+
+func (r *landmarkRepository) Search(keyword string, page repository.Page) ([]models.Landmark, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	landmarks, total := paginateSorted(r.s.landmarks.list(func(landmark *models.Landmark) bool {
+		return contains(landmark.Name, keyword) || contains(landmark.Description, keyword)
+	}), page, landmarkID, r.s.compareLandmarks)
+	return landmarks, total, nil
+}
+
+func (r *landmarkRepository) Filter(filter repository.LandmarkFilter, page repository.Page) ([]models.Landmark, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	matcher := r.s.landmarkMatcher(filter)
+	landmarks, total := paginateSorted(r.s.landmarks.list(func(landmark *models.Landmark) bool {
+		return matcher.match(landmark, "")
+	}), page, landmarkID, r.s.compareLandmarks)
+	return landmarks, total, nil
+}
+
+func (r *landmarkRepository) ListByCity(cityID uint, page repository.Page) ([]models.Landmark, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	landmarks, total := paginateSorted(r.s.landmarks.list(func(landmark *models.Landmark) bool {
+		return landmark.CityID == cityID
+	}), page, landmarkID, r.s.compareLandmarks)
+	return landmarks, total, nil
+}
+
+func (r *landmarkRepository) ListByRegion(regionID uint, page repository.Page) ([]models.Landmark, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	landmarks, total := paginateSorted(r.s.landmarks.list(func(landmark *models.Landmark) bool {
+		city, ok := r.s.cities.rows[landmark.CityID]
+		return ok && city.RegionID == regionID
+	}), page, landmarkID, r.s.compareLandmarks)
+	return landmarks, total, nil
+}
+
+func (r *landmarkRepository) Nearby(query repository.NearbyQuery) ([]repository.NearbyLandmark, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var landmarks []repository.NearbyLandmark
+	for _, landmark := range r.s.landmarks.list(nil) {
+		if query.Type != "" && landmark.Type != query.Type {
+			continue
+		}
+		distance := distanceKm(query.Latitude, query.Longitude, landmark.Latitude, landmark.Longitude)
+		if distance <= query.RadiusKm {
+			landmarks = append(landmarks, repository.NearbyLandmark{
+				Landmark:    landmark,
+				Distance:    distance,
+				ReviewStats: r.s.reviewStats(landmark.ID),
+			})
+		}
+	}
+
+	sort.SliceStable(landmarks, func(i, j int) bool { return landmarks[i].Distance < landmarks[j].Distance })
+	return landmarks, nil
+}
+
+// inBox returns the landmarks of the viewport, the caller must hold the lock
+func (s *store) inBox(query repository.MapQuery) []models.Landmark {
+	return s.landmarks.list(func(landmark *models.Landmark) bool {
+		return (query.Type == "" || landmark.Type == query.Type) &&
+			query.Box.Contains(geo.Point{Lng: landmark.Longitude, Lat: landmark.Latitude})
+	})
+}
+
+func (r *landmarkRepository) InBox(query repository.MapQuery, limit int) ([]models.Landmark, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	landmarks := r.s.inBox(query)
+	if len(landmarks) > limit {
+		return nil, int64(len(landmarks)), nil
+	}
+	return landmarks, int64(len(landmarks)), nil
+}
+
+func (r *landmarkRepository) Clusters(query repository.MapQuery, cellSize float64) ([]repository.Cluster, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	type cell struct{ row, column int }
+	var clusters []repository.Cluster
+	index := map[cell]int{}
+	for _, landmark := range r.s.inBox(query) {
+		key := cell{int(math.Floor((landmark.Latitude + 90) / cellSize)), int(math.Floor((landmark.Longitude + 180) / cellSize))}
+		i, ok := index[key]
+		if !ok {
+			// Landmarks are listed by id, so the first one of a cell has the smallest id
+			index[key] = len(clusters)
+			clusters = append(clusters, repository.Cluster{
+				MinLatitude:  landmark.Latitude,
+				MinLongitude: landmark.Longitude,
+				MaxLatitude:  landmark.Latitude,
+				MaxLongitude: landmark.Longitude,
+				LandmarkID:   landmark.ID,
+			})
+			i = len(clusters) - 1
+		}
+
+		cluster := &clusters[i]
+		cluster.Count++
+		// Centroids are accumulated as sums and divided once every landmark is counted
+		cluster.Latitude += landmark.Latitude
+		cluster.Longitude += landmark.Longitude
+		cluster.MinLatitude = math.Min(cluster.MinLatitude, landmark.Latitude)
+		cluster.MinLongitude = math.Min(cluster.MinLongitude, landmark.Longitude)
+		cluster.MaxLatitude = math.Max(cluster.MaxLatitude, landmark.Latitude)
+		cluster.MaxLongitude = math.Max(cluster.MaxLongitude, landmark.Longitude)
+	}
+	for i := range clusters {
+		clusters[i].Latitude /= float64(clusters[i].Count)
+		clusters[i].Longitude /= float64(clusters[i].Count)
+	}
+	return clusters, nil
+}
+
+func (r *landmarkRepository) Create(landmark *models.Landmark) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarks.create(landmark)
+	return nil
+}
+
+func (r *landmarkRepository) CreateBatch(landmarks []models.Landmark) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i := range landmarks {
+		r.s.landmarks.create(&landmarks[i])
+	}
+	return nil
+}
+
+func (r *landmarkRepository) UpsertByExternalRef(landmarks []models.Landmark) (repository.UpsertCounts, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Deleted landmarks are removed for good, so they are imported again
+	byRef := map[string]models.Landmark{}
+	for _, landmark := range r.s.landmarks.list(func(landmark *models.Landmark) bool { return landmark.ExternalRef != nil }) {
+		byRef[*landmark.ExternalRef] = landmark
+	}
+
+	var counts repository.UpsertCounts
+	for i := range landmarks {
+		var stored models.Landmark
+		ok := false
+		if landmarks[i].ExternalRef != nil {
+			stored, ok = byRef[*landmarks[i].ExternalRef]
+		}
+		switch {
+		case !ok:
+			r.s.landmarks.create(&landmarks[i])
+			if landmarks[i].ExternalRef != nil {
+				byRef[*landmarks[i].ExternalRef] = landmarks[i]
+			}
+			counts.Created++
+		case repository.ApplyImport(&stored, landmarks[i]):
+			r.s.landmarks.save(&stored)
+			byRef[*stored.ExternalRef] = stored
+			counts.Updated++
+		default:
+			counts.Unchanged++
+		}
+	}
+	return counts, nil
+}
+
+func (r *landmarkRepository) Update(landmark *models.Landmark) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarks.save(landmark)
+	return nil
+}
+
+func (r *landmarkRepository) Delete(landmark *models.Landmark) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarks.delete(landmark.ID)
+	return nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type landmarkTypeRepository struct {
+	s *store
+}
+
+// withLabels returns a copy of the type with its own map of labels, so that callers never share
+// the map of a stored row
+func withLabels(landmarkType models.LandmarkType) models.LandmarkType {
+	labels := make(map[string]string, len(landmarkType.Labels))
+	for language, label := range landmarkType.Labels {
+		labels[language] = label
+	}
+	landmarkType.Labels = labels
+	return landmarkType
+}
+
+func (r *landmarkTypeRepository) List(page repository.Page) ([]models.LandmarkType, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	landmarkTypes, total := paginate(r.s.landmarkTypes.list(nil), page, landmarkTypeID)
+	for i := range landmarkTypes {
+		landmarkTypes[i] = withLabels(landmarkTypes[i])
+	}
+	return landmarkTypes, total, nil
+}
+
+func (r *landmarkTypeRepository) Get(id uint) (*models.LandmarkType, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	landmarkType, err := r.s.landmarkTypes.get(id)
+	if err != nil {
+		return nil, err
+	}
+	copied := withLabels(*landmarkType)
+	return &copied, nil
+}
+
+func (r *landmarkTypeRepository) GetBySlug(slug string) (*models.LandmarkType, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, landmarkType := range r.s.landmarkTypes.list(nil) {
+		if landmarkType.Slug == slug {
+			copied := withLabels(landmarkType)
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *landmarkTypeRepository) Create(landmarkType *models.LandmarkType) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	stored := withLabels(*landmarkType)
+	r.s.landmarkTypes.create(&stored)
+	landmarkType.ID, landmarkType.CreatedAt, landmarkType.UpdatedAt = stored.ID, stored.CreatedAt, stored.UpdatedAt
+	return nil
+}
+
+func (r *landmarkTypeRepository) Update(landmarkType *models.LandmarkType) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	previous, err := r.s.landmarkTypes.get(landmarkType.ID)
+	if err != nil {
+		return err
+	}
+	if previous.Slug != landmarkType.Slug {
+		for id, landmark := range r.s.landmarks.rows {
+			if landmark.Type == previous.Slug {
+				landmark.Type = landmarkType.Slug
+				r.s.landmarks.rows[id] = landmark
+			}
+		}
+	}
+	stored := withLabels(*landmarkType)
+	r.s.landmarkTypes.save(&stored)
+	landmarkType.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *landmarkTypeRepository) Delete(landmarkType *models.LandmarkType) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarkTypes.delete(landmarkType.ID)
+	return nil
+}
+
+func (r *landmarkTypeRepository) EnsureSlugs(slugs []string) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	known := map[string]bool{}
+	for _, landmarkType := range r.s.landmarkTypes.rows {
+		known[landmarkType.Slug] = true
+	}
+
+	created := 0
+	for _, slug := range slugs {
+		if slug == "" || known[slug] {
+			continue
+		}
+		known[slug] = true
+		r.s.landmarkTypes.create(&models.LandmarkType{Slug: slug, Name: models.LandmarkTypeName(slug), Labels: map[string]string{}})
+		created++
+	}
+	return created, nil
+}
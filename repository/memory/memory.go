@@ -0,0 +1,192 @@
+// Package memory provides in-memory implementations of the repositories,
+// meant for handler tests and demos which should not need a database.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+// table keeps the rows of one model keyed by their primary key
+type table[T any] struct {
+	rows   map[uint]T
+	nextID uint
+	// fields gives access to the primary key and timestamps of a row
+	fields func(row *T) (id *uint, createdAt, updatedAt *time.Time)
+}
+
+func newTable[T any](fields func(row *T) (*uint, *time.Time, *time.Time)) *table[T] {
+	return &table[T]{rows: map[uint]T{}, fields: fields}
+}
+
+func (t *table[T]) create(row *T) {
+	id, createdAt, updatedAt := t.fields(row)
+	if *id == 0 {
+		t.nextID++
+		*id = t.nextID
+	} else if *id > t.nextID {
+		t.nextID = *id
+	}
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	*updatedAt = now
+	t.rows[*id] = *row
+}
+
+// save updates the row, or creates it if it does not exist yet
+func (t *table[T]) save(row *T) {
+	id, _, updatedAt := t.fields(row)
+	if _, ok := t.rows[*id]; !ok {
+		t.create(row)
+		return
+	}
+	*updatedAt = time.Now()
+	t.rows[*id] = *row
+}
+
+func (t *table[T]) get(id uint) (*T, error) {
+	row, ok := t.rows[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &row, nil
+}
+
+func (t *table[T]) delete(id uint) {
+	delete(t.rows, id)
+}
+
+// list returns the rows accepted by match ordered by primary key, a nil match accepts every row
+func (t *table[T]) list(match func(row *T) bool) []T {
+	ids := make([]uint, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var rows []T
+	for _, id := range ids {
+		row := t.rows[id]
+		if match == nil || match(&row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// store holds every table behind a single lock
+type store struct {
+	mu             sync.RWMutex
+	countries      *table[models.Country]
+	regions        *table[models.Region]
+	cities         *table[models.City]
+	landmarks      *table[models.Landmark]
+	reviews        *table[models.Review]
+	landmarkPhotos *table[models.LandmarkPhoto]
+	reviewPhotos   *table[models.ReviewPhoto]
+	geoJSON        *table[models.GeoJSON]
+	// simplifications holds the simplified versions of the boundaries
+	simplifications *table[models.GeoJSONSimplification]
+	// landmarkTypes holds the types along with their labels
+	landmarkTypes *table[models.LandmarkType]
+	translations  *table[models.Translation]
+}
+
+// New returns empty in-memory repositories sharing one store
+func New() repository.Repositories {
+	s := &store{
+		countries: newTable(func(r *models.Country) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		regions: newTable(func(r *models.Region) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		cities: newTable(func(r *models.City) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		landmarks: newTable(func(r *models.Landmark) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		reviews: newTable(func(r *models.Review) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		landmarkPhotos: newTable(func(r *models.LandmarkPhoto) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		reviewPhotos: newTable(func(r *models.ReviewPhoto) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		geoJSON: newTable(func(r *models.GeoJSON) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		simplifications: newTable(func(r *models.GeoJSONSimplification) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		landmarkTypes: newTable(func(r *models.LandmarkType) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+		translations: newTable(func(r *models.Translation) (*uint, *time.Time, *time.Time) {
+			return &r.ID, &r.CreatedAt, &r.UpdatedAt
+		}),
+	}
+
+	return repository.Repositories{
+		Countries: &countryRepository{s},
+		Regions:   &regionRepository{s},
+		Cities:    &cityRepository{s},
+		Landmarks: &landmarkRepository{s},
+		Reviews:   &reviewRepository{s},
+		Photos:    &photoRepository{s},
+		GeoJSON:   &geoJSONRepository{s},
+
+		LandmarkTypes: &landmarkTypeRepository{s},
+		Translations:  &translationRepository{s},
+	}
+}
+
+// paginate returns the requested page of rows, which must be ordered by id, and the number of rows
+func paginate[T any](rows []T, page repository.Page, id func(row *T) uint) ([]T, int64) {
+	total := int64(len(rows))
+
+	if page.AfterID > 0 {
+		start := sort.Search(len(rows), func(i int) bool { return id(&rows[i]) > page.AfterID })
+		rows = rows[start:]
+	}
+	return window(rows, page), total
+}
+
+// paginateSorted is paginate for listings which may be sorted on other fields than the id
+func paginateSorted[T any](rows []T, page repository.Page, id func(row *T) uint, compare func(a, b *T, field string) int) ([]T, int64) {
+	rows, total := paginate(rows, repository.Page{AfterID: page.AfterID}, id)
+	return window(sorted(rows, page.Sort, compare), page), total
+}
+
+// window applies the offset and limit of the page to rows
+func window[T any](rows []T, page repository.Page) []T {
+	if page.Offset > 0 {
+		if page.Offset >= len(rows) {
+			return nil
+		}
+		rows = rows[page.Offset:]
+	}
+	if page.Limit > 0 && len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+	}
+	return rows
+}
+
+func countryID(r *models.Country) uint             { return r.ID }
+func regionID(r *models.Region) uint               { return r.ID }
+func cityID(r *models.City) uint                   { return r.ID }
+func landmarkID(r *models.Landmark) uint           { return r.ID }
+func reviewID(r *models.Review) uint               { return r.ID }
+func landmarkPhotoID(r *models.LandmarkPhoto) uint { return r.ID }
+func reviewPhotoID(r *models.ReviewPhoto) uint     { return r.ID }
+func geoJSONID(r *models.GeoJSON) uint             { return r.ID }
+func landmarkTypeID(r *models.LandmarkType) uint   { return r.ID }
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type photoRepository struct {
+	s *store
+}
+
+func (r *photoRepository) ListLandmarkPhotos(page repository.Page) ([]models.LandmarkPhoto, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	photos, total := paginate(r.s.landmarkPhotos.list(nil), page, landmarkPhotoID)
+	return photos, total, nil
+}
+
+func (r *photoRepository) GetLandmarkPhoto(id uint) (*models.LandmarkPhoto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.landmarkPhotos.get(id)
+}
+
+func (r *photoRepository) ListLandmarkPhotosByLandmark(landmarkID uint) ([]models.LandmarkPhoto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.landmarkPhotos.list(func(photo *models.LandmarkPhoto) bool {
+		return photo.LandmarkID == landmarkID
+	}), nil
+}
+
+func (r *photoRepository) CreateLandmarkPhoto(photo *models.LandmarkPhoto) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarkPhotos.create(photo)
+	return nil
+}
+
+func (r *photoRepository) UpdateLandmarkPhoto(photo *models.LandmarkPhoto) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarkPhotos.save(photo)
+	return nil
+}
+
+func (r *photoRepository) DeleteLandmarkPhoto(photo *models.LandmarkPhoto) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.landmarkPhotos.delete(photo.ID)
+	return nil
+}
+
+func (r *photoRepository) ListReviewPhotos(page repository.Page) ([]models.ReviewPhoto, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	photos, total := paginate(r.s.reviewPhotos.list(nil), page, reviewPhotoID)
+	return photos, total, nil
+}
+
+func (r *photoRepository) GetReviewPhoto(id uint) (*models.ReviewPhoto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.reviewPhotos.get(id)
+}
+
+func (r *photoRepository) ListReviewPhotosByReview(reviewID uint) ([]models.ReviewPhoto, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.reviewPhotos.list(func(photo *models.ReviewPhoto) bool {
+		return photo.ReviewID == reviewID
+	}), nil
+}
+
+func (r *photoRepository) CreateReviewPhoto(photo *models.ReviewPhoto) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.reviewPhotos.create(photo)
+	return nil
+}
+
+func (r *photoRepository) UpdateReviewPhoto(photo *models.ReviewPhoto) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.reviewPhotos.save(photo)
+	return nil
+}
+
+func (r *photoRepository) DeleteReviewPhoto(photo *models.ReviewPhoto) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.reviewPhotos.delete(photo.ID)
+	return nil
+}
+
+func (r *photoRepository) LoadLandmarkPhotoLinks(landmarks []models.Landmark) error {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	links := map[uint][]string{}
+	for _, photo := range r.s.landmarkPhotos.list(nil) {
+		links[photo.LandmarkID] = append(links[photo.LandmarkID], photo.Path)
+	}
+	for i := range landmarks {
+		landmarks[i].Photos = nil
+		landmarks[i].PhotoLinks = links[landmarks[i].ID]
+	}
+	return nil
+}
+
+func (r *photoRepository) LoadReviewPhotoLinks(reviews []models.Review) error {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	links := map[uint][]string{}
+	for _, photo := range r.s.reviewPhotos.list(nil) {
+		links[photo.ReviewID] = append(links[photo.ReviewID], photo.Path)
+	}
+	for i := range reviews {
+		reviews[i].Photos = nil
+		reviews[i].PhotoLinks = links[reviews[i].ID]
+	}
+	return nil
+}
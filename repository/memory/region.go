@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type regionRepository struct {
+	s *store
+}
+
+func (r *regionRepository) List(page repository.Page) ([]models.Region, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	regions, total := paginateSorted(r.s.regions.list(nil), page, regionID, compareRegions)
+	return regions, total, nil
+}
+
+func (r *regionRepository) ListByCountry(countryID uint) ([]models.Region, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.regions.list(func(region *models.Region) bool {
+		return region.CountryID == countryID
+	}), nil
+}
+
+func (r *regionRepository) ListByCountries(countryIDs []uint) ([]models.Region, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	wanted := map[uint]bool{}
+	for _, id := range countryIDs {
+		wanted[id] = true
+	}
+	return r.s.regions.list(func(region *models.Region) bool {
+		return wanted[region.CountryID]
+	}), nil
+}
+
+func (r *regionRepository) ListByIDs(ids []uint) ([]models.Region, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	wanted := map[uint]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	return r.s.regions.list(func(region *models.Region) bool {
+		return wanted[region.ID]
+	}), nil
+}
+
+func (r *regionRepository) Get(id uint) (*models.Region, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.regions.get(id)
+}
+
+func (r *regionRepository) Search(name string, page repository.Page) ([]models.Region, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	regions, total := paginateSorted(r.s.regions.list(func(region *models.Region) bool {
+		return contains(region.Name, name)
+	}), page, regionID, compareRegions)
+	return regions, total, nil
+}
+
+func (r *regionRepository) Filter(filter repository.RegionFilter, page repository.Page) ([]models.Region, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	regions, total := paginateSorted(r.s.regions.list(func(region *models.Region) bool {
+		return inIntRange(region.Population, filter.MinPopulation, filter.MaxPopulation)
+	}), page, regionID, compareRegions)
+	return regions, total, nil
+}
+
+func (r *regionRepository) Create(region *models.Region) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.regions.create(region)
+	return nil
+}
+
+func (r *regionRepository) Update(region *models.Region) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.regions.save(region)
+	return nil
+}
+
+func (r *regionRepository) Delete(region *models.Region) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.regions.delete(region.ID)
+	return nil
+}
+
+func (r *regionRepository) AssignCountry(countryID uint, regionIDs []uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, id := range regionIDs {
+		if region, ok := r.s.regions.rows[id]; ok {
+			region.CountryID = countryID
+			r.s.regions.save(&region)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"sort"
+	"strconv"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+type reviewRepository struct {
+	s *store
+}
+
+// reviewStats summarizes the reviews of a landmark, the caller must hold the lock
+func (s *store) reviewStats(landmarkID uint) repository.ReviewStats {
+	var stats repository.ReviewStats
+	var sum int
+	for _, review := range s.reviews.rows {
+		if review.LandmarkID == landmarkID {
+			stats.ReviewCount++
+			sum += review.Rating
+		}
+	}
+	if stats.ReviewCount > 0 {
+		stats.AverageRating = float64(sum) / float64(stats.ReviewCount)
+	}
+	return stats
+}
+
+func (r *reviewRepository) List(page repository.Page) ([]models.Review, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	reviews, total := paginateSorted(r.s.reviews.list(nil), page, reviewID, compareReviews)
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) Get(id uint) (*models.Review, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.reviews.get(id)
+}
+
+func (r *reviewRepository) ListByLandmark(landmarkID uint, page repository.Page) ([]models.Review, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	reviews := r.s.reviews.list(func(review *models.Review) bool {
+		return review.LandmarkID == landmarkID
+	})
+	if len(page.Sort) > 0 {
+		return window(sorted(reviews, page.Sort, compareReviews), page), int64(len(reviews)), nil
+	}
+	newestFirst(reviews)
+	return window(reviews, page), int64(len(reviews)), nil
+}
+
+func (r *reviewRepository) ListLatestByLandmarks(landmarkIDs []uint, limit int) ([]models.Review, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	byLandmark := map[uint][]models.Review{}
+	for _, review := range r.s.reviews.list(nil) {
+		byLandmark[review.LandmarkID] = append(byLandmark[review.LandmarkID], review)
+	}
+
+	ids := append([]uint(nil), landmarkIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var reviews []models.Review
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
+		}
+		latest := byLandmark[id]
+		newestFirst(latest)
+		reviews = append(reviews, window(latest, repository.Page{Limit: limit})...)
+	}
+	return reviews, nil
+}
+
+// newestFirst orders reviews by creation time, newest first
+func newestFirst(reviews []models.Review) {
+	sort.SliceStable(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+		}
+		return reviews[i].ID > reviews[j].ID
+	})
+}
+
+func (r *reviewRepository) ListByDevice(deviceID string, page repository.Page) ([]models.Review, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	reviews, total := paginateSorted(r.s.reviews.list(func(review *models.Review) bool {
+		return review.DeviceID == deviceID
+	}), page, reviewID, compareReviews)
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) ListByLandmarkAndDevice(landmarkID uint, deviceID string) ([]models.Review, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.reviews.list(func(review *models.Review) bool {
+		return review.LandmarkID == landmarkID && review.DeviceID == deviceID
+	}), nil
+}
+
+func (r *reviewRepository) Search(keyword string, page repository.Page) ([]models.Review, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	reviews, total := paginateSorted(r.s.reviews.list(func(review *models.Review) bool {
+		return contains(review.Name, keyword) || contains(review.Comment, keyword) ||
+			contains(strconv.Itoa(review.Rating), keyword)
+	}), page, reviewID, compareReviews)
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) Filter(filter repository.ReviewFilter, page repository.Page) ([]models.Review, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	reviews, total := paginateSorted(r.s.reviews.list(func(review *models.Review) bool {
+		return inIntRange(review.Rating, filter.MinRating, filter.MaxRating)
+	}), page, reviewID, compareReviews)
+	return reviews, total, nil
+}
+
+func (r *reviewRepository) Stats(landmarkID uint) (repository.ReviewStats, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.reviewStats(landmarkID), nil
+}
+
+func (r *reviewRepository) StatsByLandmarks(landmarkIDs []uint) (map[uint]repository.ReviewStats, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	stats := map[uint]repository.ReviewStats{}
+	for _, id := range landmarkIDs {
+		if landmarkStats := r.s.reviewStats(id); landmarkStats.ReviewCount > 0 {
+			stats[id] = landmarkStats
+		}
+	}
+	return stats, nil
+}
+
+func (r *reviewRepository) OverallStats() (repository.ReviewStats, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var stats repository.ReviewStats
+	var sum int
+	for _, review := range r.s.reviews.rows {
+		stats.ReviewCount++
+		sum += review.Rating
+	}
+	if stats.ReviewCount > 0 {
+		stats.AverageRating = float64(sum) / float64(stats.ReviewCount)
+	}
+	return stats, nil
+}
+
+func (r *reviewRepository) Create(review *models.Review) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.reviews.create(review)
+	return nil
+}
+
+func (r *reviewRepository) Update(review *models.Review) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.reviews.save(review)
+	return nil
+}
+
+func (r *reviewRepository) Delete(review *models.Review) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, photo := range r.s.reviewPhotos.list(func(photo *models.ReviewPhoto) bool {
+		return photo.ReviewID == review.ID
+	}) {
+		r.s.reviewPhotos.delete(photo.ID)
+	}
+	r.s.reviews.delete(review.ID)
+	return nil
+}
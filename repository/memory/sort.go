@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+
+	"landmarksmodule/models"
+	"landmarksmodule/repository"
+)
+
+// sorted orders rows by the sort fields and returns them, ties keep their order by id.
+// Fields are compared with compare, which returns 0 for fields it does not know.
+func sorted[T any](rows []T, fields []repository.SortField, compare func(a, b *T, field string) int) []T {
+	if len(fields) == 0 {
+		return rows
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, field := range fields {
+			result := compare(&rows[i], &rows[j], field.Field)
+			if field.Descending {
+				result = -result
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+		return false
+	})
+	return rows
+}
+
+// compareText compares strings ignoring case, like ORDER BY with the default collation
+func compareText(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func compareRegions(a, b *models.Region, field string) int {
+	switch field {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "name":
+		return compareText(a.Name, b.Name)
+	case "area":
+		return cmp.Compare(a.Area, b.Area)
+	case "population":
+		return cmp.Compare(a.Population, b.Population)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+func compareCities(a, b *models.City, field string) int {
+	switch field {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "name":
+		return compareText(a.Name, b.Name)
+	case "area":
+		return cmp.Compare(a.Area, b.Area)
+	case "population":
+		return cmp.Compare(a.Population, b.Population)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+func compareReviews(a, b *models.Review, field string) int {
+	switch field {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "name":
+		return compareText(a.Name, b.Name)
+	case "rating":
+		return cmp.Compare(a.Rating, b.Rating)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	}
+	return 0
+}
+
+// compareLandmarks compares landmarks, including the review statistics kept in the store.
+// The caller must hold the lock of the store.
+func (s *store) compareLandmarks(a, b *models.Landmark, field string) int {
+	switch field {
+	case "id":
+		return cmp.Compare(a.ID, b.ID)
+	case "name":
+		return compareText(a.Name, b.Name)
+	case "type":
+		return compareText(a.Type, b.Type)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case "average_rating":
+		return cmp.Compare(s.reviewStats(a.ID).AverageRating, s.reviewStats(b.ID).AverageRating)
+	case "review_count":
+		return cmp.Compare(s.reviewStats(a.ID).ReviewCount, s.reviewStats(b.ID).ReviewCount)
+	}
+	return 0
+}
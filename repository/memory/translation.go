@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"sort"
+
+	"landmarksmodule/models"
+)
+
+type translationRepository struct {
+	s *store
+}
+
+// sortTranslations orders translations by entity, locale and field
+func sortTranslations(translations []models.Translation) {
+	sort.Slice(translations, func(i, j int) bool {
+		a, b := translations[i], translations[j]
+		if a.EntityID != b.EntityID {
+			return a.EntityID < b.EntityID
+		}
+		if a.Locale != b.Locale {
+			return a.Locale < b.Locale
+		}
+		return a.Field < b.Field
+	})
+}
+
+func (r *translationRepository) ListByEntity(entityType string, entityID uint) ([]models.Translation, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	translations := r.s.translations.list(func(t *models.Translation) bool {
+		return t.EntityType == entityType && t.EntityID == entityID
+	})
+	sortTranslations(translations)
+	return translations, nil
+}
+
+func (r *translationRepository) ListByEntities(entityType string, entityIDs []uint, locales []string) ([]models.Translation, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	ids := idSet(entityIDs)
+	var localeSet map[string]bool
+	if locales != nil {
+		localeSet = map[string]bool{}
+		for _, locale := range locales {
+			localeSet[locale] = true
+		}
+	}
+	translations := r.s.translations.list(func(t *models.Translation) bool {
+		return t.EntityType == entityType && (ids == nil || ids[t.EntityID]) && (localeSet == nil || localeSet[t.Locale])
+	})
+	sortTranslations(translations)
+	return translations, nil
+}
+
+func (r *translationRepository) SetLocale(entityType string, entityID uint, locale string, texts map[string]string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.deleteLocale(entityType, entityID, locale)
+	for field, text := range texts {
+		r.s.translations.create(&models.Translation{EntityType: entityType, EntityID: entityID, Field: field, Locale: locale, Text: text})
+	}
+	return nil
+}
+
+func (r *translationRepository) DeleteLocale(entityType string, entityID uint, locale string) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.deleteLocale(entityType, entityID, locale), nil
+}
+
+// deleteLocale deletes the translations of an entity in a locale, the store being locked
+func (r *translationRepository) deleteLocale(entityType string, entityID uint, locale string) int64 {
+	var deleted int64
+	for id, t := range r.s.translations.rows {
+		if t.EntityType == entityType && t.EntityID == entityID && t.Locale == locale {
+			r.s.translations.delete(id)
+			deleted++
+		}
+	}
+	return deleted
+}
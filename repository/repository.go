@@ -0,0 +1,420 @@
+package repository
+
+import (
+	"errors"
+
+	"landmarksmodule/geo"
+	"landmarksmodule/models"
+)
+
+// ErrNotFound is returned when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// Page selects a window of a listing. Rows are ordered by id unless stated otherwise.
+type Page struct {
+	// Limit is the maximum number of rows returned, 0 returns every row
+	Limit int
+	// AfterID skips the rows up to and including this id, for keyset pagination.
+	// It is only meaningful when the rows are ordered by id.
+	AfterID uint
+	// Offset skips this many rows
+	Offset int
+	// Sort overrides the default order of the listing, ties are broken by id
+	Sort []SortField
+}
+
+// SortField orders a listing by one field
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// Fields accepted in Page.Sort by the listings of each model
+var (
+	RegionSortFields   = []string{"id", "name", "area", "population", "created_at", "updated_at"}
+	CitySortFields     = []string{"id", "name", "area", "population", "created_at", "updated_at"}
+	LandmarkSortFields = []string{"id", "name", "type", "created_at", "updated_at", "average_rating", "review_count"}
+	ReviewSortFields   = []string{"id", "name", "rating", "created_at", "updated_at"}
+)
+
+// Repositories groups the repositories used by the handlers
+type Repositories struct {
+	Countries CountryRepository
+	Regions   RegionRepository
+	Cities    CityRepository
+	Landmarks LandmarkRepository
+	Reviews   ReviewRepository
+	Photos    PhotoRepository
+	GeoJSON   GeoJSONRepository
+	// LandmarkTypes holds the taxonomy of the landmark types
+	LandmarkTypes LandmarkTypeRepository
+	// Translations holds the translated texts of the landmarks, cities, regions and countries
+	Translations TranslationRepository
+}
+
+// CountryRepository stores countries
+type CountryRepository interface {
+	List(page Page) ([]models.Country, int64, error)
+	Get(id uint) (*models.Country, error)
+	// Nearest returns the country whose stored coordinates are closest to the point
+	Nearest(latitude, longitude float64) (*models.Country, error)
+	Create(country *models.Country) error
+	Update(country *models.Country) error
+	Delete(country *models.Country) error
+}
+
+// RegionFilter restricts the regions returned by RegionRepository.Filter, nil fields are ignored
+type RegionFilter struct {
+	MinPopulation *int
+	MaxPopulation *int
+}
+
+// RegionRepository stores regions
+type RegionRepository interface {
+	List(page Page) ([]models.Region, int64, error)
+	ListByCountry(countryID uint) ([]models.Region, error)
+	// ListByCountries returns the regions of all the given countries at once
+	ListByCountries(countryIDs []uint) ([]models.Region, error)
+	// ListByIDs returns the regions with the given ids at once, missing ones are skipped
+	ListByIDs(ids []uint) ([]models.Region, error)
+	Get(id uint) (*models.Region, error)
+	Search(name string, page Page) ([]models.Region, int64, error)
+	Filter(filter RegionFilter, page Page) ([]models.Region, int64, error)
+	Create(region *models.Region) error
+	Update(region *models.Region) error
+	Delete(region *models.Region) error
+	// AssignCountry moves the given regions to the country
+	AssignCountry(countryID uint, regionIDs []uint) error
+}
+
+// CityFilter restricts the cities returned by CityRepository.Filter, nil fields are ignored
+type CityFilter struct {
+	MinPopulation *int
+	MaxPopulation *int
+	MinArea       *float64
+	MaxArea       *float64
+	MinLatitude   *float64
+	MaxLatitude   *float64
+	MinLongitude  *float64
+	MaxLongitude  *float64
+	// IDs restricts the cities to the given ones unless it is nil
+	IDs []uint
+}
+
+// CityRepository stores cities
+type CityRepository interface {
+	List(page Page) ([]models.City, int64, error)
+	Get(id uint) (*models.City, error)
+	// Nearest returns the city whose stored coordinates are closest to the point,
+	// among the cities of the region unless regionID is 0
+	Nearest(latitude, longitude float64, regionID uint) (*models.City, error)
+	Search(name string, page Page) ([]models.City, int64, error)
+	// ListByName returns the cities with exactly the given name, ignoring case
+	ListByName(name string) ([]models.City, error)
+	Filter(filter CityFilter, page Page) ([]models.City, int64, error)
+	Create(city *models.City) error
+	Update(city *models.City) error
+	Delete(city *models.City) error
+}
+
+// LandmarkFilter restricts the landmarks returned by LandmarkRepository.Filter, zero fields are ignored.
+// A landmark matches a slice of values when it has any of them.
+type LandmarkFilter struct {
+	// Keyword restricts the landmarks to the ones whose name or description contains it
+	Keyword       string
+	CityIDs       []uint
+	RegionIDs     []uint
+	Types         []string
+	RatingBuckets []RatingBucket
+	// HasPhotos restricts the landmarks to the ones with photos when true, without when false
+	HasPhotos    *bool
+	MinLatitude  *float64
+	MaxLatitude  *float64
+	MinLongitude *float64
+	MaxLongitude *float64
+	// IDs restricts the landmarks to the given ones unless it is nil
+	IDs []uint
+}
+
+// RatingBucket is a range of average ratings of landmarks, see RatingBucketOf
+type RatingBucket string
+
+const (
+	Unrated    RatingBucket = "unrated"
+	Rating1To2 RatingBucket = "1-2"
+	Rating2To3 RatingBucket = "2-3"
+	Rating3To4 RatingBucket = "3-4"
+	Rating4To5 RatingBucket = "4-5"
+)
+
+// RatingBuckets lists the rating buckets, the lowest ratings first
+var RatingBuckets = []RatingBucket{Unrated, Rating1To2, Rating2To3, Rating3To4, Rating4To5}
+
+// RatingBucketOf returns the bucket of the reviews of a landmark. A bucket holds its lower bound,
+// 4-5 holds both of them.
+func RatingBucketOf(stats ReviewStats) RatingBucket {
+	switch {
+	case stats.ReviewCount == 0:
+		return Unrated
+	case stats.AverageRating < 2:
+		return Rating1To2
+	case stats.AverageRating < 3:
+		return Rating2To3
+	case stats.AverageRating < 4:
+		return Rating3To4
+	default:
+		return Rating4To5
+	}
+}
+
+// Facets counted by LandmarkRepository.Facets, named after the fields of LandmarkFacets
+const (
+	FacetType      = "type"
+	FacetCity      = "city"
+	FacetRegion    = "region"
+	FacetRating    = "rating"
+	FacetHasPhotos = "has_photos"
+)
+
+// FacetCount is the number of landmarks having a value of a facet. Label is the name of the city
+// or region whose id is the value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// LandmarkFacets counts the landmarks matching a filter by the values of every facet. The counts of
+// a facet ignore the filter on that facet, so that they tell what selecting another value would give.
+// Ratings lists every bucket in the order of RatingBuckets and HasPhotos the values true and false,
+// the other facets list their values by decreasing count.
+type LandmarkFacets struct {
+	Types     []FacetCount `json:"type"`
+	Cities    []FacetCount `json:"city"`
+	Regions   []FacetCount `json:"region"`
+	Ratings   []FacetCount `json:"rating"`
+	HasPhotos []FacetCount `json:"has_photos"`
+}
+
+// RatingFacet lists the counts of every rating bucket in the order of RatingBuckets
+func RatingFacet(counts map[RatingBucket]int64) []FacetCount {
+	facet := make([]FacetCount, len(RatingBuckets))
+	for i, bucket := range RatingBuckets {
+		facet[i] = FacetCount{Value: string(bucket), Count: counts[bucket]}
+	}
+	return facet
+}
+
+// HasPhotosFacet lists the counts of the landmarks with photos and without
+func HasPhotosFacet(with, without int64) []FacetCount {
+	return []FacetCount{{Value: "true", Count: with}, {Value: "false", Count: without}}
+}
+
+// LandmarkRepository stores landmarks
+type LandmarkRepository interface {
+	List(page Page) ([]models.Landmark, int64, error)
+	Get(id uint) (*models.Landmark, error)
+	// Search returns the landmarks whose name or description contains the keyword
+	Search(keyword string, page Page) ([]models.Landmark, int64, error)
+	Filter(filter LandmarkFilter, page Page) ([]models.Landmark, int64, error)
+	// Facets counts the landmarks matching the filter by the values of every facet
+	Facets(filter LandmarkFilter) (*LandmarkFacets, error)
+	ListByCity(cityID uint, page Page) ([]models.Landmark, int64, error)
+	ListByRegion(regionID uint, page Page) ([]models.Landmark, int64, error)
+	// Nearby returns the landmarks within the radius of the query along with their distance
+	// and review statistics, closest first
+	Nearby(query NearbyQuery) ([]NearbyLandmark, error)
+	// InBox counts the landmarks of the viewport and returns them, ordered by id,
+	// unless there are more than limit of them
+	InBox(query MapQuery, limit int) ([]models.Landmark, int64, error)
+	// Clusters groups the landmarks of the viewport in the cells of a grid of the given size in
+	// degrees, aligned on latitude -90 and longitude -180
+	Clusters(query MapQuery, cellSize float64) ([]Cluster, error)
+	Create(landmark *models.Landmark) error
+	// CreateBatch creates the landmarks in a single transaction, none is created when one fails
+	CreateBatch(landmarks []models.Landmark) error
+	// UpsertByExternalRef creates the landmarks whose ExternalRef is not stored yet and updates the
+	// imported fields of the others, see ApplyImport, in a single transaction. Landmarks deleted
+	// since they were imported stay deleted, landmarks without ExternalRef are always created.
+	UpsertByExternalRef(landmarks []models.Landmark) (UpsertCounts, error)
+	Update(landmark *models.Landmark) error
+	Delete(landmark *models.Landmark) error
+}
+
+// UpsertCounts tells what LandmarkRepository.UpsertByExternalRef did with the landmarks
+type UpsertCounts struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	// Deleted counts the landmarks left deleted
+	Deleted int `json:"deleted"`
+}
+
+// ApplyImport copies the fields owned by an import, the name, type, coordinates and city, from an
+// imported landmark to the stored one and reports whether any of them changed. The other fields
+// may have been edited since the landmark was created and are kept.
+func ApplyImport(stored *models.Landmark, imported models.Landmark) bool {
+	changed := stored.Name != imported.Name || stored.Type != imported.Type ||
+		stored.Latitude != imported.Latitude || stored.Longitude != imported.Longitude ||
+		stored.CityID != imported.CityID
+	stored.Name, stored.Type = imported.Name, imported.Type
+	stored.Latitude, stored.Longitude = imported.Latitude, imported.Longitude
+	stored.CityID = imported.CityID
+	return changed
+}
+
+// MapQuery selects the landmarks of a map viewport
+type MapQuery struct {
+	// Box may cross the antimeridian
+	Box geo.BBox
+	// Type restricts the landmarks to one type unless empty
+	Type string
+}
+
+// Cluster groups the landmarks of one cell of a grid
+type Cluster struct {
+	Count int64 `json:"count"`
+	// Latitude and Longitude locate the centroid of the landmarks
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	MinLatitude  float64 `json:"min_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+	// LandmarkID is the smallest id of the landmarks, which identifies a cluster of one
+	LandmarkID uint `json:"landmark_id"`
+}
+
+// NearbyQuery selects the landmarks around a point
+type NearbyQuery struct {
+	Latitude  float64
+	Longitude float64
+	// RadiusKm is the largest distance of the returned landmarks
+	RadiusKm float64
+	// Type restricts the landmarks to one type unless empty
+	Type string
+}
+
+// NearbyLandmark is a landmark found around a point
+type NearbyLandmark struct {
+	models.Landmark
+	// Distance from the point in km
+	Distance float64 `json:"distance"`
+	ReviewStats
+}
+
+// LandmarkTypeRepository stores the landmark taxonomy, every type being loaded with its labels
+type LandmarkTypeRepository interface {
+	List(page Page) ([]models.LandmarkType, int64, error)
+	Get(id uint) (*models.LandmarkType, error)
+	GetBySlug(slug string) (*models.LandmarkType, error)
+	// Create creates the type along with its labels
+	Create(landmarkType *models.LandmarkType) error
+	// Update saves the type and replaces its labels. Changing the slug changes the type of its
+	// landmarks along, in the same transaction.
+	Update(landmarkType *models.LandmarkType) error
+	// Delete deletes the type and its labels
+	Delete(landmarkType *models.LandmarkType) error
+	// EnsureSlugs creates the types of the given slugs which do not exist yet, named after their
+	// slug, and returns how many it created
+	EnsureSlugs(slugs []string) (int, error)
+}
+
+// TranslationRepository stores the translations of the fields of the entities listed in
+// models.TranslatableFields
+type TranslationRepository interface {
+	// ListByEntity returns the translations of an entity ordered by locale and field
+	ListByEntity(entityType string, entityID uint) ([]models.Translation, error)
+	// ListByEntities returns the translations of the given entities in the given locales at once.
+	// Nil entityIDs or locales are not restricted.
+	ListByEntities(entityType string, entityIDs []uint, locales []string) ([]models.Translation, error)
+	// SetLocale replaces the translations of an entity in a locale by the given texts keyed by field,
+	// in a single transaction
+	SetLocale(entityType string, entityID uint, locale string, texts map[string]string) error
+	// DeleteLocale deletes the translations of an entity in a locale and returns how many there were
+	DeleteLocale(entityType string, entityID uint, locale string) (int64, error)
+}
+
+// ReviewFilter restricts the reviews returned by ReviewRepository.Filter, nil fields are ignored
+type ReviewFilter struct {
+	MinRating *int
+	MaxRating *int
+}
+
+// ReviewStats summarizes the reviews of a landmark
+type ReviewStats struct {
+	ReviewCount   int64   `json:"review_count"`
+	AverageRating float64 `json:"average_rating"`
+}
+
+// ReviewRepository stores reviews
+type ReviewRepository interface {
+	List(page Page) ([]models.Review, int64, error)
+	Get(id uint) (*models.Review, error)
+	// ListByLandmark returns the reviews of a landmark newest first, AfterID of the page is ignored
+	ListByLandmark(landmarkID uint, page Page) ([]models.Review, int64, error)
+	// ListLatestByLandmarks returns up to limit of the newest reviews of each of the given
+	// landmarks at once, grouped by landmark and newest first within a landmark
+	ListLatestByLandmarks(landmarkIDs []uint, limit int) ([]models.Review, error)
+	ListByDevice(deviceID string, page Page) ([]models.Review, int64, error)
+	ListByLandmarkAndDevice(landmarkID uint, deviceID string) ([]models.Review, error)
+	// Search returns the reviews whose name, comment or rating contains the keyword
+	Search(keyword string, page Page) ([]models.Review, int64, error)
+	Filter(filter ReviewFilter, page Page) ([]models.Review, int64, error)
+	Stats(landmarkID uint) (ReviewStats, error)
+	// StatsByLandmarks returns the statistics of all the given landmarks at once, keyed by landmark id.
+	// Landmarks without reviews are left out.
+	StatsByLandmarks(landmarkIDs []uint) (map[uint]ReviewStats, error)
+	// OverallStats summarizes the reviews of every landmark
+	OverallStats() (ReviewStats, error)
+	Create(review *models.Review) error
+	Update(review *models.Review) error
+	// Delete removes the review together with its photo records
+	Delete(review *models.Review) error
+}
+
+// PhotoRepository stores the photo records of landmarks and reviews
+type PhotoRepository interface {
+	ListLandmarkPhotos(page Page) ([]models.LandmarkPhoto, int64, error)
+	GetLandmarkPhoto(id uint) (*models.LandmarkPhoto, error)
+	ListLandmarkPhotosByLandmark(landmarkID uint) ([]models.LandmarkPhoto, error)
+	CreateLandmarkPhoto(photo *models.LandmarkPhoto) error
+	UpdateLandmarkPhoto(photo *models.LandmarkPhoto) error
+	DeleteLandmarkPhoto(photo *models.LandmarkPhoto) error
+
+	ListReviewPhotos(page Page) ([]models.ReviewPhoto, int64, error)
+	GetReviewPhoto(id uint) (*models.ReviewPhoto, error)
+	ListReviewPhotosByReview(reviewID uint) ([]models.ReviewPhoto, error)
+	CreateReviewPhoto(photo *models.ReviewPhoto) error
+	UpdateReviewPhoto(photo *models.ReviewPhoto) error
+	DeleteReviewPhoto(photo *models.ReviewPhoto) error
+
+	// LoadLandmarkPhotoLinks fills PhotoLinks of every landmark and clears Photos,
+	// loading the photos of all the landmarks at once
+	LoadLandmarkPhotoLinks(landmarks []models.Landmark) error
+	// LoadReviewPhotoLinks fills PhotoLinks of every review and clears Photos,
+	// loading the photos of all the reviews at once
+	LoadReviewPhotoLinks(reviews []models.Review) error
+}
+
+// GeoJSONRepository stores the boundaries of regions
+type GeoJSONRepository interface {
+	List(page Page) ([]models.GeoJSON, int64, error)
+	GetByRegion(regionID uint) (*models.GeoJSON, error)
+	Create(geoJSON *models.GeoJSON) error
+	Update(geoJSON *models.GeoJSON) error
+	// ReplaceSimplifications stores the simplified versions of a boundary in place of the former ones
+	ReplaceSimplifications(geoJSONID uint, simplifications []models.GeoJSONSimplification) error
+	// LoadRegionGeoJSON fills the GeoJSON and Map fields of every region which has a boundary,
+	// loading the boundaries of all the regions at once in the given detail
+	LoadRegionGeoJSON(regions []models.Region, detail GeometryDetail) error
+}
+
+// GeometryDetail selects the version of the boundaries loaded by LoadRegionGeoJSON
+type GeometryDetail struct {
+	// Omit leaves the GeoJSON field empty, only the map view is loaded
+	Omit bool
+	// Zoom selects the simplification made for a level of geo.SimplifiedZooms,
+	// nil selects the full boundaries. Boundaries without that simplification are loaded in full.
+	Zoom *int
+}
@@ -2,13 +2,29 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"landmarksmodule/config"
 	"landmarksmodule/handlers"
+	"landmarksmodule/models"
+	"landmarksmodule/storage"
 	"log"
 )
 
-// SetupRoutes initializes routes for the API
-func SetupRoutes() {
+// SetupRoutes initializes routes for the API and starts the server
+func SetupRoutes(cfg config.ServerConfig) {
+	router := NewRouter()
+
+	// Start server
+	err := router.Run(cfg.Addr)
+	if err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// NewRouter returns the router of the API, serving the handlers set up by handlers.Init
+func NewRouter() *gin.Engine {
 	router := gin.Default()
+	// The lang query parameter and the Accept-Language header pick the language of the responses
+	router.Use(handlers.NegotiateLocale)
 
 	//Country endpoints
 	router.GET("/countries", handlers.GetCountries)
@@ -26,6 +42,7 @@ func SetupRoutes() {
 	router.DELETE("/regions/:id", handlers.DeleteRegion)
 	router.GET("/regions/search", handlers.SearchRegions)
 	router.GET("/regions/filter", handlers.FilterRegions)
+	router.GET("/regions/lookup", handlers.LookupRegion)
 	router.POST("/regions/country", handlers.AddRegionsToCountry)
 
 	// Cities endpoints
@@ -38,9 +55,36 @@ func SetupRoutes() {
 	router.GET("/cities/filter", handlers.FilterCities)
 	router.GET("/cities/region", handlers.GetRegionOfCity)
 
+	// Translations of the names and texts, one locale at a time
+	translated := map[string]string{
+		"/countries": models.EntityCountry,
+		"/regions":   models.EntityRegion,
+		"/cities":    models.EntityCity,
+		"/landmarks": models.EntityLandmark,
+	}
+	for path, entity := range translated {
+		router.GET(path+"/:id/translations", handlers.GetTranslations(entity))
+		router.GET(path+"/:id/translations/:locale", handlers.GetTranslation(entity))
+		router.PUT(path+"/:id/translations/:locale", handlers.SetTranslation(entity))
+		router.DELETE(path+"/:id/translations/:locale", handlers.DeleteTranslation(entity))
+	}
+
+	// Landmark type taxonomy endpoints
+	router.GET("/landmark-types", handlers.GetLandmarkTypes)
+	router.GET("/landmark-types/tree", handlers.GetLandmarkTypeTree)
+	router.POST("/landmark-types", handlers.CreateLandmarkType)
+	router.GET("/landmark-types/:id", handlers.GetLandmarkTypeByID)
+	router.PUT("/landmark-types/:id", handlers.UpdateLandmarkType)
+	router.DELETE("/landmark-types/:id", handlers.DeleteLandmarkType)
+
+	// Search across landmarks, cities and regions
+	router.GET("/search", handlers.Search)
+	router.GET("/autocomplete", handlers.Autocomplete)
+
 	// Landmarks endpoints
 	router.GET("/landmarks", handlers.GetLandmarks)
 	router.POST("/landmarks", handlers.CreateLandmark)
+	router.POST("/landmarks/import", handlers.ImportLandmarks)
 	router.GET("/landmarks/:id", handlers.GetLandmarkByID)
 	router.PUT("/landmarks/:id", handlers.UpdateLandmark)
 	router.DELETE("/landmarks/:id", handlers.DeleteLandmark)
@@ -54,6 +98,21 @@ func SetupRoutes() {
 	router.GET("/landmarks/region/:region_id", handlers.GetAllLandmarksOfRegion)
 	router.GET("/landmarks/:id/photos", handlers.GetLandmarkPhotosByLandmarkID)
 	router.GET("/landmarks/suggested", handlers.GetSuggestedLandmarks)
+	router.GET("/landmarks/map", handlers.GetLandmarkMap)
+
+	// Exports, the extension of the path picks the format. The landmarks of a city or a region
+	// are exported by adding the extension to the id, as in /landmarks/region/3.kml
+	for _, extension := range []string{".geojson", ".kml", ".gpx"} {
+		router.GET("/landmarks"+extension, handlers.Export(handlers.GetLandmarks))
+		router.GET("/landmarks/search"+extension, handlers.Export(handlers.SearchLandmarks))
+		router.GET("/landmarks/filter"+extension, handlers.Export(handlers.FilterLandmarks))
+		router.GET("/regions/:id/boundary"+extension, handlers.Export(handlers.GetRegionBoundary))
+	}
+	for _, extension := range []string{".kml", ".gpx"} {
+		router.GET("/cities"+extension, handlers.Export(handlers.GetCities))
+		router.GET("/cities/search"+extension, handlers.Export(handlers.SearchCities))
+		router.GET("/cities/filter"+extension, handlers.Export(handlers.FilterCities))
+	}
 
 	//Review endpoints
 	router.GET("/reviews", handlers.GetReviews)
@@ -73,6 +132,9 @@ func SetupRoutes() {
 	router.PUT("/geojson/:id", handlers.UpdateGeoJSON)
 	router.GET("/geojson/:id", handlers.GetGeoJSONFromDB)
 
+	// Vector tile endpoint, y carries the .mvt extension
+	router.GET("/tiles/:z/:x/:y", handlers.GetTile)
+
 	//Photo endpoints
 	router.GET("/landmarkphotos", handlers.GetAllLandmarkPhotos)
 	router.GET("/landmarkphotos/:id", handlers.GetLandmarkPhotoByID)
@@ -86,9 +148,10 @@ func SetupRoutes() {
 	router.PUT("/reviewphotos/:id", handlers.UpdateReviewPhoto)
 	router.DELETE("/reviewphotos/:id", handlers.DeleteReviewPhoto)
 
-	// Start server
-	err := router.Run(":8080")
-	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Stored files are served by the API itself when they are kept on the local disk
+	if _, ok := storage.Store.(*storage.LocalStorage); ok {
+		router.GET("/files/*key", handlers.ServeStoredFile)
 	}
+
+	return router
 }
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldedLetters lists the lowercase letters with diacritics by the letters they fold to, covering
+// the Latin-1 Supplement and Latin Extended-A blocks along with the letters of Albanian, Serbian,
+// Croatian, Romanian, Turkish and Vietnamese
+var foldedLetters = map[string]string{
+	"a":  "àáâãäåāăąǎǟǡǻȁȃȧạảấầẩẫậắằẳẵặ",
+	"c":  "çćĉċč",
+	"d":  "ďđð",
+	"e":  "èéêëēĕėęěȅȇȩẹẻẽếềểễệ",
+	"g":  "ĝğġģǧ",
+	"h":  "ĥħ",
+	"i":  "ìíîïĩīĭįıǐȉȋỉị",
+	"j":  "ĵǰ",
+	"k":  "ķǩ",
+	"l":  "ĺļľŀł",
+	"n":  "ñńņňŉǹ",
+	"o":  "òóôõöøōŏőǒǫȍȏȯọỏốồổỗộớờởỡợơ",
+	"r":  "ŕŗřȑȓ",
+	"s":  "śŝşšș",
+	"t":  "ţťŧț",
+	"u":  "ùúûüũūŭůűųưǔǖǘǚǜȕȗụủứừửữự",
+	"w":  "ŵẁẃẅ",
+	"y":  "ýÿŷỳỵỷỹ",
+	"z":  "źżž",
+	"ae": "æǽ",
+	"oe": "œ",
+	"ss": "ß",
+	"th": "þ",
+	"dz": "ǆ",
+	"lj": "ǉ",
+	"nj": "ǌ",
+}
+
+// folding maps every letter of foldedLetters to its folded form
+var folding = map[rune]string{}
+
+func init() {
+	for folded, letters := range foldedLetters {
+		for _, letter := range letters {
+			folding[letter] = folded
+		}
+	}
+}
+
+// fold lowercases a word and strips its diacritics, so that Prishtinë, PRISHTINE and Prishtine
+// are the same term. Combining marks are dropped, which also folds decomposed letters.
+func fold(word string) string {
+	var out strings.Builder
+	out.Grow(len(word))
+	for _, r := range strings.ToLower(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if folded, ok := folding[r]; ok {
+			out.WriteString(folded)
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
@@ -0,0 +1,111 @@
+package search
+
+import "sort"
+
+// fuzzyPenalty scales the score of a term matched with a typo, once per edit
+const fuzzyPenalty = 0.5
+
+// maxEdits returns the number of typos tolerated in a term: none below 4 letters, where an edit
+// often makes another word, one up to 7 letters and two beyond
+func maxEdits(term []rune) int {
+	switch {
+	case len(term) < 4:
+		return 0
+	case len(term) < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and transpositions of
+// adjacent letters turning a into b, or into a prefix of b when prefix is set. It gives up and
+// returns limit+1 as soon as the distance exceeds limit.
+func editDistance(a, b []rune, limit int, prefix bool) int {
+	if len(a)-len(b) > limit || !prefix && len(b)-len(a) > limit {
+		return limit + 1
+	}
+
+	// rows[i%3] holds the distances between the first i letters of a and every prefix of b
+	var rows [3][]int
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		row, previous, before := rows[i%3], rows[(i-1)%3], rows[(i+1)%3]
+		row[0] = i
+		smallest := i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min(previous[j]+1, row[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				row[j] = min(row[j], before[j-2]+1)
+			}
+			smallest = min(smallest, row[j])
+		}
+		// Every later distance derives from the last two rows
+		if smallest > limit && minOf(previous) > limit {
+			return limit + 1
+		}
+	}
+
+	last := rows[len(a)%3]
+	distance := last[len(b)]
+	if prefix {
+		distance = minOf(last)
+	}
+	if distance > limit {
+		return limit + 1
+	}
+	return distance
+}
+
+func minOf(values []int) int {
+	smallest := values[0]
+	for _, value := range values[1:] {
+		smallest = min(smallest, value)
+	}
+	return smallest
+}
+
+// withPrefix returns the terms of a sorted vocabulary starting with the prefix
+func withPrefix(vocabulary []string, prefix string) []string {
+	start := sort.SearchStrings(vocabulary, prefix)
+	end := start
+	for end < len(vocabulary) && len(vocabulary[end]) >= len(prefix) && vocabulary[end][:len(prefix)] == prefix {
+		end++
+	}
+	return vocabulary[start:end]
+}
+
+// variant is a term of the index matching a term of a query with a number of typos
+type variant struct {
+	term  string
+	edits int
+}
+
+// similar returns the terms of a sorted vocabulary within the tolerated typos of a term, or
+// starting with something within them when prefix is set. The term itself is not included.
+func similar(vocabulary []string, term string, prefix bool) []variant {
+	letters := []rune(term)
+	tolerated := maxEdits(letters)
+	if tolerated == 0 {
+		return nil
+	}
+	var variants []variant
+	for _, candidate := range vocabulary {
+		if candidate == term {
+			continue
+		}
+		if edits := editDistance(letters, []rune(candidate), tolerated, prefix); edits <= tolerated {
+			variants = append(variants, variant{term: candidate, edits: edits})
+		}
+	}
+	return variants
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		limit  int
+		prefix bool
+		want   int
+	}{
+		{"prizren", "prizren", 2, false, 0},
+		{"prizren", "przren", 2, false, 1},
+		{"prizren", "prizrenn", 2, false, 1},
+		{"prizren", "prizran", 2, false, 1},
+		{"prizren", "pirzren", 2, false, 1},
+		{"prizren", "pirzran", 2, false, 2},
+		{"prizren", "prishtine", 2, false, 3},
+		{"prizren", "gjakove", 1, false, 2},
+		// A prefix of b is enough when prefix is set
+		{"prizr", "prizrene", 1, true, 0},
+		{"pirzr", "prizrene", 1, true, 1},
+		{"prizr", "prizrene", 1, false, 2},
+		{"", "ura", 0, true, 0},
+	}
+	for _, test := range tests {
+		got := editDistance([]rune(test.a), []rune(test.b), test.limit, test.prefix)
+		if got != test.want {
+			t.Errorf("editDistance(%q, %q, %d, %v) = %d, want %d", test.a, test.b, test.limit, test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestMaxEdits(t *testing.T) {
+	for term, want := range map[string]int{"ura": 0, "gjak": 1, "prizren": 1, "prishtine": 2} {
+		if got := maxEdits([]rune(term)); got != want {
+			t.Errorf("maxEdits(%q) = %d, want %d", term, got, want)
+		}
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	vocabulary := []string{"kala", "kalaja", "kalase", "prizren", "prizrene", "prizrenit"}
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"kala", []string{"kala", "kalaja", "kalase"}},
+		{"prizrene", []string{"prizrene"}},
+		{"pz", []string{}},
+		{"zz", []string{}},
+	}
+	for _, test := range tests {
+		if got := withPrefix(vocabulary, test.prefix); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("withPrefix(%q) = %q, want %q", test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	vocabulary := []string{"gurit", "prizren", "prizrene", "prizrenit", "ura", "urat"}
+	tests := []struct {
+		term   string
+		prefix bool
+		want   []variant
+	}{
+		{"prizren", false, []variant{{"prizrene", 1}}},
+		{"przren", false, []variant{{"prizren", 1}}},
+		{"przren", true, []variant{{"prizren", 1}, {"prizrene", 1}, {"prizrenit", 1}}},
+		{"guirt", false, []variant{{"gurit", 1}}},
+		// Too short for a typo
+		{"ira", false, nil},
+	}
+	for _, test := range tests {
+		if got := similar(vocabulary, test.term, test.prefix); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("similar(%q, %v) = %v, want %v", test.term, test.prefix, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// snippetLength is the length in bytes past which a text is cut around its first match
+const snippetLength = 160
+
+// highlight returns the part of a text around its first match with the given terms, escaped for
+// HTML with every match wrapped in <mark> tags, or false when the text does not match
+func highlight(text string, matches map[string]bool) (string, bool) {
+	tokens := tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if matches[t.term] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	// The snippet starts a little before the first match and is cut between words
+	start, end := 0, len(text)
+	if len(text) > snippetLength {
+		limit := tokens[first].start - snippetLength/4
+		start = tokens[first].start
+		for i := first - 1; i >= 0 && tokens[i].start >= limit; i-- {
+			start = tokens[i].start
+		}
+		end = start + snippetLength
+		if end >= len(text) {
+			end = len(text)
+		} else {
+			last := start
+			for _, t := range tokens {
+				if t.end <= end && t.end > last {
+					last = t.end
+				}
+			}
+			end = last
+		}
+	}
+
+	var out strings.Builder
+	if start > 0 {
+		out.WriteString("…")
+	}
+	position := start
+	for _, t := range tokens {
+		if t.start < start || t.end > end || !matches[t.term] {
+			continue
+		}
+		out.WriteString(html.EscapeString(text[position:t.start]))
+		out.WriteString("<mark>" + html.EscapeString(text[t.start:t.end]) + "</mark>")
+		position = t.end
+	}
+	out.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		out.WriteString("…")
+	}
+	return out.String(), true
+}
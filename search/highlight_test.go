@@ -0,0 +1,51 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	matches := map[string]bool{"prizrene": true, "hamam": true}
+	tests := []struct {
+		name string
+		text string
+		want string
+		ok   bool
+	}{
+		{"match keeps its diacritics", "Kalaja e Prizrenë", "Kalaja e <mark>Prizrenë</mark>", true},
+		{"every match", "Hamam, hamam", "<mark>Hamam</mark>, <mark>hamam</mark>", true},
+		{"text is escaped", `<b>Hamam</b> & "baths"`, "&lt;b&gt;<mark>Hamam</mark>&lt;/b&gt; &amp; &#34;baths&#34;", true},
+		{"no match", "Ura e Gurit", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := highlight(test.text, matches)
+			if got != test.want || ok != test.ok {
+				t.Errorf("highlight(%q) = %q, %v, want %q, %v", test.text, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestHighlightCutsLongTexts(t *testing.T) {
+	before := strings.Repeat("lorem ipsum ", 30)
+	after := strings.Repeat(" dolor sit amet", 30)
+	got, ok := highlight(before+"<Hamam>"+after, map[string]bool{"hamam": true})
+	if !ok {
+		t.Fatal("long text does not match")
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet %q is not cut on both sides", got)
+	}
+	if !strings.Contains(got, "&lt;<mark>Hamam</mark>&gt;") {
+		t.Errorf("snippet %q does not hold the escaped match", got)
+	}
+	// The snippet is cut between words
+	for _, cut := range []string{"…ipsum", "…lorem", "amet…", "sit…", "dolor…"} {
+		if strings.HasPrefix(got, cut) || strings.HasSuffix(got, cut) {
+			return
+		}
+	}
+	t.Errorf("snippet %q is cut within a word", got)
+}
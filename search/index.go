@@ -0,0 +1,279 @@
+// Package search keeps an inverted index of the names and descriptions of landmarks, cities and
+// regions, ranks the documents matching a query with BM25 and suggests names as they are typed.
+// Terms are compared without case and diacritics, and tolerate typos.
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 parameters: k1 bounds the weight of repeated terms, b scales the normalization by field length
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Kind is the kind of entity a document describes
+type Kind string
+
+const (
+	Landmark Kind = "landmark"
+	City     Kind = "city"
+	Region   Kind = "region"
+)
+
+// Field is a searched text of a document, the scores of its matches are multiplied by Weight
+type Field struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+// Document is an indexed entity
+type Document struct {
+	Kind   Kind
+	ID     uint
+	Name   string
+	Fields []Field
+	// Aliases are other names of the document, such as its translations, which suggestions complete like Name
+	Aliases []string
+	// Type is the type of a landmark
+	Type string
+	// RegionID is the region of a landmark or a city, and the id of a region
+	RegionID uint
+}
+
+// Query selects and pages the documents matching every term of Text
+type Query struct {
+	Text string
+	// Kinds restricts the results to some kinds of documents, all of them when empty
+	Kinds []Kind
+	// Type and RegionID are ignored when empty
+	Type     string
+	RegionID uint
+	Offset   int
+	Limit    int
+}
+
+// Hit is a document matching a query, with its matching fields highlighted
+type Hit struct {
+	Kind  Kind    `json:"kind"`
+	ID    uint    `json:"id"`
+	Name  string  `json:"name"`
+	Type  string  `json:"type,omitempty"`
+	Score float64 `json:"score"`
+	// Highlights holds a snippet of every matching field, the matches wrapped in <mark> tags
+	Highlights map[string]string `json:"highlights"`
+}
+
+// posting records the occurrences of a term in a field of a document
+type posting struct {
+	doc   int
+	field int
+	count int
+}
+
+// Index is an inverted index of documents. It is not modified by searches and may be shared once
+// every document is added.
+type Index struct {
+	documents []Document
+	postings  map[string][]posting
+	// lengths holds the number of terms of every field of every document
+	lengths [][]int
+	// fieldTerms and fieldCounts sum the lengths and count the fields of each name, for their average length
+	fieldTerms  map[string]int
+	fieldCounts map[string]int
+	// names maps the terms of the document names to the documents, for suggestions
+	names map[string][]int
+
+	// prepared sorts the vocabularies on the first search after documents are added
+	prepared       *sync.Once
+	vocabulary     []string
+	nameVocabulary []string
+}
+
+// NewIndex returns an empty index
+func NewIndex() *Index {
+	return &Index{
+		postings:    map[string][]posting{},
+		fieldTerms:  map[string]int{},
+		fieldCounts: map[string]int{},
+		names:       map[string][]int{},
+		prepared:    &sync.Once{},
+	}
+}
+
+// Add indexes a document
+func (ix *Index) Add(document Document) {
+	doc := len(ix.documents)
+	ix.documents = append(ix.documents, document)
+	lengths := make([]int, len(document.Fields))
+	for f, field := range document.Fields {
+		counts := map[string]int{}
+		var order []string
+		for _, t := range tokenize(field.Text) {
+			if counts[t.term] == 0 {
+				order = append(order, t.term)
+			}
+			counts[t.term]++
+			lengths[f]++
+		}
+		for _, term := range order {
+			ix.postings[term] = append(ix.postings[term], posting{doc: doc, field: f, count: counts[term]})
+		}
+		ix.fieldTerms[field.Name] += lengths[f]
+		ix.fieldCounts[field.Name]++
+	}
+	ix.lengths = append(ix.lengths, lengths)
+
+	seen := map[string]bool{}
+	for _, name := range append([]string{document.Name}, document.Aliases...) {
+		for _, t := range tokenize(name) {
+			if !seen[t.term] {
+				seen[t.term] = true
+				ix.names[t.term] = append(ix.names[t.term], doc)
+			}
+		}
+	}
+	ix.prepared = &sync.Once{}
+}
+
+// prepare sorts the terms of the index, once every document is added
+func (ix *Index) prepare() {
+	ix.prepared.Do(func() {
+		ix.vocabulary = sortedKeys(ix.postings)
+		ix.nameVocabulary = sortedKeys(ix.names)
+	})
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Len returns the number of indexed documents
+func (ix *Index) Len() int {
+	return len(ix.documents)
+}
+
+// Search returns the requested page of the documents matching every term of the query, the most
+// relevant first, along with their total number. A term matches the terms of the index within the
+// typos tolerated by maxEdits, whose scores are lowered by fuzzyPenalty for every typo.
+func (ix *Index) Search(q Query) ([]Hit, int) {
+	queryTerms := terms(q.Text)
+	if len(queryTerms) == 0 {
+		return nil, 0
+	}
+	ix.prepare()
+
+	scores := map[int]float64{}
+	matched := map[int]int{}
+	matches := map[string]bool{}
+	for _, term := range queryTerms {
+		variants := similar(ix.vocabulary, term, false)
+		if _, ok := ix.postings[term]; ok {
+			variants = append(variants, variant{term: term})
+		}
+
+		// A document scores the best of the variants it contains
+		best := map[int]float64{}
+		for _, v := range variants {
+			matches[v.term] = true
+			penalty := math.Pow(fuzzyPenalty, float64(v.edits))
+			for doc, score := range ix.termScores(ix.postings[v.term]) {
+				best[doc] = math.Max(best[doc], score*penalty)
+			}
+		}
+		for doc, score := range best {
+			scores[doc] += score
+			matched[doc]++
+		}
+	}
+
+	var docs []int
+	for doc, count := range matched {
+		if count == len(queryTerms) && ix.accepts(doc, q) {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if scores[docs[i]] != scores[docs[j]] {
+			return scores[docs[i]] > scores[docs[j]]
+		}
+		return docs[i] < docs[j]
+	})
+
+	total := len(docs)
+	if q.Offset >= len(docs) {
+		return []Hit{}, total
+	}
+	docs = docs[q.Offset:]
+	if q.Limit > 0 && len(docs) > q.Limit {
+		docs = docs[:q.Limit]
+	}
+
+	hits := make([]Hit, len(docs))
+	for i, doc := range docs {
+		document := ix.documents[doc]
+		hits[i] = Hit{
+			Kind:       document.Kind,
+			ID:         document.ID,
+			Name:       document.Name,
+			Type:       document.Type,
+			Score:      math.Round(scores[doc]*1000) / 1000,
+			Highlights: map[string]string{},
+		}
+		for _, field := range document.Fields {
+			if snippet, ok := highlight(field.Text, matches); ok {
+				hits[i].Highlights[field.Name] = snippet
+			}
+		}
+	}
+	return hits, total
+}
+
+// termScores returns the BM25 score of a term for every document containing it, summed over
+// the fields of the document and weighted by them
+func (ix *Index) termScores(postings []posting) map[int]float64 {
+	// The frequency of the term is the number of documents containing it, whatever the field
+	documents := map[int]bool{}
+	for _, p := range postings {
+		documents[p.doc] = true
+	}
+	n, df := float64(len(ix.documents)), float64(len(documents))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	scores := map[int]float64{}
+	for _, p := range postings {
+		field := ix.documents[p.doc].Fields[p.field]
+		average := float64(ix.fieldTerms[field.Name]) / float64(ix.fieldCounts[field.Name])
+		length := float64(ix.lengths[p.doc][p.field])
+		count := float64(p.count)
+		scores[p.doc] += field.Weight * idf * count * (k1 + 1) / (count + k1*(1-b+b*length/average))
+	}
+	return scores
+}
+
+// accepts reports whether a document passes the filters of the query
+func (ix *Index) accepts(doc int, q Query) bool {
+	document := ix.documents[doc]
+	if len(q.Kinds) > 0 {
+		found := false
+		for _, kind := range q.Kinds {
+			found = found || kind == document.Kind
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Type != "" && (document.Kind != Landmark || document.Type != q.Type) {
+		return false
+	}
+	return q.RegionID == 0 || document.RegionID == q.RegionID
+}
@@ -0,0 +1,97 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testIndex indexes some landmarks, a city and a region, their names weighted above their descriptions
+func testIndex() *Index {
+	document := func(kind Kind, id uint, name, landmarkType string, regionID uint, description string) Document {
+		return Document{
+			Kind: kind,
+			ID:   id,
+			Name: name,
+			Fields: []Field{
+				{Name: "name", Text: name, Weight: 3},
+				{Name: "description", Text: description, Weight: 1},
+			},
+			Type:     landmarkType,
+			RegionID: regionID,
+		}
+	}
+	ix := NewIndex()
+	for _, d := range []Document{
+		document(Landmark, 1, "Ura e Gurit", "bridge", 7, "Ottoman stone bridge over the Lumbardhi in Prizren"),
+		document(Landmark, 2, "Kalaja e Prizrenë", "castle", 7, "Fortress above the old town"),
+		document(Landmark, 3, "Hamami i Gazi Mehmed Pashës", "museum", 7, "The largest baths of Prizren, near the Prizren bridge and the Prizren fortress"),
+		document(Landmark, 4, "Ura e Fshehtë", "bridge", 8, "Hidden bridge in the Drin canyon"),
+		document(City, 5, "Prizren", "", 7, ""),
+		document(Region, 7, "Rajoni i Prizrenit", "", 7, ""),
+	} {
+		ix.Add(d)
+	}
+	return ix
+}
+
+// hitIDs returns the ids of the hits in their order
+func hitIDs(hits []Hit) []uint {
+	ids := []uint{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	ix := testIndex()
+	tests := []struct {
+		name  string
+		query Query
+		want  []uint
+		total int
+	}{
+		// The shorter the description, the more a single occurrence weighs
+		{"shorter fields first", Query{Text: "bridge"}, []uint{4, 1, 3}, 3},
+		// The name outweighs the description even when the typo of Prizrenë halves its score
+		{"name before description", Query{Text: "prizren"}, []uint{5, 2, 3, 1}, 4},
+		{"every term must match", Query{Text: "bridge prizren"}, []uint{3, 1}, 2},
+		{"diacritics are ignored", Query{Text: "PASHES"}, []uint{3}, 1},
+		{"typo", Query{Text: "fortres"}, []uint{2, 3}, 2},
+		{"two typos in a long term", Query{Text: "lumbadrhi"}, []uint{1}, 1},
+		{"short terms tolerate no typo", Query{Text: "ira"}, []uint{}, 0},
+		{"kind", Query{Text: "prizren", Kinds: []Kind{City, Region}}, []uint{5}, 1},
+		{"type", Query{Text: "bridge", Type: "bridge"}, []uint{4, 1}, 2},
+		{"region", Query{Text: "bridge", RegionID: 7}, []uint{1, 3}, 2},
+		{"page", Query{Text: "prizren", Offset: 1, Limit: 2}, []uint{2, 3}, 4},
+		{"page beyond the hits", Query{Text: "prizren", Offset: 4}, []uint{}, 4},
+		{"no term", Query{Text: " - "}, []uint{}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hits, total := ix.Search(test.query)
+			if got := hitIDs(hits); !reflect.DeepEqual(got, test.want) || total != test.total {
+				t.Errorf("Search(%+v) = %v of %d, want %v of %d", test.query, got, total, test.want, test.total)
+			}
+			for i := 1; i < len(hits); i++ {
+				if hits[i].Score > hits[i-1].Score {
+					t.Errorf("hit %d scores %g, more than the %g of the one before", i, hits[i].Score, hits[i-1].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchHighlights(t *testing.T) {
+	hits, _ := testIndex().Search(Query{Text: "prizren fortres"})
+	if len(hits) != 2 {
+		t.Fatalf("%d hits, want the castle and the baths", len(hits))
+	}
+	want := map[string]string{"name": "Kalaja e <mark>Prizrenë</mark>", "description": "<mark>Fortress</mark> above the old town"}
+	if hits[0].ID != 2 || !reflect.DeepEqual(hits[0].Highlights, want) {
+		t.Errorf("hit %+v, want the castle highlighted as %v", hits[0], want)
+	}
+	if _, ok := hits[1].Highlights["name"]; ok {
+		t.Errorf("name of the baths highlighted as %q without matching", hits[1].Highlights["name"])
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Suggestion is a document whose name completes a query
+type Suggestion struct {
+	Kind Kind   `json:"kind"`
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Suggest returns up to limit documents of the given kinds, all of them when kinds is empty, with
+// a word of their name or aliases starting with every word of the text. A word without any completion
+// matches the names with a word starting within the typos tolerated by maxEdits. Names starting
+// with the text come first, then the ones with fewer typos, then the shortest ones.
+func (ix *Index) Suggest(text string, kinds []Kind, limit int) []Suggestion {
+	prefixes := terms(text)
+	if len(prefixes) == 0 {
+		return []Suggestion{}
+	}
+	ix.prepare()
+
+	// edits holds the typos of the documents matching every prefix so far
+	var edits map[int]int
+	for _, prefix := range prefixes {
+		variants := []variant{}
+		for _, term := range withPrefix(ix.nameVocabulary, prefix) {
+			variants = append(variants, variant{term: term})
+		}
+		if len(variants) == 0 {
+			variants = similar(ix.nameVocabulary, prefix, true)
+		}
+
+		matched := map[int]int{}
+		for _, v := range variants {
+			for _, doc := range ix.names[v.term] {
+				if previous, ok := matched[doc]; !ok || v.edits < previous {
+					matched[doc] = v.edits
+				}
+			}
+		}
+		if edits == nil {
+			edits = matched
+			continue
+		}
+		for doc, count := range edits {
+			if more, ok := matched[doc]; ok {
+				edits[doc] = count + more
+			} else {
+				delete(edits, doc)
+			}
+		}
+	}
+
+	type candidate struct {
+		doc     int
+		leading bool
+		edits   int
+		length  int
+		name    string
+	}
+	candidates := make([]candidate, 0, len(edits))
+	query := Query{Kinds: kinds}
+	for doc, count := range edits {
+		if !ix.accepts(doc, query) {
+			continue
+		}
+		name := ix.documents[doc].Name
+		words := tokenize(name)
+		candidates = append(candidates, candidate{
+			doc:     doc,
+			leading: len(words) > 0 && strings.HasPrefix(words[0].term, prefixes[0]),
+			edits:   count,
+			length:  utf8.RuneCountInString(name),
+			name:    fold(name),
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.leading != b.leading:
+			return a.leading
+		case a.edits != b.edits:
+			return a.edits < b.edits
+		case a.length != b.length:
+			return a.length < b.length
+		case a.name != b.name:
+			return a.name < b.name
+		}
+		return a.doc < b.doc
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	suggestions := make([]Suggestion, len(candidates))
+	for i, c := range candidates {
+		document := ix.documents[c.doc]
+		suggestions[i] = Suggestion{Kind: document.Kind, ID: document.ID, Name: document.Name, Type: document.Type}
+	}
+	return suggestions
+}
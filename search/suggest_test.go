@@ -0,0 +1,53 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	ix := testIndex()
+	ix.Add(Document{Kind: Landmark, ID: 8, Name: "Shadërvani", Aliases: []string{"Fountain square"}})
+	tests := []struct {
+		name  string
+		text  string
+		kinds []Kind
+		limit int
+		want  []uint
+	}{
+		// Names starting with the text come first, then the shortest
+		{"prefix", "pri", nil, 0, []uint{5, 2, 7}},
+		{"whole word", "prizren", nil, 0, []uint{5, 2, 7}},
+		{"every word completes", "ura g", nil, 0, []uint{1}},
+		{"words in any order", "gurit ura", nil, 0, []uint{1}},
+		{"diacritics are ignored", "pashe", nil, 0, []uint{3}},
+		{"alias", "fount", nil, 0, []uint{8}},
+		{"typo", "przr", nil, 0, []uint{5, 2, 7}},
+		{"no completion", "zz", nil, 0, []uint{}},
+		{"no word", " - ", nil, 0, []uint{}},
+		{"kind", "pri", []Kind{Landmark, Region}, 0, []uint{2, 7}},
+		{"city", "pri", []Kind{City}, 0, []uint{5}},
+		{"limit", "pri", nil, 2, []uint{5, 2}},
+		{"limit beyond the suggestions", "pri", nil, 10, []uint{5, 2, 7}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suggestions := ix.Suggest(test.text, test.kinds, test.limit)
+			ids := []uint{}
+			for _, suggestion := range suggestions {
+				ids = append(ids, suggestion.ID)
+			}
+			if !reflect.DeepEqual(ids, test.want) {
+				t.Errorf("Suggest(%q, %v, %d) = %v, want %v", test.text, test.kinds, test.limit, ids, test.want)
+			}
+		})
+	}
+}
+
+func TestSuggestCompletesNames(t *testing.T) {
+	suggestions := testIndex().Suggest("ura", []Kind{Landmark}, 1)
+	want := []Suggestion{{Kind: Landmark, ID: 1, Name: "Ura e Gurit", Type: "bridge"}}
+	if !reflect.DeepEqual(suggestions, want) {
+		t.Errorf("Suggest() = %+v, want %+v", suggestions, want)
+	}
+}
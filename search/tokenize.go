@@ -0,0 +1,56 @@
+package search
+
+import "unicode"
+
+// token is a word of a text, located by its byte offsets
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// tokenize splits a text into its words, runs of letters and digits, and normalizes them into terms
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = appendToken(tokens, text, start, i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = appendToken(tokens, text, start, len(text))
+	}
+	return tokens
+}
+
+// appendToken appends the word of text between start and end, unless it is only made of combining marks
+func appendToken(tokens []token, text string, start, end int) []token {
+	if term := normalize(text[start:end]); term != "" {
+		tokens = append(tokens, token{term: term, start: start, end: end})
+	}
+	return tokens
+}
+
+// normalize turns a word into the term it is indexed under
+func normalize(word string) string {
+	return fold(word)
+}
+
+// terms returns the distinct terms of a query, in their order of appearance
+func terms(text string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, t := range tokenize(text) {
+		if !seen[t.term] {
+			seen[t.term] = true
+			out = append(out, t.term)
+		}
+	}
+	return out
+}
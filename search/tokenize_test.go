@@ -0,0 +1,66 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"Prizrenë", "prizrene"},
+		{"PRISHTINË", "prishtine"},
+		{"Đakovica", "dakovica"},
+		{"Ćuprija", "cuprija"},
+		{"Şişli", "sisli"},
+		{"Straße", "strasse"},
+		{"Æsir", "aesir"},
+		// e followed by a combining diaeresis
+		{"Prizrene\u0308", "prizrene"},
+		{"Ohrid", "ohrid"},
+		{"1389", "1389"},
+	}
+	for _, test := range tests {
+		if got := fold(test.word); got != test.want {
+			t.Errorf("fold(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	// The lone combining mark is not a word
+	text := "Lidhja e Prizrenit, 1878 — Kalaja \u0301 e Prizrenë!"
+	want := []string{"lidhja", "e", "prizrenit", "1878", "kalaja", "e", "prizrene"}
+	tokens := tokenize(text)
+	if len(tokens) != len(want) {
+		t.Fatalf("tokenize(%q) = %+v, want the terms %q", text, tokens, want)
+	}
+	for i, token := range tokens {
+		if token.term != want[i] {
+			t.Errorf("token %d is %q, want %q", i, token.term, want[i])
+		}
+		// The offsets locate the word as it is written
+		if word := text[token.start:token.end]; fold(word) != token.term {
+			t.Errorf("token %d located at %q, want a word folding to %q", i, word, token.term)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Ura e Gurit", []string{"ura", "e", "gurit"}},
+		{"Prizrene prizrene PRIZRENË", []string{"prizrene"}},
+		{"  ,;- ", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		if got := terms(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("terms(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
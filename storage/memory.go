@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MemoryStorage keeps objects in memory, meant for tests
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+	baseURL string
+}
+
+// NewMemoryStorage creates an empty storage whose objects are reported under baseURL
+func NewMemoryStorage(baseURL string) *MemoryStorage {
+	return &MemoryStorage{objects: map[string][]byte{}, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *MemoryStorage) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *MemoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *MemoryStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
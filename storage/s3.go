@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Storage stores objects in an S3 bucket
+type S3Storage struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Storage creates an S3 backed storage using the default AWS credential chain
+func NewS3Storage(ctx context.Context, bucket string) (*S3Storage, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %v", err)
+	}
+	return &S3Storage{client: s3.NewFromConfig(cfg), bucket: bucket}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	buffer := new(bytes.Buffer)
+	if _, err := buffer.ReadFrom(body); err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+	contentLength := int64(buffer.Len())
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(buffer.Bytes()),
+		ContentLength: &contentLength,
+		ContentType:   aws.String(contentType),
+	}
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to upload file to S3: %v", err)
+	}
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to download file from S3: %v", err)
+	}
+	return output.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete file from S3: %v", err)
+	}
+	return nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, key)
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"landmarksmodule/config"
+	"strings"
+)
+
+// ErrNotFound is returned by Get and Delete when no object exists for the key
+var ErrNotFound = errors.New("object not found")
+
+// Storage is a backend able to persist uploaded photos under a key
+type Storage interface {
+	// Put stores the content of body under key
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get opens the object stored under key, the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL under which the object is reachable
+	URL(key string) string
+}
+
+// Store is the storage backend used by the handlers
+var Store Storage
+
+// Init creates the storage backend selected by cfg
+func Init(cfg config.StorageConfig) error {
+	switch cfg.Backend {
+	case "s3":
+		s3Storage, err := NewS3Storage(context.TODO(), cfg.Bucket)
+		if err != nil {
+			return err
+		}
+		Store = s3Storage
+	case "local":
+		localStorage, err := NewLocalStorage(cfg.LocalDir, cfg.BaseURL)
+		if err != nil {
+			return err
+		}
+		Store = localStorage
+	default:
+		return fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+	return nil
+}
+
+// KeyFromURL returns the key of an object from the URL produced by s.URL.
+// The second return value is false if the URL does not belong to the backend.
+func KeyFromURL(s Storage, url string) (string, bool) {
+	prefix := s.URL("")
+	if !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(url, prefix), true
+}
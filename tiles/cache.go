@@ -0,0 +1,222 @@
+package tiles
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"landmarksmodule/config"
+)
+
+// Cache keeps rendered tiles under a key
+type Cache interface {
+	// Get returns the tile stored under key, if any
+	Get(key string) ([]byte, bool)
+	// Put stores a tile under key
+	Put(key string, data []byte)
+	// Clear removes every tile
+	Clear()
+}
+
+var (
+	// mu orders invalidations with the storage of tiles rendered before them
+	mu         sync.Mutex
+	cache      Cache = noCache{}
+	generation uint64
+)
+
+// Init creates the tile cache selected by cfg
+func Init(cfg config.TilesConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch cfg.Cache {
+	case "memory":
+		cache = NewMemoryCache(cfg.CacheSize)
+	case "disk":
+		diskCache, err := NewDiskCache(cfg.CacheDir)
+		if err != nil {
+			return err
+		}
+		cache = diskCache
+	case "off":
+		cache = noCache{}
+	default:
+		return fmt.Errorf("unknown tile cache %q", cfg.Cache)
+	}
+	return nil
+}
+
+// Invalidate drops every cached tile after a change of the data drawn on them
+func Invalidate() {
+	mu.Lock()
+	defer mu.Unlock()
+	generation++
+	cache.Clear()
+}
+
+// Render returns the cached tile, or renders and caches it. A tile whose data changed
+// while it was being rendered is returned but not cached. Renderings of the same tile differing
+// in variant, such as the language of the names, are cached apart, the empty variant being the default.
+func Render(tile Tile, variant string, render func() ([]byte, error)) ([]byte, error) {
+	key := tile.String()
+	if variant != "" {
+		key += "@" + variant
+	}
+
+	mu.Lock()
+	data, ok := cache.Get(key)
+	started := generation
+	mu.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	data, err := render()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if generation == started {
+		cache.Put(key, data)
+	}
+	return data, nil
+}
+
+// noCache renders every tile again
+type noCache struct{}
+
+func (noCache) Get(key string) ([]byte, bool) { return nil, false }
+func (noCache) Put(key string, data []byte)   {}
+func (noCache) Clear()                        {}
+
+// MemoryCache keeps the most recently used tiles in memory
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key  string
+	data []byte
+}
+
+// NewMemoryCache creates a cache holding at most size tiles
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return element.Value.(*memoryEntry).data, true
+}
+
+func (m *MemoryCache) Put(key string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		element.Value.(*memoryEntry).data = data
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, data: data})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (m *MemoryCache) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.order.Init()
+	m.entries = map[string]*list.Element{}
+}
+
+// DiskCache keeps tiles as files below a directory, named after their z/x/y key
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a cache writing to dir, emptying it first since its tiles may be outdated
+func NewDiskCache(dir string) (*DiskCache, error) {
+	d := &DiskCache{dir: dir}
+	d.Clear()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create tile cache directory: %v", err)
+	}
+	return d, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, filepath.FromSlash(key)+".mvt")
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to read cached tile %s: %v", key, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+func (d *DiskCache) Put(key string, data []byte) {
+	filePath := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		log.Printf("Failed to cache tile %s: %v", key, err)
+		return
+	}
+
+	// Write to a temporary file first so that readers never see a partial tile
+	temp, err := os.CreateTemp(filepath.Dir(filePath), ".tile-*")
+	if err != nil {
+		log.Printf("Failed to cache tile %s: %v", key, err)
+		return
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), filePath)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		log.Printf("Failed to cache tile %s: %v", key, err)
+	}
+}
+
+func (d *DiskCache) Clear() {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Failed to clear the tile cache: %v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(d.dir, entry.Name())); err != nil {
+			log.Printf("Failed to clear the tile cache: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tiles
+
+import "testing"
+
+// useMemoryCache replaces the tile cache by a memory cache for the duration of a test
+func useMemoryCache(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	previous := cache
+	cache = NewMemoryCache(10)
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		cache = previous
+	})
+}
+
+// renderer returns a render function counting its calls and returning data
+func renderer(calls *int, data string, during func()) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		*calls++
+		if during != nil {
+			during()
+		}
+		return []byte(data), nil
+	}
+}
+
+func TestRenderCachesTiles(t *testing.T) {
+	useMemoryCache(t)
+	tile, _ := NewTile(3, 4, 2)
+	calls := 0
+
+	for i := 0; i < 2; i++ {
+		data, err := Render(tile, "", renderer(&calls, "default", nil))
+		if err != nil || string(data) != "default" {
+			t.Fatalf("Render returned %q, %v", data, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("tile rendered %d times, want once", calls)
+	}
+
+	// Variants are cached apart from the default rendering
+	data, _ := Render(tile, "de", renderer(&calls, "german", nil))
+	if string(data) != "german" || calls != 2 {
+		t.Errorf("variant rendered as %q after %d renderings, want its own rendering", data, calls)
+	}
+
+	Invalidate()
+	data, _ = Render(tile, "", renderer(&calls, "changed", nil))
+	if string(data) != "changed" || calls != 3 {
+		t.Errorf("tile rendered as %q after %d renderings following an invalidation, want it rendered again", data, calls)
+	}
+}
+
+func TestRenderDoesNotCacheTileInvalidatedWhileRendering(t *testing.T) {
+	useMemoryCache(t)
+	tile, _ := NewTile(3, 4, 2)
+	calls := 0
+
+	// The data change while the tile is rendered, so the rendering may be outdated already
+	data, err := Render(tile, "", renderer(&calls, "outdated", Invalidate))
+	if err != nil || string(data) != "outdated" {
+		t.Fatalf("Render returned %q, %v, want the rendered tile", data, err)
+	}
+	if _, ok := cache.Get(tile.String()); ok {
+		t.Error("tile invalidated while rendering was cached")
+	}
+
+	data, _ = Render(tile, "", renderer(&calls, "current", nil))
+	if string(data) != "current" || calls != 2 {
+		t.Errorf("Render returned %q after %d renderings, want the tile rendered again", data, calls)
+	}
+	data, _ = Render(tile, "", renderer(&calls, "unexpected", nil))
+	if string(data) != "current" || calls != 2 {
+		t.Errorf("Render returned %q after %d renderings, want the cached tile", data, calls)
+	}
+}
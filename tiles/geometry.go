@@ -0,0 +1,162 @@
+package tiles
+
+import (
+	"math"
+
+	"landmarksmodule/geo"
+)
+
+// Tolerance is the distance in tile coordinates below which details of polygons are
+// simplified away, a quarter of a pixel of a 256 pixel tile
+const Tolerance = Extent / 256 / 4
+
+type vertex struct {
+	x float64
+	y float64
+}
+
+// Point projects a position to the tile, reporting false if it lies outside the tile and its buffer
+func (t Tile) Point(p geo.Point) (Position, bool) {
+	x, y := t.project(p)
+	if x < -Buffer || x > Extent+Buffer || y < -Buffer || y > Extent+Buffer {
+		return Position{}, false
+	}
+	return Position{X: int(math.Round(x)), Y: int(math.Round(y))}, true
+}
+
+// Polygons projects the polygons of a shape to the tile, clipped to the tile and its buffer.
+// Each zoom level gets its own simplification, since Tolerance is measured in tile coordinates.
+// Polygons which vanish are left out.
+func (t Tile) Polygons(shape *geo.Shape) [][][]Position {
+	var polygons [][][]Position
+	for _, polygon := range shape.Polygons {
+		var rings [][]Position
+		for i, ring := range polygon {
+			projected := make([]vertex, len(ring))
+			for j, p := range ring {
+				projected[j].x, projected[j].y = t.project(p)
+			}
+			positions := quantize(simplify(clip(projected), Tolerance))
+			if len(positions) < 3 {
+				if i == 0 {
+					// Without its exterior the holes are meaningless
+					break
+				}
+				continue
+			}
+			rings = append(rings, positions)
+		}
+		if len(rings) > 0 {
+			polygons = append(polygons, rings)
+		}
+	}
+	return polygons
+}
+
+// clip cuts a ring along the edges of the tile buffer with the Sutherland-Hodgman algorithm
+func clip(ring []vertex) []vertex {
+	const min, max = -Buffer, Extent + Buffer
+	edges := []struct {
+		inside    func(v vertex) bool
+		intersect func(a, b vertex) vertex
+	}{
+		{func(v vertex) bool { return v.x >= min }, func(a, b vertex) vertex { return atX(a, b, min) }},
+		{func(v vertex) bool { return v.x <= max }, func(a, b vertex) vertex { return atX(a, b, max) }},
+		{func(v vertex) bool { return v.y >= min }, func(a, b vertex) vertex { return atY(a, b, min) }},
+		{func(v vertex) bool { return v.y <= max }, func(a, b vertex) vertex { return atY(a, b, max) }},
+	}
+
+	for _, edge := range edges {
+		if len(ring) == 0 {
+			return nil
+		}
+		var out []vertex
+		previous := ring[len(ring)-1]
+		for _, current := range ring {
+			if edge.inside(current) {
+				if !edge.inside(previous) {
+					out = append(out, edge.intersect(previous, current))
+				}
+				out = append(out, current)
+			} else if edge.inside(previous) {
+				out = append(out, edge.intersect(previous, current))
+			}
+			previous = current
+		}
+		ring = out
+	}
+	return ring
+}
+
+func atX(a, b vertex, x float64) vertex {
+	return vertex{x: x, y: a.y + (b.y-a.y)*(x-a.x)/(b.x-a.x)}
+}
+
+func atY(a, b vertex, y float64) vertex {
+	return vertex{x: a.x + (b.x-a.x)*(y-a.y)/(b.y-a.y), y: y}
+}
+
+// simplify removes the vertices of a ring closer than tolerance to the line joining their
+// neighbours, with the Douglas-Peucker algorithm
+func simplify(ring []vertex, tolerance float64) []vertex {
+	if len(ring) < 4 {
+		return ring
+	}
+
+	keep := make([]bool, len(ring))
+	keep[0], keep[len(ring)-1] = true, true
+	var visit func(first, last int)
+	visit = func(first, last int) {
+		farthest, distance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(ring[i], ring[first], ring[last]); d > distance {
+				farthest, distance = i, d
+			}
+		}
+		if farthest >= 0 {
+			keep[farthest] = true
+			visit(first, farthest)
+			visit(farthest, last)
+		}
+	}
+	visit(0, len(ring)-1)
+
+	var out []vertex
+	for i, v := range ring {
+		if keep[i] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// segmentDistance returns the distance between p and the segment from a to b
+func segmentDistance(p, a, b vertex) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p.x-a.x, p.y-a.y)
+	}
+	t := ((p.x-a.x)*dx + (p.y-a.y)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.x-(a.x+t*dx), p.y-(a.y+t*dy))
+}
+
+// quantize rounds the vertices of a ring to integer positions, dropping repeated positions
+// and the closing position of GeoJSON rings
+func quantize(ring []vertex) []Position {
+	var out []Position
+	for _, v := range ring {
+		p := Position{X: int(math.Round(v.x)), Y: int(math.Round(v.y))}
+		if len(out) > 0 && out[len(out)-1] == p {
+			continue
+		}
+		out = append(out, p)
+	}
+	for len(out) > 1 && out[len(out)-1] == out[0] {
+		out = out[:len(out)-1]
+	}
+	if len(out) >= 3 && ringArea(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,308 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// GeomType is the type of the geometry of a feature
+type GeomType int
+
+// Geometry types of the vector tile specification, line strings are not used
+const (
+	PointGeometry   GeomType = 1
+	PolygonGeometry GeomType = 3
+)
+
+// Position is a position in tile coordinates
+type Position struct {
+	X int
+	Y int
+}
+
+// Feature is a feature of a layer. Points use Points, polygons use Polygons
+// whose rings are not closed, the first ring of each being the exterior.
+type Feature struct {
+	ID         uint64
+	Type       GeomType
+	Points     []Position
+	Polygons   [][][]Position
+	Properties map[string]interface{}
+}
+
+// Layer is a named set of features
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+// Commands of the geometry encoding
+const (
+	moveTo    = 1
+	lineTo    = 2
+	closePath = 7
+)
+
+// Field numbers of the vector tile protobuf messages
+const (
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueSint   = 6
+	valueUint   = 5
+	valueBool   = 7
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// Encode serializes the layers to a vector tile, version 2 of the specification.
+// Properties may be strings, booleans, integers and floats, other values are formatted as strings.
+func Encode(layers []Layer) []byte {
+	var tile protoBuffer
+	for _, layer := range layers {
+		if len(layer.Features) > 0 {
+			tile.bytes(tileLayers, encodeLayer(layer))
+		}
+	}
+	return tile.data
+}
+
+func encodeLayer(layer Layer) []byte {
+	var keys []string
+	keyIndex := map[string]int{}
+	var values [][]byte
+	valueIndex := map[interface{}]int{}
+
+	var out protoBuffer
+	out.uint(layerVersion, 2)
+	out.string(layerName, layer.Name)
+
+	for _, feature := range layer.Features {
+		geometry := encodeGeometry(feature)
+		if len(geometry) == 0 {
+			continue
+		}
+
+		var tags []uint32
+		for _, key := range sortedKeys(feature.Properties) {
+			value := normalizeValue(feature.Properties[key])
+			if value == nil {
+				continue
+			}
+			k, ok := keyIndex[key]
+			if !ok {
+				k = len(keys)
+				keys = append(keys, key)
+				keyIndex[key] = k
+			}
+			v, ok := valueIndex[value]
+			if !ok {
+				v = len(values)
+				values = append(values, encodeValue(value))
+				valueIndex[value] = v
+			}
+			tags = append(tags, uint32(k), uint32(v))
+		}
+
+		var f protoBuffer
+		if feature.ID != 0 {
+			f.uint(featureID, feature.ID)
+		}
+		f.packed(featureTags, tags)
+		f.uint(featureType, uint64(feature.Type))
+		f.packed(featureGeometry, geometry)
+		out.bytes(layerFeatures, f.data)
+	}
+
+	for _, key := range keys {
+		out.string(layerKeys, key)
+	}
+	for _, value := range values {
+		out.bytes(layerValues, value)
+	}
+	out.uint(layerExtent, Extent)
+	return out.data
+}
+
+// normalizeValue converts a property to one of string, bool, int64, uint64 and float64, or nil to omit it
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string, bool, int64, uint64, float64:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case float32:
+		return float64(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys(properties map[string]interface{}) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func encodeValue(value interface{}) []byte {
+	var out protoBuffer
+	switch v := value.(type) {
+	case string:
+		out.string(valueString, v)
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		out.uint(valueBool, b)
+	case int64:
+		out.uint(valueSint, uint64((v<<1)^(v>>63)))
+	case uint64:
+		out.uint(valueUint, v)
+	case float64:
+		out.double(valueDouble, v)
+	}
+	return out.data
+}
+
+// encodeGeometry encodes the positions of the feature as commands relative to the previous position
+func encodeGeometry(feature Feature) []uint32 {
+	var g geometryEncoder
+	switch feature.Type {
+	case PointGeometry:
+		if len(feature.Points) == 0 {
+			return nil
+		}
+		g.command(moveTo, len(feature.Points))
+		for _, p := range feature.Points {
+			g.position(p)
+		}
+	case PolygonGeometry:
+		for _, polygon := range feature.Polygons {
+			for i, ring := range polygon {
+				if len(ring) < 3 {
+					continue
+				}
+				// Exterior rings have a positive area, holes a negative one
+				if area := ringArea(ring); (i == 0) != (area > 0) {
+					ring = reversed(ring)
+				}
+				g.command(moveTo, 1)
+				g.position(ring[0])
+				g.command(lineTo, len(ring)-1)
+				for _, p := range ring[1:] {
+					g.position(p)
+				}
+				g.command(closePath, 1)
+			}
+		}
+	}
+	return g.data
+}
+
+type geometryEncoder struct {
+	data   []uint32
+	cursor Position
+}
+
+func (g *geometryEncoder) command(id, count int) {
+	g.data = append(g.data, uint32(id&0x7|count<<3))
+}
+
+func (g *geometryEncoder) position(p Position) {
+	dx, dy := int32(p.X-g.cursor.X), int32(p.Y-g.cursor.Y)
+	g.data = append(g.data, uint32((dx<<1)^(dx>>31)), uint32((dy<<1)^(dy>>31)))
+	g.cursor = p
+}
+
+// ringArea returns twice the signed area of the ring, positive when it runs clockwise on screen
+func ringArea(ring []Position) int64 {
+	var sum int64
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		sum += int64(ring[j].X)*int64(ring[i].Y) - int64(ring[i].X)*int64(ring[j].Y)
+	}
+	return sum
+}
+
+func reversed(ring []Position) []Position {
+	out := make([]Position, len(ring))
+	for i, p := range ring {
+		out[len(ring)-1-i] = p
+	}
+	return out
+}
+
+// protoBuffer writes protobuf fields
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(v uint64) {
+	b.data = binary.AppendUvarint(b.data, v)
+}
+
+func (b *protoBuffer) key(field, wireType int) {
+	b.varint(uint64(field<<3 | wireType))
+}
+
+func (b *protoBuffer) uint(field int, v uint64) {
+	b.key(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *protoBuffer) double(field int, v float64) {
+	b.key(field, wireFixed64)
+	b.data = binary.LittleEndian.AppendUint64(b.data, math.Float64bits(v))
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *protoBuffer) packed(field int, values []uint32) {
+	if len(values) == 0 {
+		return
+	}
+	var packed protoBuffer
+	for _, v := range values {
+		packed.varint(uint64(v))
+	}
+	b.bytes(field, packed.data)
+}
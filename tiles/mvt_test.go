@@ -0,0 +1,356 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"landmarksmodule/geo"
+)
+
+// decodedLayer is a layer read back from an encoded tile
+type decodedLayer struct {
+	name     string
+	version  uint64
+	extent   uint64
+	features []decodedFeature
+}
+
+// decodedFeature is a feature read back from an encoded tile, its rings closed implicitly
+type decodedFeature struct {
+	id         uint64
+	geomType   GeomType
+	properties map[string]interface{}
+	points     []Position
+	rings      [][]Position
+}
+
+// protoField is a field of a protobuf message, value holding varints and fixed64 values
+type protoField struct {
+	number int
+	value  uint64
+	bytes  []byte
+}
+
+// readFields splits a protobuf message into its fields
+func readFields(t *testing.T, data []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("invalid field key in % x", data)
+		}
+		data = data[n:]
+		field := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case wireVarint:
+			field.value, n = binary.Uvarint(data)
+			if n <= 0 {
+				t.Fatalf("invalid varint of field %d", field.number)
+			}
+			data = data[n:]
+		case wireFixed64:
+			field.value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				t.Fatalf("invalid length of field %d", field.number)
+			}
+			field.bytes, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d of field %d", key&7, field.number)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func readPacked(t *testing.T, data []byte) []uint32 {
+	t.Helper()
+	var values []uint32
+	for len(data) > 0 {
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatal("invalid packed varint")
+		}
+		values, data = append(values, uint32(value)), data[n:]
+	}
+	return values
+}
+
+func unzigzag(value uint32) int {
+	return int(int32(value>>1) ^ -int32(value&1))
+}
+
+// decode reads an encoded tile back, following version 2 of the vector tile specification
+func decode(t *testing.T, data []byte) []decodedLayer {
+	t.Helper()
+	var layers []decodedLayer
+	for _, tileField := range readFields(t, data) {
+		if tileField.number != tileLayers {
+			t.Fatalf("unexpected tile field %d", tileField.number)
+		}
+		var layer decodedLayer
+		var keys []string
+		var values []interface{}
+		var features [][]protoField
+		for _, field := range readFields(t, tileField.bytes) {
+			switch field.number {
+			case layerName:
+				layer.name = string(field.bytes)
+			case layerVersion:
+				layer.version = field.value
+			case layerExtent:
+				layer.extent = field.value
+			case layerKeys:
+				keys = append(keys, string(field.bytes))
+			case layerValues:
+				values = append(values, decodeValue(t, field.bytes))
+			case layerFeatures:
+				features = append(features, readFields(t, field.bytes))
+			}
+		}
+
+		for _, fields := range features {
+			feature := decodedFeature{properties: map[string]interface{}{}}
+			var geometry []uint32
+			for _, field := range fields {
+				switch field.number {
+				case featureID:
+					feature.id = field.value
+				case featureType:
+					feature.geomType = GeomType(field.value)
+				case featureTags:
+					tags := readPacked(t, field.bytes)
+					for i := 0; i+1 < len(tags); i += 2 {
+						feature.properties[keys[tags[i]]] = values[tags[i+1]]
+					}
+				case featureGeometry:
+					geometry = readPacked(t, field.bytes)
+				}
+			}
+			feature.points, feature.rings = decodeGeometry(t, geometry)
+			layer.features = append(layer.features, feature)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+func decodeValue(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	fields := readFields(t, data)
+	if len(fields) != 1 {
+		t.Fatalf("value has %d fields", len(fields))
+	}
+	switch field := fields[0]; field.number {
+	case valueString:
+		return string(field.bytes)
+	case valueDouble:
+		return math.Float64frombits(field.value)
+	case valueSint:
+		return int64(field.value>>1) ^ -int64(field.value&1)
+	case valueUint:
+		return field.value
+	case valueBool:
+		return field.value == 1
+	default:
+		t.Fatalf("unexpected value field %d", field.number)
+		return nil
+	}
+}
+
+// decodeGeometry runs the commands of a geometry, returning the positions of MoveTo commands
+// which are not followed by a LineTo as points and the others as rings
+func decodeGeometry(t *testing.T, geometry []uint32) ([]Position, [][]Position) {
+	t.Helper()
+	var points []Position
+	var rings [][]Position
+	var cursor Position
+	var ring []Position
+	for len(geometry) > 0 {
+		id, count := int(geometry[0]&0x7), int(geometry[0]>>3)
+		geometry = geometry[1:]
+		switch id {
+		case moveTo, lineTo:
+			if len(geometry) < 2*count {
+				t.Fatalf("command %d lacks parameters", id)
+			}
+			for i := 0; i < count; i++ {
+				cursor.X += unzigzag(geometry[2*i])
+				cursor.Y += unzigzag(geometry[2*i+1])
+				if id == moveTo {
+					if len(ring) == 1 {
+						points = append(points, ring[0])
+					}
+					ring = nil
+				}
+				ring = append(ring, cursor)
+			}
+			geometry = geometry[2*count:]
+		case closePath:
+			rings, ring = append(rings, ring), nil
+		default:
+			t.Fatalf("unexpected command %d", id)
+		}
+	}
+	if len(ring) == 1 {
+		points = append(points, ring[0])
+	}
+	return points, rings
+}
+
+// singleFeature decodes a tile expected to hold one layer of one feature
+func singleFeature(t *testing.T, data []byte) decodedFeature {
+	t.Helper()
+	layers := decode(t, data)
+	if len(layers) != 1 || len(layers[0].features) != 1 {
+		t.Fatalf("decoded %+v, want one layer of one feature", layers)
+	}
+	if layers[0].version != 2 || layers[0].extent != Extent {
+		t.Errorf("layer has version %d and extent %d, want 2 and %d", layers[0].version, layers[0].extent, Extent)
+	}
+	return layers[0].features[0]
+}
+
+func TestEncodePoint(t *testing.T) {
+	tile, _ := NewTile(0, 0, 0)
+	position, ok := tile.Point(geo.Point{Lng: 0, Lat: 0})
+	if !ok || position != (Position{X: 2048, Y: 2048}) {
+		t.Fatalf("point projected to %+v, %v, want the middle of the tile", position, ok)
+	}
+
+	data := Encode([]Layer{{Name: "landmarks", Features: []Feature{{
+		ID:     7,
+		Type:   PointGeometry,
+		Points: []Position{position},
+		Properties: map[string]interface{}{
+			"name": "Stone Bridge", "rating": 4.5, "reviews": 3, "views": uint(12), "open": true, "note": nil,
+		},
+	}}}})
+
+	feature := singleFeature(t, data)
+	if feature.id != 7 || feature.geomType != PointGeometry {
+		t.Errorf("feature has id %d and type %d", feature.id, feature.geomType)
+	}
+	if !reflect.DeepEqual(feature.points, []Position{{X: 2048, Y: 2048}}) || len(feature.rings) != 0 {
+		t.Errorf("points %+v and rings %+v, want the middle point", feature.points, feature.rings)
+	}
+	wantProperties := map[string]interface{}{
+		"name": "Stone Bridge", "rating": 4.5, "reviews": int64(3), "views": uint64(12), "open": true,
+	}
+	if !reflect.DeepEqual(feature.properties, wantProperties) {
+		t.Errorf("properties %v, want %v", feature.properties, wantProperties)
+	}
+}
+
+func TestEncodePointBytes(t *testing.T) {
+	data := Encode([]Layer{{Name: "p", Features: []Feature{{Type: PointGeometry, Points: []Position{{X: 2048, Y: 2048}}}}}})
+	want := []byte{
+		0x1a, 0x13, // layer of 19 bytes
+		0x78, 0x02, // version 2
+		0x0a, 0x01, 'p', // name
+		0x12, 0x09, // feature of 9 bytes, without id nor tags
+		0x18, 0x01, // point
+		0x22, 0x05, 0x09, 0x80, 0x20, 0x80, 0x20, // MoveTo(1) by the zigzag coded 2048, 2048
+		0x28, 0x80, 0x20, // extent 4096
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("encoded % x, want % x", data, want)
+	}
+	if Encode([]Layer{{Name: "empty"}}) != nil {
+		t.Error("a tile without features is not empty")
+	}
+}
+
+// shape parses a GeoJSON geometry
+func shape(t *testing.T, geoJSON string) *geo.Shape {
+	t.Helper()
+	s, err := geo.ParseShape([]byte(geoJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestEncodePolygonClippedAtTileEdge(t *testing.T) {
+	// The north western tile of zoom 1 ends at the equator and the prime meridian, which the square crosses
+	tile, _ := NewTile(1, 0, 0)
+	polygons := tile.Polygons(shape(t, `{"type":"Polygon","coordinates":[[[-10,-10],[10,-10],[10,10],[-10,10],[-10,-10]]]}`))
+
+	feature := singleFeature(t, Encode([]Layer{{Name: "regions", Features: []Feature{{ID: 1, Type: PolygonGeometry, Polygons: polygons}}}}))
+	if feature.geomType != PolygonGeometry || len(feature.rings) != 1 {
+		t.Fatalf("feature of type %d has rings %+v, want one ring", feature.geomType, feature.rings)
+	}
+	ring := feature.rings[0]
+	if ringArea(ring) <= 0 {
+		t.Errorf("exterior ring %+v is not clockwise", ring)
+	}
+
+	// The west and north sides of the square lie inside the tile, the others are cut at its buffer
+	northWest := tile.mustPoint(t, geo.Point{Lng: -10, Lat: 10})
+	var minX, minY, maxX, maxY = math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	for _, p := range ring {
+		minX, minY = min(minX, p.X), min(minY, p.Y)
+		maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+	}
+	if maxX != Extent+Buffer || maxY != Extent+Buffer {
+		t.Errorf("ring reaches %d, %d, want the buffer edge %d", maxX, maxY, Extent+Buffer)
+	}
+	if minX != northWest.X || minY != northWest.Y {
+		t.Errorf("ring starts at %d, %d, want the north western corner %+v", minX, minY, northWest)
+	}
+	if len(ring) != 4 {
+		t.Errorf("ring %+v, want the 4 corners of the clipped square", ring)
+	}
+}
+
+// mustPoint projects a point expected to lie on the tile
+func (tile Tile) mustPoint(t *testing.T, p geo.Point) Position {
+	t.Helper()
+	position, ok := tile.Point(p)
+	if !ok {
+		t.Fatalf("%+v is outside tile %s", p, tile)
+	}
+	return position
+}
+
+func TestEncodePolygonWithHole(t *testing.T) {
+	tile, _ := NewTile(0, 0, 0)
+	// Both rings run the same way, the encoder winds the hole against the exterior
+	polygons := tile.Polygons(shape(t, `{"type":"Polygon","coordinates":[
+		[[-90,-60],[90,-60],[90,60],[-90,60],[-90,-60]],
+		[[-45,-30],[45,-30],[45,30],[-45,30],[-45,-30]]
+	]}`))
+
+	feature := singleFeature(t, Encode([]Layer{{Name: "regions", Features: []Feature{{ID: 2, Type: PolygonGeometry, Polygons: polygons}}}}))
+	if len(feature.rings) != 2 {
+		t.Fatalf("rings %+v, want an exterior and a hole", feature.rings)
+	}
+	exterior, hole := feature.rings[0], feature.rings[1]
+	if ringArea(exterior) <= 0 || ringArea(hole) >= 0 {
+		t.Errorf("exterior area %d and hole area %d, want a positive and a negative one", ringArea(exterior), ringArea(hole))
+	}
+
+	corners := func(ring []Position) map[Position]bool {
+		set := map[Position]bool{}
+		for _, p := range ring {
+			set[p] = true
+		}
+		return set
+	}
+	wantExterior := map[Position]bool{}
+	for _, p := range []geo.Point{{Lng: -90, Lat: -60}, {Lng: 90, Lat: -60}, {Lng: 90, Lat: 60}, {Lng: -90, Lat: 60}} {
+		wantExterior[tile.mustPoint(t, p)] = true
+	}
+	wantHole := map[Position]bool{}
+	for _, p := range []geo.Point{{Lng: -45, Lat: -30}, {Lng: 45, Lat: -30}, {Lng: 45, Lat: 30}, {Lng: -45, Lat: 30}} {
+		wantHole[tile.mustPoint(t, p)] = true
+	}
+	if !reflect.DeepEqual(corners(exterior), wantExterior) || !reflect.DeepEqual(corners(hole), wantHole) {
+		t.Errorf("exterior %+v and hole %+v, want the corners of both squares", exterior, hole)
+	}
+}
@@ -0,0 +1,76 @@
+// Package tiles renders Mapbox Vector Tiles of the web mercator tiling scheme and caches them
+package tiles
+
+import (
+	"fmt"
+	"math"
+
+	"landmarksmodule/geo"
+)
+
+const (
+	// Extent is the size of a tile in tile coordinates
+	Extent = 4096
+	// Buffer is the margin in tile coordinates kept around a tile, so that
+	// geometries crossing its edges are drawn without seams
+	Buffer = 64
+	// MaxZoom is the deepest zoom level served
+	MaxZoom = 22
+	// maxLatitude is the latitude at which the web mercator projection is cut to a square
+	maxLatitude = 85.05112878
+)
+
+// Tile identifies a tile by its zoom level and column and row, row 0 being the northernmost
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+// NewTile checks the coordinates of a tile
+func NewTile(z, x, y int) (Tile, error) {
+	if z < 0 || z > MaxZoom {
+		return Tile{}, fmt.Errorf("zoom must be between 0 and %d", MaxZoom)
+	}
+	n := 1 << z
+	if x < 0 || x >= n || y < 0 || y >= n {
+		return Tile{}, fmt.Errorf("x and y must be between 0 and %d at zoom %d", n-1, z)
+	}
+	return Tile{Z: z, X: x, Y: y}, nil
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// BBox returns the area covered by the tile and its buffer, in degrees
+func (t Tile) BBox() geo.BBox {
+	n := math.Exp2(float64(t.Z))
+	margin := float64(Buffer) / Extent
+	box := geo.BBox{
+		MinLng: tileLng(float64(t.X)-margin, n),
+		MinLat: tileLat(float64(t.Y+1)+margin, n),
+		MaxLng: tileLng(float64(t.X+1)+margin, n),
+		MaxLat: tileLat(float64(t.Y)-margin, n),
+	}
+	box.MinLng = math.Max(box.MinLng, -180)
+	box.MaxLng = math.Min(box.MaxLng, 180)
+	return box
+}
+
+func tileLng(x, n float64) float64 {
+	return x/n*360 - 180
+}
+
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+// project converts a position to the coordinates of the tile, which grow eastwards and southwards
+func (t Tile) project(p geo.Point) (float64, float64) {
+	n := math.Exp2(float64(t.Z))
+	lat := math.Max(-maxLatitude, math.Min(maxLatitude, p.Lat)) * math.Pi / 180
+	x := (p.Lng+180)/360*n - float64(t.X)
+	y := (0.5-math.Log(math.Tan(lat)+1/math.Cos(lat))/(2*math.Pi))*n - float64(t.Y)
+	return x * Extent, y * Extent
+}